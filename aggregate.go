@@ -0,0 +1,68 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Aggregates sums up the values of a numeric field across a collection
+type Aggregates struct {
+	Count   int     // records holding a number in the field
+	Skipped int     // records lacking the field or holding something else in it
+	Sum     float64 // sum of the values
+	Min     float64 // smallest value; zero without any
+	Max     float64 // largest value; zero without any
+	Avg     float64 // mean of the values; zero without any
+}
+
+// Aggregate reads every record of [collection] once and returns the count,
+// sum, minimum, maximum and average of the numbers in their top-level
+// [field]. Records without the field, or where it isn't a number, are
+// counted as skipped.
+func (d *Driver) Aggregate(collection, field string) (agg Aggregates, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "aggregate", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return Aggregates{}, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Aggregates{}, err
+	}
+
+	for _, name := range names {
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(b) == 0) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+		if err != nil {
+			return Aggregates{}, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		key := recordSortKey(name, b, field)
+		if key.rank != sortNumber {
+			agg.Skipped++
+			continue
+		}
+
+		if agg.Count == 0 || key.num < agg.Min {
+			agg.Min = key.num
+		}
+		if agg.Count == 0 || key.num > agg.Max {
+			agg.Max = key.num
+		}
+		agg.Sum += key.num
+		agg.Count++
+	}
+
+	if agg.Count > 0 {
+		agg.Avg = agg.Sum / float64(agg.Count)
+	}
+
+	return agg, nil
+}
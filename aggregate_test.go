@@ -0,0 +1,36 @@
+package jsondb
+
+import (
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	records := map[string]interface{}{
+		"a": map[string]interface{}{"amount": 10},
+		"b": map[string]interface{}{"amount": 2.5},
+		"c": map[string]interface{}{"amount": -4},
+		"d": map[string]interface{}{"amount": "lots"},
+		"e": map[string]interface{}{"price": 3},
+	}
+	for name, v := range records {
+		if err := d.Write(collection, name, v); err != nil {
+			t.Fatal("Create order failed: ", err.Error())
+		}
+	}
+
+	agg, err := d.Aggregate(collection, "amount")
+	if err != nil {
+		t.Fatal("Failed to aggregate: ", err.Error())
+	}
+
+	want := Aggregates{Count: 3, Skipped: 2, Sum: 8.5, Min: -4, Max: 10, Avg: 8.5 / 3}
+	if agg != want {
+		t.Error("Expected ", want, ", got: ", agg)
+	}
+
+	if agg, err := d.Aggregate("missing", "amount"); err != nil || agg != (Aggregates{}) {
+		t.Error("Expected nothing aggregated in a missing collection, got: ", agg, err)
+	}
+}
@@ -0,0 +1,116 @@
+package jsondb
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// Backup writes the whole database to [w] as a single JSON document mapping
+// collection to resource to record, the shape Dump returns. It holds read
+// locks on every collection so the backup is consistent, but streams the
+// records one at a time rather than reading the database into memory.
+// Reserved names and expired records are left out, and so are collections
+// without records of their own. Every record must be JSON, which a custom
+// Options.Codec may not write.
+func (d *Driver) Backup(w io.Writer) error {
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := d.rlockCollections(collections...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+
+	now := time.Now()
+	first := true
+	for _, collection := range collections {
+		names, err := d.list(collection)
+		if err != nil {
+			return &OpError{Op: "backup", Collection: collection, Err: err}
+		}
+
+		started := false
+		for _, name := range names {
+			b, err := d.readFile(collection, name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return &OpError{Op: "backup", Collection: collection, Resource: name, Err: err}
+			}
+
+			if len(b) == 0 || expired(b, now) {
+				continue
+			}
+			if !json.Valid(b) {
+				return &OpError{Op: "backup", Collection: collection, Resource: name, Err: errors.New("record is not JSON")}
+			}
+
+			// a collection is only opened once it has a record to hold
+			if !started {
+				if !first {
+					bw.WriteByte(',')
+				}
+				writeJSONKey(bw, collection)
+				bw.WriteByte('{')
+				first, started = false, true
+			} else {
+				bw.WriteByte(',')
+			}
+
+			writeJSONKey(bw, name)
+			bw.Write(b)
+		}
+
+		if started {
+			bw.WriteByte('}')
+		}
+	}
+
+	bw.WriteByte('}')
+	return bw.Flush()
+}
+
+// writeJSONKey writes [key] as an object member name, followed by its colon
+func writeJSONKey(w *bufio.Writer, key string) {
+	b, _ := json.Marshal(key)
+	w.Write(b)
+	w.WriteByte(':')
+}
+
+// Restore writes every record of a backup made by Backup through the normal
+// write path, like Load. With [merge] the records are added to the
+// collections already there, overwriting those with the same names;
+// without it a collection that already has records fails the restore with
+// ErrAlreadyExists before anything is written.
+func (d *Driver) Restore(r io.Reader, merge bool) error {
+	var dump map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return fmt.Errorf("invalid backup: %w", err)
+	}
+
+	if !merge {
+		for collection := range dump {
+			names, err := d.list(d.normalize(collection))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return &OpError{Op: "restore", Collection: collection, Err: err}
+			}
+			if len(names) > 0 {
+				return &OpError{Op: "restore", Collection: collection, Err: fmt.Errorf("%w: collection %s has records", ErrAlreadyExists, collection)}
+			}
+		}
+	}
+
+	return d.Load(dump)
+}
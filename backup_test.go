@@ -0,0 +1,79 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write("ocean/"+collection, "nemo", Fish{Type: "clown"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := d.Backup(&buf); err != nil {
+		t.Fatal("Backup failed: ", err.Error())
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Fatal("Expected the backup to be JSON, got: ", buf.String())
+	}
+
+	// the backup restores into another database unchanged
+	other := newTestDB(t, nil)
+	if err := other.Restore(bytes.NewReader(buf.Bytes()), false); err != nil {
+		t.Fatal("Restore failed: ", err.Error())
+	}
+
+	want, err := d.Dump()
+	if err != nil {
+		t.Fatal("Dump failed: ", err.Error())
+	}
+	restored, err := other.Dump()
+	if err != nil || !reflect.DeepEqual(restored, want) {
+		t.Error("Expected the restored database to dump ", want, ", got: ", restored, err)
+	}
+
+	// restoring again refuses to overwrite, unless merging
+	if err := other.Write(collection, "red", Fish{Type: "changed"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := other.Restore(bytes.NewReader(buf.Bytes()), false); !errors.Is(err, ErrAlreadyExists) {
+		t.Error("Expected ErrAlreadyExists, got: ", err)
+	}
+
+	fish := Fish{}
+	if err := other.Read(collection, "red", &fish); err != nil || fish.Type != "changed" {
+		t.Error("Expected the refused restore to change nothing, got: ", fish, err)
+	}
+
+	if err := other.Restore(bytes.NewReader(buf.Bytes()), true); err != nil {
+		t.Fatal("Restore failed: ", err.Error())
+	}
+	if err := other.Read(collection, "red", &fish); err != nil || fish != redfish {
+		t.Error("Expected redfish, got: ", fish, err)
+	}
+}
+
+func TestBackupEmpty(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	var buf bytes.Buffer
+	if err := d.Backup(&buf); err != nil || buf.String() != "{}" {
+		t.Error("Expected an empty backup, got: ", buf.String(), err)
+	}
+
+	if err := d.Restore(bytes.NewReader([]byte("not json")), false); err == nil {
+		t.Error("Expected an invalid backup to fail")
+	}
+}
@@ -0,0 +1,146 @@
+package jsondb
+
+import (
+	"context"
+	"sort"
+)
+
+// WriteBatch locks the collection and writes each of [records], leaving
+// other records alone. Every record is marshaled and validated before the
+// lock is taken, so a value that can't be encoded fails the batch before
+// anything is written; the batch is then committed like WriteAll, all or
+// nothing across a crash with Options.WAL. Every record is written to its
+// temp file before the first is moved into place, so one that can't be
+// written leaves the others as they were; without the log, only a crash or
+// a failing rename partway through the moves leaves some of them replaced.
+func (d *Driver) WriteBatch(collection string, records map[string]interface{}) (err error) {
+	defer wrapOpError(&err, "writebatch", collection, "")
+
+	// ensure there is a place to save records
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	names, encoded, err := d.marshalAll(collection, records)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, "")
+
+	ops := make([]walOp, 0, len(names))
+	for _, name := range names {
+		b, err := d.resolve(collection, name, encoded[name])
+		if err != nil {
+			return &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+		ops = append(ops, walOp{Resource: name, Data: b})
+	}
+
+	return d.commitOps("write", collection, ops)
+}
+
+// WriteBatchContext locks the collection and writes each of [records] in
+// sorted resource order, checking [ctx] before every record. When the context
+// is cancelled it stops and returns the context's error along with the
+// resources already committed; each of those was written atomically and stays
+// valid, so an interrupted import can resume from there. With Options.WAL
+// every record is marshaled and logged before the first is written, so a
+// crash partway through is completed by the next New.
+func (d *Driver) WriteBatchContext(ctx context.Context, collection string,
+	records map[string]interface{}) (committed []string, err error) {
+	// ensure there is a place to save records
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names := make([]string, 0, len(records))
+	for name := range records {
+		// ensure there is a resource (name) to save each record as
+		if name == "" {
+			return nil, ErrMissingResource
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// the lock is given up on once the context is done
+	unlock, err := d.lockResourceContext(ctx, collection, "")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if d.wal {
+		return d.writeBatchLogged(ctx, collection, names, records)
+	}
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return committed, err
+		}
+
+		err := d.write(collection, name, records[name])
+		d.cache.invalidate(collection, name)
+		if err != nil {
+			return committed, err
+		}
+
+		committed = append(committed, name)
+	}
+
+	return committed, nil
+}
+
+// writeBatchLogged is WriteBatchContext with a write-ahead log; the caller
+// holds the collection lock
+func (d *Driver) writeBatchLogged(ctx context.Context, collection string, names []string,
+	records map[string]interface{}) (committed []string, err error) {
+	ops := make([]walOp, 0, len(names))
+	for _, name := range names {
+		b, err := d.marshal(collection, name, records[name])
+		if err == nil {
+			b, err = d.resolve(collection, name, b)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, walOp{Resource: name, Data: b})
+	}
+
+	if err := d.beginWAL(collection, ops); err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		err = d.writeRaw(collection, op.Resource, op.Data)
+		d.cache.invalidate(collection, op.Resource)
+		if err != nil {
+			// leave the log behind so the next New completes the batch
+			return committed, err
+		}
+
+		committed = append(committed, op.Resource)
+	}
+
+	// a cancelled batch stops where it is, like it does without a log
+	if werr := d.endWAL(collection, ops[:len(committed)]); werr != nil && err == nil {
+		err = werr
+	}
+
+	return committed, err
+}
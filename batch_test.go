@@ -0,0 +1,124 @@
+package jsondb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cancellingFish cancels its context as soon as it's marshaled
+type cancellingFish struct {
+	Fish
+	cancel context.CancelFunc
+}
+
+func (f cancellingFish) MarshalJSON() ([]byte, error) {
+	f.cancel()
+	return []byte(`{"type":"` + f.Type + `"}`), nil
+}
+
+func TestWriteBatchContext(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	committed, err := d.WriteBatchContext(context.Background(), collection, map[string]interface{}{
+		"red":  redfish,
+		"blue": Fish{Type: "blue"},
+	})
+	if err != nil || fmt.Sprint(committed) != "[blue red]" {
+		t.Error("Expected blue and red committed, got: ", committed, err)
+	}
+
+	// cancel partway through; records are written in sorted order
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	committed, err = d.WriteBatchContext(ctx, "sea", map[string]interface{}{
+		"a": Fish{Type: "a"},
+		"b": cancellingFish{Fish: Fish{Type: "b"}, cancel: cancel},
+		"c": Fish{Type: "c"},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Error("Expected context.Canceled, got: ", err)
+	}
+
+	if fmt.Sprint(committed) != "[a b]" {
+		t.Error("Expected a and b committed, got: ", committed)
+	}
+
+	fish := Fish{}
+	if err := d.Read("sea", "b", &fish); err != nil || fish.Type != "b" {
+		t.Error("Expected committed record to be readable, got: ", fish.Type, err)
+	}
+
+	if err := d.Read("sea", "c", &fish); err == nil {
+		t.Error("Expected c not to be written")
+	}
+}
+
+func TestWriteBatch(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "old", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a value that can't be marshaled leaves the whole batch unwritten
+	err := d.WriteBatch(collection, map[string]interface{}{
+		"a": Fish{Type: "a"},
+		"b": make(chan int),
+		"c": Fish{Type: "c"},
+	})
+	var opErr *OpError
+	if !errors.As(err, &opErr) || opErr.Resource != "b" {
+		t.Fatal("Expected an error naming b, got: ", err)
+	}
+
+	keys, err := d.Keys(collection)
+	if err != nil || len(keys) != 1 {
+		t.Error("Expected only the old record, got: ", keys, err)
+	}
+
+	if err := d.WriteBatch(collection, map[string]interface{}{"a": Fish{Type: "a"}, "c": Fish{Type: "c"}}); err != nil {
+		t.Fatal("WriteBatch failed: ", err.Error())
+	}
+
+	// the batch is added to what's there
+	keys, err = d.Keys(collection)
+	if err != nil || fmt.Sprint(keys) != "[a c old]" {
+		t.Error("Expected a, c and old, got: ", keys, err)
+	}
+}
+
+func TestWriteBatchStaged(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"a", "b"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// a directory in the way of the temp file of b fails staging it
+	tmp := d.recordPath(collection, "b") + tmpSuffix
+	if err := os.MkdirAll(filepath.Join(tmp, "x"), dirMode); err != nil {
+		t.Fatal("Failed to create directory: ", err.Error())
+	}
+
+	err := d.WriteBatch(collection, map[string]interface{}{"a": Fish{Type: "blue"}, "b": Fish{Type: "blue"}})
+	var opErr *OpError
+	if !errors.As(err, &opErr) || opErr.Resource != "b" {
+		t.Fatal("Expected an error naming b, got: ", err)
+	}
+
+	// a was staged but never moved into place
+	fish := Fish{}
+	if err := d.Read(collection, "a", &fish); err != nil || fish != redfish {
+		t.Error("Expected a unchanged, got: ", fish, err)
+	}
+	if _, err := os.Stat(d.recordPath(collection, "a") + tmpSuffix); !os.IsNotExist(err) {
+		t.Error("Expected the temp file of a removed, got: ", err)
+	}
+}
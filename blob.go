@@ -0,0 +1,167 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// blobSuffix is appended to a resource's name to store its blob
+const blobSuffix = ".blob"
+
+// WriteBlob locks the collection and atomically stores [data] as is, without
+// marshaling, as the blob attached to [resource]. Blobs live next to records
+// but are never returned by ReadAll or other record listings.
+func (d *Driver) WriteBlob(collection, resource string, data []byte) (err error) {
+	defer wrapOpError(&err, "writeblob", collection, resource)
+
+	// ensure there is a place to save blob
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save blob as
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := d.ensureDir(collection); err != nil {
+		return err
+	}
+
+	path := d.blobPath(collection, resource)
+	if err := d.writeBytes(path+tmpSuffix, path, data); err != nil {
+		return err
+	}
+
+	// a blob stored under a hash can only be listed by its key file
+	if !d.isHashed(resource) {
+		return nil
+	}
+	return writeFileAtomic(path+keySuffix, []byte(d.normalize(resource)))
+}
+
+// ReadBlob returns the blob attached to [resource]
+func (d *Driver) ReadBlob(collection, resource string) (data []byte, err error) {
+	defer wrapOpError(&err, "readblob", collection, resource)
+
+	// ensure there is a place to read blob from
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to read blob of
+	if resource == "" {
+		return nil, ErrMissingResource
+	}
+
+	if err := d.checkNames(collection, resource); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(d.blobPath(collection, resource))
+}
+
+// DeleteBlob locks the collection and removes the blob attached to [resource]
+func (d *Driver) DeleteBlob(collection, resource string) (err error) {
+	defer wrapOpError(&err, "deleteblob", collection, resource)
+
+	// ensure there is a place to delete blob from
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to delete blob of
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := d.blobPath(collection, resource)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path + keySuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ListBlobs read locks the collection and returns the sorted resource names
+// that have a blob attached; a collection that doesn't exist has none
+func (d *Driver) ListBlobs(collection string) ([]string, error) {
+	collection = d.normalize(collection)
+
+	// ensure there is a collection to list
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	names := []string{}
+
+	dir := d.collectionDir(collection)
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), blobSuffix) {
+			continue
+		}
+
+		// content addressed blobs are symlinks to their objects
+		linked := d.contentAddressed && file.Type()&os.ModeSymlink != 0
+		if !file.Type().IsRegular() && !linked {
+			continue
+		}
+
+		name := strings.TrimSuffix(file.Name(), blobSuffix)
+		if strings.HasPrefix(name, hashedPrefix) {
+			if name, ok := hashedResource(dir, file.Name()); ok {
+				names = append(names, name)
+			}
+			continue
+		}
+		if d.decodeKey != nil {
+			var err error
+			if name, err = d.decodeKey(name); err != nil {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// blobPath returns the path of the blob attached to [resource]; a name too
+// long for the file system is stored under its hash, like a record's
+func (d *Driver) blobPath(collection, resource string) string {
+	return filepath.Join(d.collectionDir(collection), storedName(d.encodedName(resource))+blobSuffix)
+}
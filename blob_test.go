@@ -0,0 +1,101 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBlob(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	photo := []byte{0x89, 'P', 'N', 'G', 0x00}
+	if err := d.WriteBlob(collection, "redfish", photo); err != nil {
+		t.Fatal("Failed to write blob: ", err.Error())
+	}
+
+	b, err := d.ReadBlob(collection, "redfish")
+	if err != nil || !bytes.Equal(b, photo) {
+		t.Error("Expected photo back, got: ", b, err)
+	}
+
+	// blobs aren't records
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 1 {
+		t.Error("Expected 1 record, got: ", len(records), err)
+	}
+
+	if names, err := d.ListBlobs(collection); err != nil || fmt.Sprint(names) != "[redfish]" {
+		t.Error("Expected redfish blob listed, got: ", names, err)
+	}
+
+	if err := d.DeleteBlob(collection, "redfish"); err != nil {
+		t.Error("Failed to delete blob: ", err.Error())
+	}
+
+	if _, err := d.ReadBlob(collection, "redfish"); err == nil {
+		t.Error("Expected nothing, got blob")
+	}
+
+	// the record itself is untouched
+	if err := d.Read(collection, "redfish", &Fish{}); err != nil {
+		t.Error("Expected red fish to remain: ", err.Error())
+	}
+}
+
+func TestBlobLongName(t *testing.T) {
+	for _, opts := range []*Options{{Durable: true}, {Durable: true, ContentAddressed: true}} {
+		d := newTestDB(t, opts)
+
+		// too long to be a file name, so it's stored under its hash
+		name := strings.Repeat("redfish", 40)
+		photo := []byte{0x89, 'P', 'N', 'G', 0x00}
+		if err := d.WriteBlob(collection, name, photo); err != nil {
+			t.Fatal("Failed to write blob: ", err.Error())
+		}
+
+		b, err := d.ReadBlob(collection, name)
+		if err != nil || !bytes.Equal(b, photo) {
+			t.Error("Expected photo back, got: ", b, err)
+		}
+
+		if names, err := d.ListBlobs(collection); err != nil || len(names) != 1 || names[0] != name {
+			t.Error("Expected the long name listed, got: ", names, err)
+		}
+
+		if err := d.DeleteBlob(collection, name); err != nil {
+			t.Error("Failed to delete blob: ", err.Error())
+		}
+
+		if files, err := os.ReadDir(d.collectionDir(collection)); err != nil || len(files) != 0 {
+			t.Error("Expected nothing left behind, got: ", files, err)
+		}
+	}
+}
+
+func TestBlobNames(t *testing.T) {
+	d := newTestDB(t, &Options{CaseInsensitiveKeys: true})
+
+	// a record can't share the file of a blob
+	if err := d.Write(collection, "pic"+blobSuffix, redfish); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+
+	if err := d.WriteBlob(collection, "Pic", []byte("png")); err != nil {
+		t.Fatal("Failed to write blob: ", err.Error())
+	}
+	if names, err := d.ListBlobs("FISH"); err != nil || fmt.Sprint(names) != "[pic]" {
+		t.Error("Expected pic listed, got: ", names, err)
+	}
+
+	if names, err := d.ListBlobs("missing"); err != nil || names == nil || len(names) != 0 {
+		t.Error("Expected no blobs, got: ", names, err)
+	}
+}
@@ -0,0 +1,260 @@
+package jsondb
+
+import (
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+const (
+	bloomBitsPerName = 10   // about a 1% false positive rate
+	bloomHashes      = 7    // the number of bits set per name
+	bloomMinBits     = 1024 // the smallest filter, for empty collections
+)
+
+// bloomFilter is a set of names answering "maybe" or "definitely not"
+type bloomFilter struct {
+	bits     []uint64
+	capacity int // how many names it was sized for
+	count    int // how many names were added
+
+	building bool     // names are being listed from disk; not usable yet
+	pending  []string // names added while building
+}
+
+// positions returns the bits [name] sets, by double hashing
+func (f *bloomFilter) positions(name string) [bloomHashes]uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	sum := h.Sum64()
+
+	n := uint64(len(f.bits) * 64)
+	a, b := sum&0xffffffff, sum>>32|1
+
+	var pos [bloomHashes]uint64
+	for i := range pos {
+		pos[i] = (a + uint64(i)*b) % n
+	}
+
+	return pos
+}
+
+func (f *bloomFilter) add(name string) {
+	for _, p := range f.positions(name) {
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+	f.count++
+}
+
+func (f *bloomFilter) mayContain(name string) bool {
+	for _, p := range f.positions(name) {
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// blooms holds the filters of the collections configured with Bloom
+type blooms struct {
+	mutex  sync.Mutex
+	byName map[string]*bloomFilter
+}
+
+// add records that [resource] may exist in [collection]; a record deleted is
+// added too, which only costs a false positive
+func (b *blooms) add(collection, resource string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	f := b.byName[collection]
+	switch {
+	case f == nil:
+	case f.building:
+		f.pending = append(f.pending, resource)
+	default:
+		f.add(resource)
+
+		// a filter filled way past its size answers "maybe" to everything;
+		// the next lookup builds a bigger one
+		if f.count > 2*f.capacity {
+			delete(b.byName, collection)
+		}
+	}
+}
+
+// mayContain reports whether [resource] may exist in [collection], and
+// false for [ok] when the collection has no usable filter
+func (b *blooms) mayContain(collection, resource string) (maybe, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	f := b.byName[collection]
+	if f == nil || f.building {
+		return true, false
+	}
+
+	return f.mayContain(resource), true
+}
+
+// start installs an empty filter for [collection] collecting the names added
+// until finish fills it; unless [replace] is set, it returns nil rather than
+// replace a filter already installed, such as one another caller is building
+func (b *blooms) start(collection string, replace bool) *bloomFilter {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !replace && b.byName[collection] != nil {
+		return nil
+	}
+
+	f := &bloomFilter{building: true}
+	b.byName[collection] = f
+	return f
+}
+
+// finish sizes [f] for [names] and the names added since start and makes it
+// usable, unless it was replaced or forgotten meanwhile
+func (b *blooms) finish(collection string, f *bloomFilter, names []string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.byName[collection] != f {
+		return
+	}
+
+	f.capacity = len(names) + len(f.pending)
+	size := f.capacity * bloomBitsPerName
+	if size < bloomMinBits {
+		size = bloomMinBits
+	}
+	f.bits = make([]uint64, (size+63)/64)
+
+	for _, name := range names {
+		f.add(name)
+	}
+	for _, name := range f.pending {
+		f.add(name)
+	}
+
+	f.building, f.pending = false, nil
+}
+
+// forget drops the filters of a collection and its nested collections
+func (b *blooms) forget(collection string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for name := range b.byName {
+		if name == collection || strings.HasPrefix(name, collection+"/") {
+			delete(b.byName, name)
+		}
+	}
+}
+
+// EnableBloom configures [collection] with Bloom, keeping its other settings,
+// and builds its filter from the records on disk. The filter lives in memory
+// only: it's built again from disk by the first Exists after New, so a crash
+// can't leave it stale, and calling EnableBloom again rebuilds it. Writes and
+// deletes through the driver keep it current; records added to the
+// directory behind the driver's back, such as by another process, are only
+// seen after a rebuild.
+func (d *Driver) EnableBloom(collection string) (err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "enablebloom", collection, "")
+
+	// ensure there is a collection to filter
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	cfg, err := d.collectionConfig(collection)
+	if err != nil {
+		return err
+	}
+	if !cfg.Bloom {
+		cfg.Bloom = true
+		if err := d.ConfigureCollection(collection, cfg); err != nil {
+			return err
+		}
+	}
+
+	return d.buildBloom(collection, true)
+}
+
+// buildBloom gives [collection] a filter of the records listed on disk,
+// replacing the one it has if [replace] is set. The filter is installed
+// before listing, so a record written meanwhile is either listed or added
+// to it.
+func (d *Driver) buildBloom(collection string, replace bool) error {
+	f := d.blooms.start(collection, replace)
+	if f == nil {
+		return nil
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		d.blooms.forget(collection)
+		return err
+	}
+
+	d.blooms.finish(collection, f, names)
+	return nil
+}
+
+// Exists reports whether the record [resource] is stored in [collection],
+// without reading it. In a collection configured with Bloom most absent
+// records are answered for from the filter alone, only possible hits being
+// looked up on disk.
+func (d *Driver) Exists(collection, resource string) (exists bool, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "exists", collection, resource)
+
+	// ensure there is a collection to look in
+	if collection == "" {
+		return false, ErrMissingCollection
+	}
+
+	// ensure there is a resource to look for
+	if resource == "" {
+		return false, ErrMissingResource
+	}
+
+	if _, ok := d.overlay.get(collection, resource); ok {
+		return true, nil
+	}
+
+	cfg, err := d.collectionConfig(collection)
+	if err != nil {
+		return false, err
+	}
+
+	if cfg.Bloom {
+		maybe, ok := d.blooms.mayContain(collection, resource)
+		if !ok {
+			if err := d.buildBloom(collection, false); err != nil {
+				return false, err
+			}
+			maybe, _ = d.blooms.mayContain(collection, resource)
+		}
+		if !maybe {
+			return false, nil
+		}
+	}
+
+	info, err := d.statRecord(collection, resource)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// a nested collection isn't a record
+	return !info.IsDir(), nil
+}
@@ -0,0 +1,122 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestExists(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write(collection+"/school", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	for name, want := range map[string]bool{"red": true, "blue": false, "school": false} {
+		if exists, err := d.Exists(collection, name); err != nil || exists != want {
+			t.Error("Expected ", name, " to exist: ", want, ", got: ", exists, err)
+		}
+	}
+
+	if _, err := d.Exists("", "red"); !errors.Is(err, ErrMissingCollection) {
+		t.Error("Expected ErrMissingCollection, got: ", err)
+	}
+}
+
+func TestBloom(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for i := 0; i < 100; i++ {
+		if err := d.Write(collection, fmt.Sprint("fish", i), redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.EnableBloom(collection); err != nil {
+		t.Fatal("EnableBloom failed: ", err.Error())
+	}
+
+	for i := 0; i < 100; i++ {
+		if exists, err := d.Exists(collection, fmt.Sprint("fish", i)); err != nil || !exists {
+			t.Fatal("Expected fish", i, " to exist, got: ", exists, err)
+		}
+	}
+
+	// writes and deletes keep the filter current
+	if err := d.Write(collection, "late", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if exists, err := d.Exists(collection, "late"); err != nil || !exists {
+		t.Error("Expected a record written after EnableBloom to exist, got: ", exists, err)
+	}
+
+	if err := d.Delete(collection, "fish0"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+	if exists, err := d.Exists(collection, "fish0"); err != nil || exists {
+		t.Error("Expected a deleted record not to exist, got: ", exists, err)
+	}
+
+	// a record stored behind the driver's back shows the filter answers alone
+	if err := os.WriteFile(d.recordPath(collection, "sneaky"), []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := d.Exists(collection, "sneaky"); err != nil || exists {
+		t.Error("Expected the filter to rule the record out, got: ", exists, err)
+	}
+
+	// rebuilding reads the disk again
+	if err := d.EnableBloom(collection); err != nil {
+		t.Fatal("EnableBloom failed: ", err.Error())
+	}
+	if exists, err := d.Exists(collection, "sneaky"); err != nil || !exists {
+		t.Error("Expected the rebuilt filter to see the record, got: ", exists, err)
+	}
+
+	// a new driver builds the filter from disk on first use
+	if err := os.WriteFile(d.recordPath(collection, "offline"), []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := New(d.dir, &Options{Debug: t.Logf})
+	if err != nil {
+		t.Fatal("Failed to reopen database: ", err.Error())
+	}
+
+	if cfg, err := reopened.collectionConfig(collection); err != nil || !cfg.Bloom {
+		t.Error("Expected Bloom to stay configured, got: ", cfg, err)
+	}
+	for name, want := range map[string]bool{"offline": true, "late": true, "fish0": false, "missing": false} {
+		if exists, err := reopened.Exists(collection, name); err != nil || exists != want {
+			t.Error("Expected ", name, " to exist: ", want, ", got: ", exists, err)
+		}
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	b := blooms{byName: make(map[string]*bloomFilter)}
+
+	// names added while the filter is built are kept
+	f := b.start(collection, true)
+	b.add(collection, "pending")
+	b.finish(collection, f, []string{"listed"})
+
+	for _, name := range []string{"pending", "listed"} {
+		if maybe, ok := b.mayContain(collection, name); !ok || !maybe {
+			t.Error("Expected ", name, " to be in the filter, got: ", maybe, ok)
+		}
+	}
+
+	// a filter filled way past its size is dropped for a bigger one
+	for i := 0; i < 2*f.capacity+1; i++ {
+		b.add(collection, fmt.Sprint("fish", i))
+	}
+	if _, ok := b.mayContain(collection, "listed"); ok {
+		t.Error("Expected the overfilled filter to be dropped")
+	}
+}
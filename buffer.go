@@ -0,0 +1,198 @@
+package jsondb
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrWriterClosed is returned when a BufferedWriter is used after Close
+var ErrWriterClosed = errors.New("buffered writer is closed")
+
+// BufferOptions configures a BufferedWriter
+type BufferOptions struct {
+	// FlushInterval flushes the pending writes this often in the background;
+	// zero only flushes on Flush, Close and MaxPending
+	FlushInterval time.Duration
+
+	// MaxPending flushes as soon as this many distinct records are pending;
+	// zero never flushes for size
+	MaxPending int
+}
+
+// BufferedWriter coalesces writes to one collection in memory and stores
+// them together, taking the collection lock once per flush rather than once
+// per record, and writing a record rewritten many times between flushes only
+// once. Records written but not yet flushed are lost if the process exits or
+// crashes without calling Close; a flush itself is all or nothing when
+// Options.WAL is set, like WriteAll. Read and ReadAll through the Driver see
+// pending records ahead of what is on disk, so a write is visible to the
+// process that made it right away; any write or delete of the record through
+// the driver replaces the pending one until the next flush stores it.
+type BufferedWriter struct {
+	d          *Driver
+	collection string
+	maxPending int
+
+	mutex   sync.Mutex
+	pending map[string][]byte // marshaled records by resource
+	err     error             // why the last background flush failed
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBufferedWriter returns a BufferedWriter for [collection]; call Close to
+// store whatever is still pending
+func (d *Driver) NewBufferedWriter(collection string, opts BufferOptions) *BufferedWriter {
+	w := &BufferedWriter{
+		d:          d,
+		collection: d.normalize(collection),
+		maxPending: opts.MaxPending,
+		pending:    make(map[string][]byte),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if opts.FlushInterval <= 0 {
+		close(w.done)
+		return w
+	}
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(opts.FlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.mutex.Lock()
+				w.err = w.flush()
+				w.mutex.Unlock()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+
+	return w
+}
+
+// Write marshals [v] and stages it as the record [resource] until the next
+// flush; marshal and validation errors are returned right away
+func (w *BufferedWriter) Write(resource string, v interface{}) error {
+	resource = w.d.normalize(resource)
+
+	// ensure there is a place to save record
+	if w.collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save record as
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	b, err := w.d.marshal(w.collection, resource, v)
+	if err != nil {
+		return &OpError{Op: "write", Collection: w.collection, Resource: resource, Err: err}
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	w.pending[resource] = b
+	w.d.overlay.put(w.collection, resource, b)
+	if w.maxPending > 0 && len(w.pending) >= w.maxPending {
+		return w.flush()
+	}
+
+	return nil
+}
+
+// Read reads a record into [v], preferring the pending write of it
+func (w *BufferedWriter) Read(resource string, v interface{}) error {
+	resource = w.d.normalize(resource)
+
+	w.mutex.Lock()
+	b, ok := w.pending[resource]
+	w.mutex.Unlock()
+
+	if !ok {
+		return w.d.Read(w.collection, resource, v)
+	}
+
+	return w.d.unmarshal(b, v)
+}
+
+// Flush stores the pending writes, returning why they couldn't be stored;
+// pending writes a flush failed to store are kept for the next one
+func (w *BufferedWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.err = w.flush()
+	return w.err
+}
+
+// Close stops the background flushes and stores the pending writes
+func (w *BufferedWriter) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mutex.Unlock()
+
+	close(w.stop)
+	<-w.done
+
+	return w.Flush()
+}
+
+// flush stores the pending writes; the caller holds w.mutex
+func (w *BufferedWriter) flush() (err error) {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	defer wrapOpError(&err, "flush", w.collection, "")
+
+	names := make([]string, 0, len(w.pending))
+	for name := range w.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	unlock, err := w.d.lockResource(w.collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer w.d.cache.invalidate(w.collection, "")
+
+	ops := make([]walOp, 0, len(names))
+	for _, name := range names {
+		b, err := w.d.resolve(w.collection, name, w.pending[name])
+		if err != nil {
+			return &OpError{Op: "write", Collection: w.collection, Resource: name, Err: err}
+		}
+		ops = append(ops, walOp{Resource: name, Data: b})
+	}
+
+	if err := w.d.commitOps("write", w.collection, ops); err != nil {
+		return err
+	}
+
+	w.pending = make(map[string][]byte)
+	return nil
+}
@@ -0,0 +1,154 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBufferedWriter(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	w := d.NewBufferedWriter(collection, BufferOptions{})
+	if err := w.Write("red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// pending writes are read back, but only stored by the flush
+	fish := Fish{}
+	if err := w.Read("red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected the pending fish, got: ", fish, err)
+	}
+	if _, err := os.Stat(d.recordPath(collection, "red")); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected nothing stored before the flush, got: ", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal("Flush failed: ", err.Error())
+	}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected the flushed fish, got: ", fish, err)
+	}
+
+	// the last of several writes before a flush wins
+	for _, kind := range []string{"blue", "green"} {
+		if err := w.Write("red", Fish{Type: kind}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal("Close failed: ", err.Error())
+	}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "green" {
+		t.Error("Expected the last fish, got: ", fish, err)
+	}
+
+	if err := w.Write("red", redfish); !errors.Is(err, ErrWriterClosed) {
+		t.Error("Expected ErrWriterClosed, got: ", err)
+	}
+}
+
+func TestBufferedWriterFlushes(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// reaching MaxPending flushes right away
+	w := d.NewBufferedWriter(collection, BufferOptions{MaxPending: 2})
+	for _, name := range []string{"red", "blue"} {
+		if err := w.Write(name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+	if err := d.Read(collection, "blue", &Fish{}); err != nil {
+		t.Error("Expected the batch flushed, got: ", err)
+	}
+
+	// and FlushInterval flushes in the background
+	w = d.NewBufferedWriter(collection, BufferOptions{FlushInterval: 10 * time.Millisecond})
+	defer w.Close()
+
+	if err := w.Write("green", Fish{Type: "green"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for d.Read(collection, "green", &Fish{}) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a background flush")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBufferedWriterReadYourWrites(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	w := d.NewBufferedWriter(collection, BufferOptions{})
+	if err := w.Write("red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the driver sees the pending write right away
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish != redfish {
+		t.Error("Expected the pending fish, got: ", fish, err)
+	}
+
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 2 {
+		t.Fatal("Expected the stored and the pending fish, got: ", len(records), err)
+	}
+
+	// a write through the driver replaces the pending one
+	if err := d.Write(collection, "red", Fish{Type: "green"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "green" {
+		t.Error("Expected the written fish, got: ", fish, err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal("Close failed: ", err.Error())
+	}
+
+	// the flush stores the pending write and leaves nothing behind
+	if err := d.Read(collection, "red", &fish); err != nil || fish != redfish {
+		t.Error("Expected the flushed fish, got: ", fish, err)
+	}
+	if len(d.overlay.byName) != 0 {
+		t.Error("Expected nothing pending after the flush, got: ", d.overlay.byName)
+	}
+
+	// pending records of a collection that isn't on disk are read too
+	fresh := d.NewBufferedWriter("fresh", BufferOptions{})
+	defer fresh.Close()
+	if err := fresh.Write("red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if records, err := d.ReadAll("fresh"); err != nil || len(records) != 1 {
+		t.Error("Expected the pending fish, got: ", len(records), err)
+	}
+}
+
+func BenchmarkBufferedWriter(b *testing.B) {
+	d, err := New(b.TempDir(), &Options{Debug: b.Logf})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	w := d.NewBufferedWriter(collection, BufferOptions{MaxPending: 100})
+	defer w.Close()
+
+	for i := 0; i < b.N; i++ {
+		if err := w.Write(strconv.Itoa(i%1000), redfish); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package jsondb
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// cache holds the raw bytes of recently read records, keyed by collection and
+// then resource. A nil *cache is valid and caches nothing.
+type cache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	fold    bool // names are case insensitive, as with CaseInsensitiveKeys
+	entries map[string]map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	b       []byte
+	expires time.Time
+}
+
+// newCache returns a cache whose entries expire after [ttl], or nil if [ttl]
+// disables caching; with [fold] names differing only in case are the same
+func newCache(ttl time.Duration, fold bool) *cache {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return &cache{ttl: ttl, fold: fold, entries: make(map[string]map[string]cacheEntry)}
+}
+
+// own returns [b] as bytes the caller may keep and modify: a copy when
+// CopyOnRead is on and the bytes may be shared with the cache
+func (d *Driver) own(b []byte) []byte {
+	if !d.copyOnRead || d.cache == nil {
+		return b
+	}
+
+	return append([]byte(nil), b...)
+}
+
+// get returns the cached bytes of a record if they haven't expired yet
+func (c *cache) get(collection, resource string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	collection, resource = foldName(c.fold, collection), foldName(c.fold, resource)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[collection][resource]
+	if !ok {
+		return nil, false
+	}
+
+	// drop expired entries so the record is read from disk again
+	if time.Now().After(e.expires) {
+		delete(c.entries[collection], resource)
+		return nil, false
+	}
+
+	return e.b, true
+}
+
+// put caches the bytes of a record for the cache's ttl
+func (c *cache) put(collection, resource string, b []byte) {
+	if c == nil {
+		return
+	}
+
+	collection, resource = foldName(c.fold, collection), foldName(c.fold, resource)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	records, ok := c.entries[collection]
+	if !ok {
+		records = make(map[string]cacheEntry)
+		c.entries[collection] = records
+	}
+
+	records[resource] = cacheEntry{b: b, expires: time.Now().Add(c.ttl)}
+}
+
+// invalidate drops a cached record, or the whole collection (including any
+// nested collections) when [resource] is empty
+func (c *cache) invalidate(collection, resource string) {
+	if c == nil {
+		return
+	}
+
+	collection, resource = foldName(c.fold, collection), foldName(c.fold, resource)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if resource != "" {
+		delete(c.entries[collection], resource)
+		return
+	}
+
+	for name := range c.entries {
+		if name == collection || strings.HasPrefix(name, collection+"/") {
+			delete(c.entries, name)
+		}
+	}
+}
+
+// sweep drops every expired entry and returns how many there were
+func (c *cache) sweep() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	swept := 0
+	for _, records := range c.entries {
+		for resource, e := range records {
+			if now.After(e.expires) {
+				delete(records, resource)
+				swept++
+			}
+		}
+	}
+
+	return swept
+}
+
+// InvalidateCache drops the cached copy of a record so the next Read goes to
+// disk; useful after the record's file was edited outside of jsondb
+func (d *Driver) InvalidateCache(collection, resource string) {
+	if resource == "" {
+		return
+	}
+
+	d.cache.invalidate(collection, resource)
+}
+
+// InvalidateCollection drops every cached record of a collection
+func (d *Driver) InvalidateCollection(collection string) {
+	d.cache.invalidate(collection, "")
+}
@@ -0,0 +1,108 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadCache(t *testing.T) {
+	d := newTestDB(t, &Options{CacheTTL: time.Hour})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "redfish", &fish); err != nil {
+		t.Fatal("Failed to read: ", err.Error())
+	}
+
+	// edit the record behind the driver's back
+	path := filepath.Join(d.dir, collection, "redfish")
+	if err := os.WriteFile(path, []byte(`{"type":"blue"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached copy is still served
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected cached red fish, got: ", fish.Type, err)
+	}
+
+	// until it's invalidated
+	d.InvalidateCache(collection, "redfish")
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected blue fish from disk, got: ", fish.Type, err)
+	}
+
+	// writes through the driver are visible immediately
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish after write, got: ", fish.Type, err)
+	}
+
+	// whole collections can be dropped from the cache
+	if err := os.WriteFile(path, []byte(`{"type":"green"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	d.InvalidateCollection(collection)
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "green" {
+		t.Error("Expected green fish from disk, got: ", fish.Type, err)
+	}
+}
+
+func TestReadCacheTTL(t *testing.T) {
+	d := newTestDB(t, &Options{CacheTTL: 10 * time.Millisecond})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "redfish", &fish); err != nil {
+		t.Fatal("Failed to read: ", err.Error())
+	}
+
+	path := filepath.Join(d.dir, collection, "redfish")
+	if err := os.WriteFile(path, []byte(`{"type":"blue"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	// once expired the record is read from disk again
+	time.Sleep(20 * time.Millisecond)
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected blue fish after expiry, got: ", fish.Type, err)
+	}
+}
+
+func TestCopyOnRead(t *testing.T) {
+	for _, copyOnRead := range []bool{false, true} {
+		d := newTestDB(t, &Options{CacheTTL: time.Minute, CopyOnRead: copyOnRead})
+
+		if err := d.Write(collection, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		first, _, err := d.Scan(collection, "", 1)
+		if err != nil {
+			t.Fatal("Scan failed: ", err.Error())
+		}
+
+		// scribble over what was handed out
+		for i := range first["red"] {
+			first["red"][i] = ' '
+		}
+
+		second, _, err := d.Scan(collection, "", 1)
+		if err != nil {
+			t.Fatal("Scan failed: ", err.Error())
+		}
+
+		if shared := string(second["red"]) == string(first["red"]); shared == copyOnRead {
+			t.Error("Expected CopyOnRead ", copyOnRead, " to decide whether the cache is shared, got: ", string(second["red"]))
+		}
+	}
+}
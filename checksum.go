@@ -0,0 +1,140 @@
+package jsondb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// sumSuffix is appended to the file name of a record to name its checksum
+const sumSuffix = ".sum"
+
+// checksumRetries is how often a read rereads a record whose checksum doesn't
+// match, since a concurrent write may be between storing the two
+const checksumRetries = 3
+
+// ErrChecksumMismatch is returned when the bytes of a record don't match the
+// checksum stored with it
+var ErrChecksumMismatch = errors.New("checksum mismatch - the record is corrupted")
+
+// sumPath returns the path of the file holding the checksum of [resource]
+func (d *Driver) sumPath(collection, resource string) string {
+	return d.recordPath(collection, resource) + sumSuffix
+}
+
+// updateChecksum stores the checksum of [resource] as it's now on disk, or
+// removes it along with the record, if checksums are kept; the caller holds
+// the collection lock
+func (d *Driver) updateChecksum(collection, resource string) error {
+	if !d.writeChecksum {
+		return nil
+	}
+
+	f, err := os.Open(d.recordPath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.Remove(d.sumPath(collection, resource)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(d.sumPath(collection, resource), []byte(hex.EncodeToString(h.Sum(nil))))
+}
+
+// verifyChecksum returns the stored bytes [b] of [resource] once they match
+// its checksum, rereading them while a concurrent write may have replaced
+// the record but not yet its checksum; records without a checksum pass
+func (d *Driver) verifyChecksum(collection, resource string, b []byte) ([]byte, error) {
+	if !d.writeChecksum {
+		return b, nil
+	}
+
+	for i := 0; ; i++ {
+		ok, err := d.checksumMatches(collection, resource, b)
+		if err != nil || ok {
+			return b, err
+		}
+
+		if i == checksumRetries {
+			return nil, ErrChecksumMismatch
+		}
+
+		if b, err = os.ReadFile(d.recordPath(collection, resource)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checksumMatches reports whether [b] matches the stored checksum of
+// [resource], or there is none
+func (d *Driver) checksumMatches(collection, resource string, b []byte) (bool, error) {
+	sum, err := os.ReadFile(d.sumPath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	actual := sha256.Sum256(b)
+	return bytes.Equal(bytes.TrimSpace(sum), []byte(hex.EncodeToString(actual[:]))), nil
+}
+
+// VerifyChecksums read locks the collection and checks every record against
+// its stored checksum, returning the sorted names of those that don't match.
+// Records without a checksum are skipped.
+func (d *Driver) VerifyChecksums(collection string) (mismatched []string, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "verifychecksums", collection, "")
+
+	// ensure there is a collection to verify
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	names, err := d.listDisk(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	mismatched = []string{}
+	for _, name := range names {
+		b, err := os.ReadFile(d.recordPath(collection, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		ok, err := d.checksumMatches(collection, name, b)
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+		if !ok {
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	return mismatched, nil
+}
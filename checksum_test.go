@@ -0,0 +1,84 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestWriteChecksum(t *testing.T) {
+	d := newTestDB(t, &Options{WriteChecksum: true})
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if _, err := os.Stat(d.sumPath(collection, "red")); err != nil {
+		t.Fatal("Expected a checksum next to the record, got: ", err.Error())
+	}
+
+	// checksums aren't records
+	records, err := d.ReadAll(collection)
+	if err != nil {
+		t.Fatal("Failed to read fish: ", err.Error())
+	}
+
+	if len(records) != 2 {
+		t.Error("Expected 2 fish, got: ", len(records))
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	// flip the record behind the driver's back
+	if err := os.WriteFile(d.recordPath(collection, "red"), []byte(`{"type":"rot"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Read(collection, "red", &Fish{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Error("Expected ErrChecksumMismatch, got: ", err)
+	}
+
+	mismatched, err := d.VerifyChecksums(collection)
+	if err != nil {
+		t.Fatal("Failed to verify checksums: ", err.Error())
+	}
+
+	if len(mismatched) != 1 || mismatched[0] != "red" {
+		t.Error("Expected red fish to be corrupted, got: ", mismatched)
+	}
+
+	// deleting the record takes its checksum along
+	if err := d.Delete(collection, "red"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	if _, err := os.Stat(d.sumPath(collection, "red")); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected the checksum to be removed, got: ", err)
+	}
+}
+
+func TestWriteChecksumUnchecked(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// records written before checksums were turned on are read as they are
+	d.writeChecksum = true
+
+	if err := d.Read(collection, "red", &Fish{}); err != nil {
+		t.Error("Expected a record without checksum to be read, got: ", err)
+	}
+
+	mismatched, err := d.VerifyChecksums(collection)
+	if err != nil || len(mismatched) != 0 {
+		t.Error("Expected nothing corrupted, got: ", mismatched, err)
+	}
+}
@@ -0,0 +1,48 @@
+package jsondb
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CleanTempFiles removes the temp files left behind by interrupted writes
+// anywhere in the database and returns their paths. Only temp files older
+// than Options.TempFileAge are removed, so writes still in flight are safe.
+func (d *Driver) CleanTempFiles() (removed []string, err error) {
+	cutoff := time.Now().Add(-d.tempFileAge)
+
+	err = filepath.WalkDir(d.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), tmpSuffix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// the write finished and renamed the file away
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		removed = append(removed, path)
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,47 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanTempFiles(t *testing.T) {
+	d := newTestDB(t, &Options{TempFileAge: time.Minute})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	stale := filepath.Join(d.dir, collection, "bluefish.tmp")
+	fresh := filepath.Join(d.dir, collection, "greenfish.tmp")
+	for _, path := range []string{stale, fresh} {
+		if err := os.WriteFile(path, []byte("{"), fileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := d.CleanTempFiles()
+	if err != nil {
+		t.Fatal("Failed to clean: ", err.Error())
+	}
+
+	if len(removed) != 1 || removed[0] != stale {
+		t.Error("Expected only the stale temp file removed, got: ", removed)
+	}
+
+	// in-flight temp files and records are left alone
+	if _, err := os.Stat(fresh); err != nil {
+		t.Error("Expected fresh temp file to remain: ", err.Error())
+	}
+
+	if err := d.Read(collection, "redfish", &Fish{}); err != nil {
+		t.Error("Expected red fish to remain: ", err.Error())
+	}
+}
@@ -0,0 +1,31 @@
+package jsondb
+
+import "encoding/json"
+
+// Codec encodes the values stored as records and decodes records into
+// values, in place of encoding/json, for instance to indent records or to
+// store another format
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(b []byte, v interface{}) error
+}
+
+// JSONCodec encodes records with plain encoding/json, as they're encoded
+// when Options.Codec is nil short of TagKey and the decoding options; it's a
+// starting point for codecs adjusting the JSON
+type JSONCodec struct{}
+
+// Marshal encodes [v] with json.Marshal
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes [b] into [v] with json.Unmarshal
+func (JSONCodec) Unmarshal(b []byte, v interface{}) error { return json.Unmarshal(b, v) }
+
+// encodeValue marshals the value [v] with the driver's codec
+func (d *Driver) encodeValue(v interface{}) ([]byte, error) {
+	if d.codec == nil {
+		return json.Marshal(v)
+	}
+
+	return d.codec.Marshal(v)
+}
@@ -0,0 +1,38 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// indentCodec stores records indented for people to read
+type indentCodec struct{ JSONCodec }
+
+func (indentCodec) Marshal(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", "  ") }
+
+func TestCodec(t *testing.T) {
+	d := newTestDB(t, &Options{Codec: indentCodec{}})
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{\n  \"type\": \"red\"\n}" {
+		t.Error("Expected an indented record, got: ", string(b))
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish != redfish {
+		t.Error("Expected redfish, got: ", fish, err)
+	}
+
+	all, err := ReadAll[Fish](d, collection)
+	if err != nil || len(all) != 1 || all[0] != redfish {
+		t.Error("Expected redfish, got: ", all, err)
+	}
+}
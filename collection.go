@@ -0,0 +1,64 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Collection is a handle on a single collection whose records all decode
+// into T, sparing callers the collection name and type on every call
+type Collection[T any] struct {
+	d    *Driver
+	name string
+}
+
+// NewCollection returns a handle on the collection [name] of [d]
+func NewCollection[T any](d *Driver, name string) *Collection[T] {
+	return &Collection[T]{d: d, name: name}
+}
+
+// Name returns the name of the collection
+func (c *Collection[T]) Name() string {
+	return c.name
+}
+
+// Get reads [resource] into a new T
+func (c *Collection[T]) Get(resource string) (T, error) {
+	var v T
+	err := c.d.Read(c.name, resource, &v)
+	return v, err
+}
+
+// Put writes [v] as [resource]
+func (c *Collection[T]) Put(resource string, v T) error {
+	return c.d.Write(c.name, resource, v)
+}
+
+// All reads every record of the collection in sorted resource order. A
+// collection that doesn't exist yet has no records.
+func (c *Collection[T]) All() ([]T, error) {
+	collection := c.d.normalize(c.name)
+
+	names, records, err := c.d.readAllNamed(collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// decoded once the read lock is released, as migrating may write
+	all := make([]T, len(records))
+	for i, b := range records {
+		if err := c.d.decodeRecord(collection, names[i], b, &all[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return all, nil
+}
+
+// Delete removes [resource]
+func (c *Collection[T]) Delete(resource string) error {
+	return c.d.Delete(c.name, resource)
+}
@@ -0,0 +1,40 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestCollection(t *testing.T) {
+	d := newTestDB(t, nil)
+	fish := NewCollection[Fish](d, collection)
+
+	if all, err := fish.All(); err != nil || len(all) != 0 {
+		t.Error("Expected no fish yet, got: ", all, err)
+	}
+
+	if err := fish.Put("red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := fish.Put("blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	red, err := fish.Get("red")
+	if err != nil || red != redfish {
+		t.Error("Expected redfish, got: ", red, err)
+	}
+
+	all, err := fish.All()
+	if err != nil || len(all) != 2 || all[0].Type != "blue" || all[1].Type != "red" {
+		t.Error("Expected blue and red fish, got: ", all, err)
+	}
+
+	if err := fish.Delete("red"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+	if _, err := fish.Get("red"); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+}
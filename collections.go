@@ -0,0 +1,29 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// Collections returns the sorted names of every collection of the database,
+// nested ones by their slash separated names, such as "ocean/school".
+// Hidden directories, which hold jsondb's own bookkeeping, and files are
+// left out; a database without collections has none. The directory is read
+// without locks: a collection created meanwhile is in the list or not, but
+// never half listed.
+func (d *Driver) Collections() (names []string, err error) {
+	defer wrapOpError(&err, "collections", "", "")
+
+	names, err = d.allCollections()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if names == nil {
+		names = []string{}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,40 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// a database without collections has none, and isn't an error
+	names, err := d.Collections()
+	if err != nil || names == nil || len(names) != 0 {
+		t.Error("Expected no collections, got: ", names, err)
+	}
+
+	for _, c := range []string{"ocean", "fish", "ocean/school"} {
+		if err := d.Write(c, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// neither files nor hidden directories are collections
+	if err := os.WriteFile(filepath.Join(d.dir, "notes.txt"), []byte("hi"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(d.dir, ".hidden"), dirMode); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err = d.Collections()
+	if err != nil {
+		t.Fatal("Collections failed: ", err.Error())
+	}
+	if want := []string{"fish", "ocean", "ocean/school"}; !reflect.DeepEqual(names, want) {
+		t.Error("Expected ", want, ", got: ", names)
+	}
+}
@@ -0,0 +1,88 @@
+package jsondb
+
+import (
+	"fmt"
+	"os"
+)
+
+// CompressCollection locks the collection and gzips every record stored
+// uncompressed, e.g. before Compress was turned on for it. Records already
+// compressed are skipped, so an interrupted run can simply be repeated. It
+// returns how many records were converted.
+func (d *Driver) CompressCollection(collection string) (int, error) {
+	return d.recompress(collection, true)
+}
+
+// DecompressCollection is the inverse of CompressCollection, storing every
+// compressed record as plain JSON
+func (d *Driver) DecompressCollection(collection string) (int, error) {
+	return d.recompress(collection, false)
+}
+
+// ReadAuto reads a record into [v] whether it's stored gzipped or not,
+// whatever Options.Compress and the collection's settings say, so records
+// compressed by another tool stay readable. Read sniffs the gzip magic
+// number the same way; ReadAuto spells the guarantee out for callers that
+// depend on it.
+func (d *Driver) ReadAuto(collection, resource string, v interface{}) error {
+	return d.Read(collection, resource, v)
+}
+
+// recompress rewrites the records of [collection] compressed or not
+func (d *Driver) recompress(collection string, compress bool) (converted int, err error) {
+	// ensure there is a collection to convert
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	names, err := d.listDisk(collection)
+	if err != nil {
+		// nothing to convert in a collection that doesn't exist
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	for _, name := range names {
+		path := d.recordPath(collection, name)
+
+		b, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return converted, fmt.Errorf("convert %s/%s: %w", collection, name, err)
+		}
+
+		// leave records already in the wanted format alone
+		if isGzip(b) == compress {
+			continue
+		}
+
+		if compress {
+			b, err = gzipBytes(b)
+		} else {
+			b, err = gunzipBytes(b)
+		}
+		if err == nil {
+			err = d.writeBytes(path+tmpSuffix, path, b)
+		}
+		if err == nil {
+			err = d.recordChanged(collection, name)
+		}
+		d.cache.invalidate(collection, name)
+		if err != nil {
+			return converted, fmt.Errorf("convert %s/%s: %w", collection, name, err)
+		}
+		converted++
+	}
+
+	return converted, nil
+}
@@ -0,0 +1,76 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCompressCollection(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	converted, err := d.CompressCollection(collection)
+	if err != nil || converted != 2 {
+		t.Error("Expected 2 records compressed, got: ", converted, err)
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil || !isGzip(b) {
+		t.Error("Expected compressed record, got: ", b, err)
+	}
+
+	// compressed records stay readable whatever the settings
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	// converting again has nothing left to do
+	if converted, err := d.CompressCollection(collection); err != nil || converted != 0 {
+		t.Error("Expected nothing compressed, got: ", converted, err)
+	}
+
+	converted, err = d.DecompressCollection(collection)
+	if err != nil || converted != 2 {
+		t.Error("Expected 2 records decompressed, got: ", converted, err)
+	}
+
+	b, err = os.ReadFile(d.recordPath(collection, "blue"))
+	if err != nil || string(b) != `{"type":"blue"}` {
+		t.Error("Expected plain record, got: ", string(b), err)
+	}
+
+	if converted, err := d.CompressCollection("missing"); err != nil || converted != 0 {
+		t.Error("Expected nothing compressed in a missing collection, got: ", converted, err)
+	}
+}
+
+func TestReadAuto(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// another tool gzipped this one behind the driver's back
+	b, err := gzipBytes([]byte(`{"type":"red"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(d.recordPath(collection, "red"), b, fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"red", "blue"} {
+		fish := Fish{}
+		if err := d.ReadAuto(collection, name, &fish); err != nil || fish.Type != name {
+			t.Error("Expected ", name, " fish, got: ", fish.Type, err)
+		}
+	}
+}
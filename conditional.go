@@ -0,0 +1,137 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+)
+
+// ReadIfModifiedSince reads a record into [v] only if it was modified after
+// [since]; otherwise [v] is left untouched and modified is false. A missing
+// record returns ErrNotFound.
+func (d *Driver) ReadIfModifiedSince(collection, resource string, since time.Time,
+	v interface{}) (modified bool, err error) {
+	defer wrapOpError(&err, "read", collection, resource)
+
+	// ensure there is a place to read record from
+	if collection == "" {
+		return false, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to read
+	if resource == "" {
+		return false, ErrMissingResource
+	}
+
+	info, err := d.statRecord(collection, resource)
+	if err != nil {
+		return false, err
+	}
+
+	if !info.ModTime().After(since) {
+		return false, nil
+	}
+
+	b, err := d.read(collection, resource)
+	if err != nil {
+		return false, err
+	}
+
+	return true, d.decodeRecord(collection, resource, b, v)
+}
+
+// DeleteIf locks [resource] and removes it only if its stored JSON is what
+// writing [expected] would store, so a record modified since it was read
+// isn't deleted by mistake. It reports whether the record was deleted; a
+// record that differs or doesn't exist is left alone and returns false.
+func (d *Driver) DeleteIf(collection, resource string, expected interface{}) (deleted bool, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "delete", collection, resource)
+	defer d.countOp(collection, opDelete, &err)
+
+	// ensure there is a place to delete record from
+	if collection == "" {
+		return false, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to delete
+	if resource == "" {
+		return false, ErrMissingResource
+	}
+
+	want, err := d.marshal(collection, resource, expected)
+	if err != nil {
+		return false, err
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	current, err := d.readExisting(collection, resource)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(current, want) {
+		return false, nil
+	}
+
+	if d.beforeDelete != nil {
+		if err := d.beforeDelete(collection, resource); err != nil {
+			return false, err
+		}
+	}
+
+	if err := d.remove(collection, resource); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// statRecord returns the file info of a record, falling back to the seed
+// records, and ErrNotFound if it exists in neither place
+func (d *Driver) statRecord(collection, resource string) (fs.FileInfo, error) {
+	if err := d.checkNames(collection, resource); err != nil {
+		return nil, err
+	}
+
+	// the file holding a single file collection's records stands in for each
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return nil, err
+	}
+	if single {
+		if _, err := d.readSingle(collection, resource); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		return os.Stat(d.singlePath(collection))
+	}
+
+	info, err := os.Stat(d.recordPath(collection, resource))
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return info, err
+	}
+
+	if d.source != nil && !d.buried(collection, resource) {
+		if info, serr := fs.Stat(d.source, path.Join(d.collectionPath(collection), d.fileName(resource))); serr == nil {
+			return info, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
@@ -0,0 +1,65 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestReadIfModifiedSince(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	before := time.Now().Add(-time.Minute)
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	modified, err := d.ReadIfModifiedSince(collection, "redfish", before, &fish)
+	if err != nil || !modified || fish.Type != "red" {
+		t.Error("Expected modified red fish, got: ", modified, fish.Type, err)
+	}
+
+	fish = Fish{}
+	modified, err = d.ReadIfModifiedSince(collection, "redfish", time.Now().Add(time.Minute), &fish)
+	if err != nil || modified || fish.Type != "" {
+		t.Error("Expected unmodified and untouched fish, got: ", modified, fish.Type, err)
+	}
+
+	if _, err := d.ReadIfModifiedSince(collection, "missing", before, &fish); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+}
+
+func TestDeleteIf(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a stale copy doesn't delete the record
+	deleted, err := d.DeleteIf(collection, "redfish", Fish{Type: "blue"})
+	if err != nil || deleted {
+		t.Error("Expected the record to be kept, got: ", deleted, err)
+	}
+
+	if err := d.Read(collection, "redfish", &Fish{}); err != nil {
+		t.Error("Expected red fish to still exist, got: ", err)
+	}
+
+	deleted, err = d.DeleteIf(collection, "redfish", redfish)
+	if err != nil || !deleted {
+		t.Error("Expected the record to be deleted, got: ", deleted, err)
+	}
+
+	if err := d.Read(collection, "redfish", &Fish{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected red fish to be removed, got: ", err)
+	}
+
+	deleted, err = d.DeleteIf(collection, "redfish", redfish)
+	if err != nil || deleted {
+		t.Error("Expected nothing deleted for a missing record, got: ", deleted, err)
+	}
+}
@@ -0,0 +1,396 @@
+package jsondb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// configName is the file in a collection holding its CollectionConfig
+const configName = ".config.json"
+
+// CollectionConfig holds the settings of a single collection. A collection
+// configured with ConfigureCollection uses its own settings instead of the
+// ones given in Options.
+type CollectionConfig struct {
+	Compress bool   `json:"compress,omitempty"` // gzip records at rest
+	Indent   string `json:"indent,omitempty"`   // indent records on disk with this
+
+	// SingleFile keeps every record of the collection in one JSON object
+	// file, keyed by resource, rather than a file per record, sparing small
+	// collections an inode and a directory entry per record. Each write or
+	// delete atomically rewrites the whole file, so it suits collections of
+	// few small records. Tombstones, checksums, key files, the manifest, the
+	// replica and the operations working on record files directly, like
+	// Reserve, ReadMapped and RenameSafe, don't cover single file records.
+	SingleFile bool `json:"singleFile,omitempty"`
+
+	// Bloom keeps a bloom filter of the collection's resource names in
+	// memory, so Exists answers for most absent records without touching
+	// the disk; see EnableBloom
+	Bloom bool `json:"bloom,omitempty"`
+}
+
+// configs caches the settings of configured collections
+type configs struct {
+	mutex  sync.Mutex
+	byName map[string]CollectionConfig
+}
+
+// forget drops the cached settings of a collection and its nested collections
+func (c *configs) forget(collection string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for name := range c.byName {
+		if name == collection || strings.HasPrefix(name, collection+"/") {
+			delete(c.byName, name)
+		}
+	}
+}
+
+// ConfigureCollection locks the collection and persists [cfg] as its
+// settings, overriding the driver's Options for it. Records already stored
+// keep their format until they're written again; SingleFile can only be
+// changed while the collection has no records.
+func (d *Driver) ConfigureCollection(collection string, cfg CollectionConfig) error {
+	collection = d.normalize(collection)
+
+	// ensure there is a collection to configure
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// records stored the other way would no longer be found
+	current, err := d.collectionConfig(collection)
+	if err != nil {
+		return err
+	}
+	if current.SingleFile != cfg.SingleFile {
+		names, err := d.listDisk(collection)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if len(names) > 0 {
+			return fmt.Errorf("cannot change SingleFile of collection %s, which has records", collection)
+		}
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	dir := d.collectionDir(collection)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, configName), b); err != nil {
+		return err
+	}
+
+	d.configs.mutex.Lock()
+	d.configs.byName[collection] = cfg
+	d.configs.mutex.Unlock()
+
+	if !cfg.Bloom {
+		d.blooms.forget(collection)
+	}
+
+	d.cache.invalidate(collection, "")
+	return nil
+}
+
+// collectionConfig returns the settings in effect for [collection]
+func (d *Driver) collectionConfig(collection string) (CollectionConfig, error) {
+	collection = d.normalize(collection)
+
+	d.configs.mutex.Lock()
+	cfg, ok := d.configs.byName[collection]
+	d.configs.mutex.Unlock()
+
+	if ok {
+		return cfg, nil
+	}
+
+	// the file is read without holding the mutex, which every write takes
+	b, err := os.ReadFile(filepath.Join(d.collectionDir(collection), configName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return d.defaults, nil
+	}
+	if err != nil {
+		// a record in place of the collection directory can't be read through
+		if cerr := d.collectionCollision(collection); cerr != nil {
+			return CollectionConfig{}, cerr
+		}
+		return CollectionConfig{}, err
+	}
+
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return CollectionConfig{}, err
+	}
+
+	d.configs.mutex.Lock()
+	defer d.configs.mutex.Unlock()
+
+	// settings ConfigureCollection stored meanwhile are newer than the file read
+	if cached, ok := d.configs.byName[collection]; ok {
+		return cached, nil
+	}
+
+	d.configs.byName[collection] = cfg
+	return cfg, nil
+}
+
+// marshal encodes [v] as the record [resource] of [collection]
+func (d *Driver) marshal(collection, resource string, v interface{}) ([]byte, error) {
+	return d.marshalStamped(collection, resource, v, stamps{})
+}
+
+// stamps are the members marshalStamped sets in a record; zero ones are left
+// out
+type stamps struct {
+	rev     int   // the revision, see WriteWithRev
+	expires int64 // the expiry in nanoseconds since the Unix epoch, see WriteTTL
+}
+
+// marshalStamped is marshal setting the members of [s] in the record, before
+// it's formatted and validated like any other
+func (d *Driver) marshalStamped(collection, resource string, v interface{}, s stamps) ([]byte, error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	cfg, err := d.collectionConfig(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.beforeWrite != nil {
+		replaced, err := d.beforeWrite(collection, resource, v)
+		if err != nil {
+			return nil, err
+		}
+		if replaced != nil {
+			v = replaced
+		}
+	}
+
+	if d.tagKey != "" && d.codec == nil {
+		v = tagged(reflect.ValueOf(v), d.tagKey)
+	}
+
+	b, err := d.encodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.stripFields) > 0 {
+		if b, err = stripFields(b, d.stripFields); err != nil {
+			return nil, err
+		}
+	}
+
+	if version := d.migrations.latest(collection); version > 0 {
+		if b, err = stampVersion(b, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.rev > 0 {
+		if b, err = stampInt(b, revField, s.rev); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.expires != 0 {
+		if b, err = stampExpiry(b, s.expires); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.canonicalJSON {
+		if b, err = canonicalJSON(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Indent != "" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, b, "", cfg.Indent); err != nil {
+			return nil, err
+		}
+		b = buf.Bytes()
+	}
+
+	if d.trailingNewline {
+		b = append(b, '\n')
+	}
+
+	if err := d.checkRecord(collection, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// checkRecord runs the marshaled [b] by Options.RequireObject, the validator
+// and the schema of [collection]
+func (d *Driver) checkRecord(collection string, b []byte) error {
+	if err := d.checkShape(b); err != nil {
+		return err
+	}
+
+	if err := d.validators.validate(collection, b); err != nil {
+		return err
+	}
+
+	return d.validateSchema(collection, b)
+}
+
+// canonicalJSON re-encodes [b] with the members of every object sorted by
+// name; numbers are kept exactly as they were written
+func canonicalJSON(b []byte) ([]byte, error) {
+	v, err := decodeValue(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// encoding/json sorts the keys of maps
+	return json.Marshal(v)
+}
+
+// checkShape makes sure the marshaled [b] is a JSON object, or an array when
+// those are allowed, if Options.RequireObject asks for it
+func (d *Driver) checkShape(b []byte) error {
+	if !d.requireObject {
+		return nil
+	}
+
+	b = bytes.TrimLeft(b, " \t\r\n")
+	if len(b) > 0 && (b[0] == '{' || (d.allowArrays && b[0] == '[')) {
+		return nil
+	}
+
+	return ErrInvalidRecordShape
+}
+
+// stripFields removes the top-level [fields] from the JSON object [b],
+// keeping the other members in order; anything but an object, or an object
+// without any of the fields, is returned as is
+func stripFields(b []byte, fields []string) ([]byte, error) {
+	obj, ok, err := parseObject(b)
+	if err != nil || !ok {
+		return b, err
+	}
+
+	strip := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		strip[f] = true
+	}
+
+	kept := obj[:0]
+	for _, m := range obj {
+		if !strip[m.name] {
+			kept = append(kept, m)
+		}
+	}
+
+	if len(kept) == len(obj) {
+		return b, nil
+	}
+
+	return kept.MarshalJSON()
+}
+
+// parseObject splits the JSON object [b] into its members in order,
+// reporting false if [b] holds anything but an object
+func parseObject(b []byte) (taggedObject, bool, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, false, nil
+	}
+
+	obj := taggedObject{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false, err
+		}
+
+		obj = append(obj, taggedMember{name: t.(string), value: value})
+	}
+
+	return obj, true, nil
+}
+
+// encode turns the marshaled [b] into the bytes stored on disk for a record
+// of [collection]
+func (d *Driver) encode(collection string, b []byte) ([]byte, error) {
+	cfg, err := d.collectionConfig(collection)
+	if err != nil || !cfg.Compress {
+		return b, err
+	}
+
+	return gzipBytes(b)
+}
+
+// decode reverses encode; compressed records are decompressed whatever the
+// settings of [collection], so they stay readable after Compress is turned off
+func (d *Driver) decode(collection string, b []byte) ([]byte, error) {
+	if !isGzip(b) {
+		return b, nil
+	}
+
+	return gunzipBytes(b)
+}
+
+// isGzip reports whether [b] starts with the gzip magic number, which no JSON
+// document can
+func isGzip(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(b []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
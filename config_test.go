@@ -0,0 +1,181 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigureCollection(t *testing.T) {
+	d := newTestDB(t, &Options{Indent: "  "})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the global options apply to unconfigured collections
+	b, err := os.ReadFile(filepath.Join(d.dir, collection, "redfish"))
+	if err != nil || !bytes.Contains(b, []byte("\n  ")) {
+		t.Error("Expected indented record, got: ", string(b), err)
+	}
+
+	if err := d.ConfigureCollection("zipped", CollectionConfig{Compress: true}); err != nil {
+		t.Fatal("Failed to configure: ", err.Error())
+	}
+
+	if err := d.Write("zipped", "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, err = os.ReadFile(filepath.Join(d.dir, "zipped", "redfish"))
+	if err != nil || !isGzip(b) {
+		t.Error("Expected compressed record, got: ", b, err)
+	}
+
+	fish := Fish{}
+	if err := d.Read("zipped", "redfish", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	// the config isn't a record
+	if records, err := d.ReadAll("zipped"); err != nil || len(records) != 1 || records[0][0] != '{' {
+		t.Error("Expected 1 decompressed record, got: ", records, err)
+	}
+
+	// settings survive reopening the database
+	reopened, err := New(d.dir, &Options{Debug: t.Logf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reopened.Read("zipped", "redfish", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish after reopen, got: ", fish.Type, err)
+	}
+}
+
+func TestStripFields(t *testing.T) {
+	d := newTestDB(t, &Options{StripFields: []string{"secret", "computed"}})
+
+	record := map[string]interface{}{
+		"type":     "red",
+		"secret":   "hunter2",
+		"computed": 42,
+		"nested":   map[string]interface{}{"secret": "kept"},
+	}
+	if err := d.Write(collection, "red", record); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"nested":{"secret":"kept"},"type":"red"}`; string(b) != want {
+		t.Error("Expected ", want, ", got: ", string(b))
+	}
+
+	// records that aren't objects pass through
+	if err := d.Write(collection, "list", []string{"secret"}); err != nil {
+		t.Fatal("Create list failed: ", err.Error())
+	}
+
+	var list []string
+	if err := d.Read(collection, "list", &list); err != nil || len(list) != 1 {
+		t.Error("Expected the list untouched, got: ", list, err)
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	d := newTestDB(t, &Options{TrailingNewline: true})
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil || string(b) != "{\"type\":\"red\"}\n" {
+		t.Error("Expected a newline at the end, got: ", string(b), err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+}
+
+func TestRequireObject(t *testing.T) {
+	d := newTestDB(t, &Options{RequireObject: true})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Error("Expected an object to be written, got: ", err)
+	}
+
+	for _, v := range []interface{}{42, "x", nil, []string{"red"}} {
+		if err := d.Write(collection, "badfish", v); !errors.Is(err, ErrInvalidRecordShape) {
+			t.Error("Expected ErrInvalidRecordShape for ", v, ", got: ", err)
+		}
+	}
+
+	d = newTestDB(t, &Options{RequireObject: true, AllowArrays: true})
+
+	if err := d.Write(collection, "school", []Fish{redfish}); err != nil {
+		t.Error("Expected an array to be written, got: ", err)
+	}
+
+	if err := d.Write(collection, "badfish", 42); !errors.Is(err, ErrInvalidRecordShape) {
+		t.Error("Expected ErrInvalidRecordShape, got: ", err)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	type before struct {
+		Type string `json:"type"`
+		Fins int    `json:"fins"`
+		Tank struct {
+			Size  int    `json:"size"`
+			Color string `json:"color"`
+		} `json:"tank"`
+	}
+
+	type after struct {
+		Tank struct {
+			Color string `json:"color"`
+			Size  int    `json:"size"`
+		} `json:"tank"`
+		Fins int    `json:"fins"`
+		Type string `json:"type"`
+	}
+
+	d := newTestDB(t, &Options{CanonicalJSON: true})
+
+	a := before{Type: "red", Fins: 4}
+	a.Tank.Size, a.Tank.Color = 10, "blue"
+
+	b := after{Type: "red", Fins: 4}
+	b.Tank.Size, b.Tank.Color = 10, "blue"
+
+	if err := d.Write(collection, "a", a); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Write(collection, "b", b); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	rawA, err := os.ReadFile(d.recordPath(collection, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawB, err := os.ReadFile(d.recordPath(collection, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"fins":4,"tank":{"color":"blue","size":10},"type":"red"}`
+	if string(rawA) != want || string(rawB) != want {
+		t.Error("Expected both records stored as ", want, ", got: ", string(rawA), string(rawB))
+	}
+}
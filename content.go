@@ -0,0 +1,69 @@
+package jsondb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// objectsDir holds the payloads of content addressed records
+const objectsDir = ".objects"
+
+// stageObject stores [b] under its hash in the objects directory, unless an
+// identical payload is already there, then creates a symlink to it at
+// [tmpPath] ready to be renamed to [dstPath]
+func (d *Driver) stageObject(tmpPath, dstPath string, b []byte) error {
+	sum := sha256.Sum256(b)
+	dir := filepath.Join(d.dir, objectsDir)
+	obj := filepath.Join(dir, hex.EncodeToString(sum[:]))
+
+	switch _, err := os.Stat(obj); {
+	case os.IsNotExist(err):
+		if err := storeObject(dir, obj, b); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+
+	// link relative to the record so the database directory can be moved
+	target, err := filepath.Rel(filepath.Dir(dstPath), obj)
+	if err != nil {
+		return err
+	}
+
+	// a temp link left behind by an interrupted write would make Symlink fail
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.Symlink(target, tmpPath)
+}
+
+// storeObject atomically writes a new object; the temp file is unique since
+// writers in different collections may store the same object concurrently
+func storeObject(dir, obj string, b []byte) error {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(obj)+"-*"+tmpSuffix)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := f.Name()
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, obj)
+}
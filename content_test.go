@@ -0,0 +1,54 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContentAddressed(t *testing.T) {
+	d := newTestDB(t, &Options{ContentAddressed: true})
+
+	// two records with the same payload
+	for _, name := range []string{"one", "two"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	objects, err := os.ReadDir(filepath.Join(d.dir, objectsDir))
+	if err != nil {
+		t.Fatal("Failed to read objects: ", err.Error())
+	}
+
+	if len(objects) != 1 {
+		t.Error("Expected duplicate payloads to share 1 object, got: ", len(objects))
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "two", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	// overwriting one record leaves the other untouched
+	if err := d.Write(collection, "one", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Read(collection, "two", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 2 {
+		t.Error("Expected 2 records, got: ", len(records), err)
+	}
+
+	if err := d.Delete(collection, "one"); err != nil {
+		t.Error("Failed to delete: ", err.Error())
+	}
+
+	if err := d.Read(collection, "one", &fish); err == nil {
+		t.Error("Expected nothing, got fish")
+	}
+}
@@ -0,0 +1,38 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Count returns how many records [collection] holds, read locking it so the
+// count is taken between writes rather than in the middle of one. Writers
+// stage a record in a temp file and rename it into place, which Count never
+// counts, so a record is counted once whether it was written just before or
+// is being rewritten meanwhile. Only the directory is listed, no record is
+// read, and nested collections aren't records. Records a BufferedWriter
+// hasn't stored yet are counted too; a collection that doesn't exist has
+// none.
+func (d *Driver) Count(collection string) (n int, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "count", collection, "")
+
+	// ensure there is a collection to count
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	return len(d.overlay.merge(collection, names)), nil
+}
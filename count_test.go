@@ -0,0 +1,97 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if n, err := d.Count(collection); err != nil || n != 0 {
+		t.Error("Expected no records, got: ", n, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := d.Write(collection, strconv.Itoa(i), redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if n, err := d.Count(collection); err != nil || n != 3 {
+		t.Error("Expected 3 records, got: ", n, err)
+	}
+
+	// neither temp files nor nested collections are counted
+	if err := os.WriteFile(d.recordPath(collection, "3")+tmpSuffix, []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Write(collection+"/school", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if n, err := d.Count(collection); err != nil || n != 3 {
+		t.Error("Expected 3 records, got: ", n, err)
+	}
+
+	if _, err := d.Count("../fish"); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+}
+
+func TestCountDuringWrites(t *testing.T) {
+	for _, resourceLevel := range []bool{false, true} {
+		d := newTestDB(t, &Options{ResourceLevelLocking: resourceLevel})
+
+		const fixed = 5
+		for i := 0; i < fixed; i++ {
+			if err := d.Write(collection, strconv.Itoa(i), redfish); err != nil {
+				t.Fatal("Create fish failed: ", err.Error())
+			}
+		}
+
+		// rewriting the same records and adding one more can only ever be
+		// counted as the fixed records, or those and the new one
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		for i := 0; i < fixed; i++ {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if err := d.Write(collection, name, redfish); err != nil {
+						t.Error("Rewrite fish failed: ", err.Error())
+						return
+					}
+				}
+			}(strconv.Itoa(i))
+		}
+
+		for i := 0; i < 200; i++ {
+			if i == 100 {
+				if err := d.Write(collection, "new", redfish); err != nil {
+					t.Fatal("Create fish failed: ", err.Error())
+				}
+			}
+
+			n, err := d.Count(collection)
+			if err != nil {
+				t.Fatal("Count failed: ", err.Error())
+			}
+			if n != fixed && n != fixed+1 {
+				t.Fatal("Expected ", fixed, " or ", fixed+1, " records, got: ", n)
+			}
+		}
+
+		close(stop)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,107 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Counter is an integer persisted as a record, updated under its
+// collection's lock so concurrent increments are never lost
+type Counter struct {
+	d          *Driver
+	collection string
+	name       string
+}
+
+// Counter returns a handle to the counter stored as [name] in [collection].
+// A counter that was never written reads as zero.
+func (d *Driver) Counter(collection, name string) *Counter {
+	return &Counter{d: d, collection: collection, name: name}
+}
+
+// Inc adds [delta] to the counter and returns its new value
+func (c *Counter) Inc(delta int64) (int64, error) {
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+
+	unlock, err := c.d.lockResource(c.collection, c.name)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	defer c.d.cache.invalidate(c.collection, c.name)
+
+	n, err := c.get()
+	if err != nil {
+		return 0, err
+	}
+
+	n += delta
+	return n, c.d.write(c.collection, c.name, n)
+}
+
+// Get returns the counter's current value
+func (c *Counter) Get() (int64, error) {
+	if err := c.check(); err != nil {
+		return 0, err
+	}
+
+	unlock, err := c.d.rlockCollections(c.collection)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	return c.get()
+}
+
+// Reset sets the counter back to zero
+func (c *Counter) Reset() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	unlock, err := c.d.lockResource(c.collection, c.name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer c.d.cache.invalidate(c.collection, c.name)
+
+	return c.d.write(c.collection, c.name, 0)
+}
+
+func (c *Counter) check() error {
+	// ensure there is a place to save the counter
+	if c.collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save the counter as
+	if c.name == "" {
+		return ErrMissingResource
+	}
+
+	return nil
+}
+
+// get reads the counter; the caller holds the collection lock
+func (c *Counter) get() (int64, error) {
+	b, err := c.d.readFile(c.collection, c.name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	if err := json.Unmarshal(b, &n); err != nil {
+		return 0, fmt.Errorf("counter %s/%s: %w", c.collection, c.name, err)
+	}
+
+	return n, nil
+}
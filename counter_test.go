@@ -0,0 +1,44 @@
+package jsondb
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	d := newTestDB(t, nil)
+	c := d.Counter("counters", "visits")
+
+	if n, err := c.Get(); err != nil || n != 0 {
+		t.Error("Expected new counter to be 0, got: ", n, err)
+	}
+
+	// concurrent increments are all counted
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Inc(2); err != nil {
+				t.Error("Failed to increment: ", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, err := d.Counter("counters", "visits").Get(); err != nil || n != 40 {
+		t.Error("Expected 40, got: ", n, err)
+	}
+
+	if err := c.Reset(); err != nil {
+		t.Fatal("Failed to reset: ", err.Error())
+	}
+
+	if n, err := c.Inc(-1); err != nil || n != -1 {
+		t.Error("Expected -1 after reset, got: ", n, err)
+	}
+
+	if _, err := d.Counter("", "visits").Inc(1); err != ErrMissingCollection {
+		t.Error("Expected ErrMissingCollection, got: ", err)
+	}
+}
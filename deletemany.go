@@ -0,0 +1,71 @@
+package jsondb
+
+import (
+	"errors"
+)
+
+// DeleteMany locks the collection once and removes each of [resources],
+// returning how many were removed. Resources that don't exist are skipped,
+// so [deleted] falls short of len(resources) by the number of those.
+func (d *Driver) DeleteMany(collection string, resources []string) (deleted int, err error) {
+	defer wrapOpError(&err, "deletemany", collection, "")
+
+	// ensure there is a place to delete records from
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	for _, resource := range resources {
+		// ensure there is a resource (name) to delete
+		if resource == "" {
+			return 0, ErrMissingResource
+		}
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	seen := make(map[string]bool, len(resources))
+	ops := make([]walOp, 0, len(resources))
+	for _, resource := range resources {
+		if seen[resource] {
+			continue
+		}
+		seen[resource] = true
+
+		_, err := d.statRecord(collection, resource)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, &OpError{Op: "delete", Collection: collection, Resource: resource, Err: err}
+		}
+
+		// one refused delete leaves all of them undone
+		if d.beforeDelete != nil {
+			if err := d.beforeDelete(collection, resource); err != nil {
+				return 0, &OpError{Op: "delete", Collection: collection, Resource: resource, Err: err}
+			}
+		}
+
+		ops = append(ops, walOp{Resource: resource, Delete: true})
+	}
+
+	if err := d.beginWAL(collection, ops); err != nil {
+		return 0, err
+	}
+
+	for _, op := range ops {
+		err := d.applyOp(collection, op)
+		d.cache.invalidate(collection, op.Resource)
+		if err != nil {
+			return deleted, &OpError{Op: "delete", Collection: collection, Resource: op.Resource, Err: err}
+		}
+		deleted++
+	}
+
+	return deleted, d.endWAL(collection, ops)
+}
@@ -0,0 +1,32 @@
+package jsondb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeleteMany(t *testing.T) {
+	for _, opts := range []*Options{nil, {Tombstones: true}, {WAL: true}} {
+		d := newTestDB(t, opts)
+
+		for _, name := range []string{"red", "blue", "green"} {
+			if err := d.Write(collection, name, redfish); err != nil {
+				t.Fatal("Create fish failed: ", err.Error())
+			}
+		}
+
+		deleted, err := d.DeleteMany(collection, []string{"red", "missing", "blue", "red"})
+		if err != nil || deleted != 2 {
+			t.Error("Expected 2 fish deleted, got: ", deleted, err)
+		}
+
+		names, err := d.list(collection)
+		if err != nil || len(names) != 1 || names[0] != "green" {
+			t.Error("Expected only green left, got: ", names, err)
+		}
+
+		if _, err := d.DeleteMany(collection, []string{"green", ""}); !errors.Is(err, ErrMissingResource) {
+			t.Error("Expected ErrMissingResource, got: ", err)
+		}
+	}
+}
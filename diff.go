@@ -0,0 +1,73 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+)
+
+// DiffCollections compares the records of collections [a] and [b] while
+// holding their read locks. Added are the resources only [b] has, removed
+// those only [a] has, and changed those both have with different bytes; each
+// list is sorted. A collection that doesn't exist has no records.
+func (d *Driver) DiffCollections(a, b string) (added, removed, changed []string, err error) {
+	defer wrapOpError(&err, "diff", a, "")
+
+	// ensure there are collections to compare
+	if a == "" || b == "" {
+		return nil, nil, nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(a, b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer unlock()
+
+	namesA, err := d.list(a)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil, err
+	}
+
+	namesB, err := d.list(b)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil, &OpError{Op: "diff", Collection: b, Err: err}
+	}
+
+	inA := make(map[string]bool, len(namesA))
+	for _, name := range namesA {
+		inA[name] = true
+	}
+
+	added, removed, changed = []string{}, []string{}, []string{}
+	inB := make(map[string]bool, len(namesB))
+	for _, name := range namesB {
+		inB[name] = true
+		if !inA[name] {
+			added = append(added, name)
+			continue
+		}
+
+		recordA, err := d.readFile(a, name)
+		if err != nil {
+			return nil, nil, nil, &OpError{Op: "read", Collection: a, Resource: name, Err: err}
+		}
+
+		recordB, err := d.readFile(b, name)
+		if err != nil {
+			return nil, nil, nil, &OpError{Op: "read", Collection: b, Resource: name, Err: err}
+		}
+
+		if !bytes.Equal(recordA, recordB) {
+			changed = append(changed, name)
+		}
+	}
+
+	for _, name := range namesA {
+		if !inB[name] {
+			removed = append(removed, name)
+		}
+	}
+
+	return added, removed, changed, nil
+}
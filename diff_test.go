@@ -0,0 +1,45 @@
+package jsondb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffCollections(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"red", "blue", "green"} {
+		if err := d.Write("a", name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "green"}, {Type: "gold"}} {
+		if err := d.Write("b", f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+	if err := d.Write("b", "green", Fish{Type: "lime"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	added, removed, changed, err := d.DiffCollections("a", "b")
+	if err != nil {
+		t.Fatal("DiffCollections failed: ", err.Error())
+	}
+	if !reflect.DeepEqual(added, []string{"gold"}) {
+		t.Error("Expected gold added, got: ", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"blue"}) {
+		t.Error("Expected blue removed, got: ", removed)
+	}
+	if !reflect.DeepEqual(changed, []string{"green"}) {
+		t.Error("Expected green changed, got: ", changed)
+	}
+
+	// a missing collection has nothing in it
+	added, removed, _, err = d.DiffCollections("a", "missing")
+	if err != nil || len(added) != 0 || len(removed) != 3 {
+		t.Error("Expected everything removed, got: ", added, removed, err)
+	}
+}
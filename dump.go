@@ -0,0 +1,97 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Dump reads the whole database into memory as a map from collection to
+// resource to record, holding read locks on every collection so the result
+// is consistent. Collections without records of their own, such as the
+// parents of nested collections, are left out.
+func (d *Driver) Dump() (map[string]map[string]json.RawMessage, error) {
+	collections, err := d.allCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := d.rlockCollections(collections...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	dump := make(map[string]map[string]json.RawMessage, len(collections))
+	for _, collection := range collections {
+		names, err := d.list(collection)
+		if err != nil {
+			return nil, &OpError{Op: "dump", Collection: collection, Err: err}
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		records := make(map[string]json.RawMessage, len(names))
+		for _, name := range names {
+			b, err := d.readFile(collection, name)
+			if err != nil {
+				return nil, &OpError{Op: "dump", Collection: collection, Resource: name, Err: err}
+			}
+			records[name] = b
+		}
+
+		dump[collection] = records
+	}
+
+	return dump, nil
+}
+
+// Load writes every record of a [dump] made by Dump, overwriting records
+// that already exist and leaving the others alone
+func (d *Driver) Load(dump map[string]map[string]json.RawMessage) error {
+	collections := make([]string, 0, len(dump))
+	for collection := range dump {
+		// ensure there is a place to save records
+		if collection == "" {
+			return ErrMissingCollection
+		}
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	for _, collection := range collections {
+		if err := d.load(collection, dump[collection]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Driver) load(collection string, records map[string]json.RawMessage) error {
+	names := make([]string, 0, len(records))
+	for name := range records {
+		// ensure there is a resource (name) to save each record as
+		if name == "" {
+			return &OpError{Op: "load", Collection: collection, Err: ErrMissingResource}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return &OpError{Op: "load", Collection: collection, Err: err}
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, "")
+
+	for _, name := range names {
+		if err := d.write(collection, name, records[name]); err != nil {
+			return &OpError{Op: "load", Collection: collection, Resource: name, Err: err}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDumpLoad(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write("ocean/"+collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	dump, err := d.Dump()
+	if err != nil {
+		t.Fatal("Dump failed: ", err.Error())
+	}
+
+	want := map[string]map[string]json.RawMessage{
+		collection:            {"red": json.RawMessage(`{"type":"red"}`)},
+		"ocean/" + collection: {"blue": json.RawMessage(`{"type":"blue"}`)},
+	}
+	if !reflect.DeepEqual(dump, want) {
+		t.Error("Expected dump ", want, ", got: ", dump)
+	}
+
+	// a dump loads into another database unchanged
+	other := newTestDB(t, nil)
+	if err := other.Load(dump); err != nil {
+		t.Fatal("Load failed: ", err.Error())
+	}
+
+	loaded, err := other.Dump()
+	if err != nil || !reflect.DeepEqual(loaded, dump) {
+		t.Error("Expected the loaded database to dump the same, got: ", loaded, err)
+	}
+}
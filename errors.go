@@ -0,0 +1,38 @@
+package jsondb
+
+import (
+	"errors"
+)
+
+// OpError records the operation, collection and resource an error happened
+// in. Sentinel errors such as ErrMissingCollection can still be matched with
+// errors.Is through it.
+type OpError struct {
+	Op         string
+	Collection string
+	Resource   string
+	Err        error
+}
+
+func (e *OpError) Error() string {
+	name := e.Collection
+	if e.Resource != "" {
+		name += "/" + e.Resource
+	}
+
+	return e.Op + " " + name + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// wrapOpError wraps a non-nil *[err] in an *OpError, unless it already is one
+func wrapOpError(err *error, op, collection, resource string) {
+	var opErr *OpError
+	if *err == nil || errors.As(*err, &opErr) {
+		return
+	}
+
+	*err = &OpError{Op: op, Collection: collection, Resource: resource, Err: *err}
+}
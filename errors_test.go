@@ -0,0 +1,37 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestOpError(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// sentinels still match through the wrapper
+	err := d.Write("", "redfish", redfish)
+	if !errors.Is(err, ErrMissingCollection) {
+		t.Error("Expected ErrMissingCollection, got: ", err)
+	}
+
+	// failures carry the record involved
+	err = d.Read(collection, "missing", &Fish{})
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) {
+		t.Fatal("Expected *OpError, got: ", err)
+	}
+
+	if opErr.Op != "read" || opErr.Collection != collection || opErr.Resource != "missing" {
+		t.Error("Unexpected error context: ", opErr)
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected the underlying not exist error, got: ", err)
+	}
+
+	if err.Error() != "read fish/missing: "+opErr.Err.Error() {
+		t.Error("Unexpected error message: ", err.Error())
+	}
+}
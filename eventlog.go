@@ -0,0 +1,296 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// logOffsetName is the file in a log's collection holding its next offset
+const logOffsetName = ".offset"
+
+// logCheckpointName is the record of a log holding its latest checkpoint
+const logCheckpointName = "checkpoint"
+
+// logDigits is how many digits entry offsets are zero padded to, enough for
+// any uint64 so entries sort by offset
+const logDigits = 20
+
+// Log is a collection used as an append-only log of entries, each stored as
+// a record named after its offset
+type Log struct {
+	d          *Driver
+	collection string
+}
+
+// Log returns a handle to [collection] as an append-only log. Offsets start
+// at zero and only ever grow: the next one is stored with the collection and
+// claimed before its entry is written, so a crash may skip an offset but
+// never hands one out twice, across restarts too.
+func (d *Driver) Log(collection string) *Log {
+	return &Log{d: d, collection: d.normalize(collection)}
+}
+
+// Append locks the log and stores [v] as its next entry, returning the
+// entry's offset
+func (l *Log) Append(v interface{}) (offset uint64, err error) {
+	defer wrapOpError(&err, "append", l.collection, "")
+
+	// ensure there is a place to save the entry
+	if l.collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	unlock, err := l.d.lockResource(l.collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := l.d.ensureDir(l.collection); err != nil {
+		return 0, err
+	}
+
+	if offset, err = l.next(); err != nil {
+		return 0, err
+	}
+
+	if err := l.storeNext(offset + 1); err != nil {
+		return 0, err
+	}
+
+	resource := logResource(offset)
+	defer l.d.cache.invalidate(l.collection, resource)
+
+	return offset, l.d.write(l.collection, resource, v)
+}
+
+// ReadFrom calls [fn] with every entry at [offset] or later, in order, until
+// [fn] returns an error, which ReadFrom then returns. The log isn't locked
+// while [fn] runs, so it may append; entries appended meanwhile may be left
+// out.
+func (l *Log) ReadFrom(offset uint64, fn func(offset uint64, raw []byte) error) (err error) {
+	defer wrapOpError(&err, "readfrom", l.collection, "")
+
+	// ensure there is a log to read
+	if l.collection == "" {
+		return ErrMissingCollection
+	}
+
+	offsets, err := l.offsets()
+	if err != nil {
+		return err
+	}
+
+	for _, o := range offsets {
+		if o < offset {
+			continue
+		}
+
+		b, err := l.d.read(l.collection, logResource(o))
+		if errors.Is(err, fs.ErrNotExist) {
+			// removed since it was listed
+			continue
+		}
+		if err != nil {
+			return &OpError{Op: "read", Collection: l.collection, Resource: logResource(o), Err: err}
+		}
+
+		if err := fn(o, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// logCheckpoint is the record Checkpoint stores
+type logCheckpoint struct {
+	UpTo  uint64          `json:"upTo"`  // the first offset the state doesn't cover
+	State json.RawMessage `json:"state"` // what the entries before UpTo added up to
+}
+
+// Checkpoint locks the log and stores [snapshot] as the state the entries
+// before [upTo] add up to, removing those entries, so a replay reads the
+// snapshot with Snapshot and continues with ReadFrom(upTo). The snapshot is
+// stored before any entry is removed, so a crash in between leaves entries
+// the snapshot already covers but never loses them without the snapshot;
+// with Options.WAL the whole checkpoint survives a crash. [upTo] can't be
+// past the next offset or behind the latest checkpoint.
+func (l *Log) Checkpoint(upTo uint64, snapshot interface{}) (err error) {
+	defer wrapOpError(&err, "checkpoint", l.collection, "")
+
+	// ensure there is a log to checkpoint
+	if l.collection == "" {
+		return ErrMissingCollection
+	}
+
+	state, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := l.d.lockResource(l.collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer l.d.cache.invalidate(l.collection, "")
+
+	next, err := l.next()
+	if err != nil {
+		return err
+	}
+	if upTo > next {
+		return fmt.Errorf("checkpoint at %d is past the next offset %d", upTo, next)
+	}
+
+	latest, err := l.snapshot(nil)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if upTo < latest {
+		return fmt.Errorf("checkpoint at %d is behind the latest checkpoint at %d", upTo, latest)
+	}
+
+	b, err := l.d.marshal(l.collection, logCheckpointName, logCheckpoint{UpTo: upTo, State: state})
+	if err != nil {
+		return err
+	}
+	if b, err = l.d.resolve(l.collection, logCheckpointName, b); err != nil {
+		return err
+	}
+
+	offsets, err := l.offsets()
+	if err != nil {
+		return err
+	}
+
+	ops := []walOp{{Resource: logCheckpointName, Data: b}}
+	for _, o := range offsets {
+		if o >= upTo {
+			break
+		}
+		ops = append(ops, walOp{Resource: logResource(o), Delete: true})
+	}
+
+	// removing the last entries mustn't give their offsets out again
+	if err := l.storeNext(next); err != nil {
+		return err
+	}
+
+	return l.d.commitOps("checkpoint", l.collection, ops)
+}
+
+// Snapshot reads the state of the latest checkpoint into [v] and returns
+// the offset replay continues at; a log without a checkpoint returns
+// ErrNotFound
+func (l *Log) Snapshot(v interface{}) (upTo uint64, err error) {
+	defer wrapOpError(&err, "snapshot", l.collection, "")
+
+	// ensure there is a log to read
+	if l.collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	return l.snapshot(v)
+}
+
+// snapshot is Snapshot without the checks; a nil [v] only reads the offset
+func (l *Log) snapshot(v interface{}) (uint64, error) {
+	b, err := l.d.read(l.collection, logCheckpointName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var cp logCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return 0, err
+	}
+
+	if v != nil {
+		if err := l.d.unmarshal(cp.State, v); err != nil {
+			return 0, err
+		}
+	}
+
+	return cp.UpTo, nil
+}
+
+// next returns the offset of the next entry; a log without a stored offset
+// continues after its last entry or checkpoint. The caller holds the
+// collection lock.
+func (l *Log) next() (uint64, error) {
+	b, err := os.ReadFile(filepath.Join(l.d.collectionDir(l.collection), logOffsetName))
+	if err == nil {
+		offset, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid log offset %q", b)
+		}
+		return offset, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	offsets, err := l.offsets()
+	if err != nil {
+		return 0, err
+	}
+	if len(offsets) > 0 {
+		return offsets[len(offsets)-1] + 1, nil
+	}
+
+	// with every entry checkpointed, the log continues after the checkpoint
+	upTo, err := l.snapshot(nil)
+	if errors.Is(err, ErrNotFound) {
+		return 0, nil
+	}
+
+	return upTo, err
+}
+
+// storeNext stores [offset] as the offset of the next entry
+func (l *Log) storeNext(offset uint64) error {
+	path := filepath.Join(l.d.collectionDir(l.collection), logOffsetName)
+	return writeFileAtomic(path, []byte(strconv.FormatUint(offset, 10)))
+}
+
+// offsets returns the sorted offsets of the log's entries
+func (l *Log) offsets() ([]uint64, error) {
+	names, err := l.d.list(l.collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make([]uint64, 0, len(names))
+	for _, name := range names {
+		// records that aren't entries have no offset
+		if len(name) != logDigits {
+			continue
+		}
+		if o, err := strconv.ParseUint(name, 10, 64); err == nil {
+			offsets = append(offsets, o)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	return offsets, nil
+}
+
+// logResource returns the resource name of the entry at [offset]
+func logResource(offset uint64) string {
+	return fmt.Sprintf("%0*d", logDigits, offset)
+}
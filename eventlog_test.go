@@ -0,0 +1,131 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+	d := newTestDB(t, nil)
+	log := d.Log("events")
+
+	for i, kind := range []string{"red", "blue", "green"} {
+		offset, err := log.Append(Fish{Type: kind})
+		if err != nil {
+			t.Fatal("Failed to append: ", err.Error())
+		}
+		if offset != uint64(i) {
+			t.Error("Expected offset ", i, ", got: ", offset)
+		}
+	}
+
+	var kinds []string
+	var offsets []uint64
+	err := log.ReadFrom(1, func(offset uint64, raw []byte) error {
+		fish := Fish{}
+		if err := json.Unmarshal(raw, &fish); err != nil {
+			return err
+		}
+		kinds = append(kinds, fish.Type)
+		offsets = append(offsets, offset)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Failed to read log: ", err.Error())
+	}
+
+	if len(kinds) != 2 || kinds[0] != "blue" || kinds[1] != "green" || offsets[0] != 1 || offsets[1] != 2 {
+		t.Error("Expected blue and green at 1 and 2, got: ", kinds, offsets)
+	}
+
+	stop := errors.New("stop")
+	if err := log.ReadFrom(0, func(uint64, []byte) error { return stop }); !errors.Is(err, stop) {
+		t.Error("Expected the callback's error, got: ", err)
+	}
+}
+
+func TestLogOffsetsSurviveRestart(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.Log("events").Append(redfish); err != nil {
+			t.Fatal("Failed to append: ", err.Error())
+		}
+	}
+
+	// removing the last entry doesn't give its offset out again
+	if err := d.Delete("events", logResource(1)); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+
+	reopened, err := New(d.dir, nil)
+	if err != nil {
+		t.Fatal("Failed to reopen database: ", err.Error())
+	}
+
+	offset, err := reopened.Log("events").Append(redfish)
+	if err != nil {
+		t.Fatal("Failed to append: ", err.Error())
+	}
+
+	if offset != 2 {
+		t.Error("Expected offset 2, got: ", offset)
+	}
+}
+
+func TestLogCheckpoint(t *testing.T) {
+	d := newTestDB(t, nil)
+	log := d.Log("events")
+
+	if _, err := log.Snapshot(&Tank{}); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound without a checkpoint, got: ", err)
+	}
+
+	for _, kind := range []string{"red", "blue", "green"} {
+		if _, err := log.Append(Fish{Type: kind}); err != nil {
+			t.Fatal("Failed to append: ", err.Error())
+		}
+	}
+
+	if err := log.Checkpoint(2, Tank{Fish: []string{"red", "blue"}, Count: 2}); err != nil {
+		t.Fatal("Failed to checkpoint: ", err.Error())
+	}
+
+	tank := Tank{}
+	upTo, err := log.Snapshot(&tank)
+	if err != nil || upTo != 2 || tank.Count != 2 {
+		t.Error("Expected the snapshot up to 2, got: ", upTo, tank, err)
+	}
+
+	// only the entries after the checkpoint are left to replay
+	var offsets []uint64
+	err = log.ReadFrom(0, func(offset uint64, raw []byte) error {
+		offsets = append(offsets, offset)
+		return nil
+	})
+	if err != nil || len(offsets) != 1 || offsets[0] != 2 {
+		t.Error("Expected only offset 2, got: ", offsets, err)
+	}
+
+	if err := log.Checkpoint(1, tank); err == nil {
+		t.Error("Expected an error checkpointing behind the latest checkpoint")
+	}
+	if err := log.Checkpoint(4, tank); err == nil {
+		t.Error("Expected an error checkpointing past the next offset")
+	}
+
+	// offsets keep growing after every entry was checkpointed, even
+	// without the stored next offset
+	if err := log.Checkpoint(3, tank); err != nil {
+		t.Fatal("Failed to checkpoint: ", err.Error())
+	}
+	if err := os.Remove(filepath.Join(d.collectionDir("events"), logOffsetName)); err != nil {
+		t.Fatal(err)
+	}
+	if offset, err := log.Append(redfish); err != nil || offset != 3 {
+		t.Error("Expected offset 3, got: ", offset, err)
+	}
+}
@@ -0,0 +1,158 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// ShardFunc returns the index of the federation driver storing a record; it
+// must always return the same index for the same record
+type ShardFunc func(collection, resource string) int
+
+// HashShard returns a ShardFunc spreading records over [n] drivers by a hash
+// of their collection and resource
+func HashShard(n int) ShardFunc {
+	return func(collection, resource string) int {
+		h := fnv.New32a()
+		h.Write([]byte(collection))
+		h.Write([]byte{0})
+		h.Write([]byte(resource))
+
+		return int(h.Sum32() % uint32(n))
+	}
+}
+
+// Federation spreads the records of a database over several drivers, for
+// example one per disk, routing each record to the driver its shard function
+// picks
+type Federation struct {
+	drivers []*Driver
+	shard   ShardFunc
+}
+
+// NewFederation returns a federation of [drivers] routing records with
+// [shard], or HashShard over all of them when it's nil. The drivers must be passed in the same
+// order every time for records to be found where they were written.
+func NewFederation(shard ShardFunc, drivers ...*Driver) (*Federation, error) {
+	if len(drivers) == 0 {
+		return nil, errors.New("federation needs at least one driver")
+	}
+
+	if shard == nil {
+		shard = HashShard(len(drivers))
+	}
+
+	return &Federation{drivers: drivers, shard: shard}, nil
+}
+
+// driver returns the driver storing [resource] of [collection]
+func (f *Federation) driver(collection, resource string) (*Driver, error) {
+	i := f.shard(collection, resource)
+	if i < 0 || i >= len(f.drivers) {
+		return nil, fmt.Errorf("shard %d of %s/%s is out of range", i, collection, resource)
+	}
+
+	return f.drivers[i], nil
+}
+
+// Write writes the record to the driver that stores it
+func (f *Federation) Write(collection, resource string, v interface{}) error {
+	d, err := f.driver(collection, resource)
+	if err != nil {
+		return err
+	}
+
+	return d.Write(collection, resource, v)
+}
+
+// Read reads the record from the driver that stores it
+func (f *Federation) Read(collection, resource string, v interface{}) error {
+	d, err := f.driver(collection, resource)
+	if err != nil {
+		return err
+	}
+
+	return d.Read(collection, resource, v)
+}
+
+// Delete removes the record from the driver that stores it
+func (f *Federation) Delete(collection, resource string) error {
+	d, err := f.driver(collection, resource)
+	if err != nil {
+		return err
+	}
+
+	return d.Delete(collection, resource)
+}
+
+// ReadAll reads the collection from every driver concurrently and merges the
+// records in sorted resource order, as if they were stored by a single
+// driver. A collection missing from some drivers just has no records there.
+func (f *Federation) ReadAll(collection string) ([][]byte, error) {
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	type shard struct {
+		names   []string
+		records [][]byte
+		err     error
+	}
+
+	shards := make([]shard, len(f.drivers))
+
+	var wg sync.WaitGroup
+	for i, d := range f.drivers {
+		wg.Add(1)
+		go func(i int, d *Driver) {
+			defer wg.Done()
+
+			s := &shards[i]
+			names, err := d.list(collection)
+			if s.err = err; err == nil {
+				s.names, s.records, s.err = d.readAll(collection, names)
+			}
+		}(i, d)
+	}
+	wg.Wait()
+
+	type record struct {
+		name string
+		b    []byte
+	}
+
+	var all []record
+	missing := 0
+	for _, s := range shards {
+		if errors.Is(s.err, fs.ErrNotExist) {
+			missing++
+			continue
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+
+		for i, name := range s.names {
+			all = append(all, record{name: name, b: s.records[i]})
+		}
+	}
+
+	// like ReadAll of a single driver, a collection that exists nowhere fails
+	if missing == len(shards) {
+		return nil, shards[0].err
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].name < all[j].name })
+
+	records := make([][]byte, len(all))
+	for i, r := range all {
+		records[i] = r.b
+	}
+
+	return records, nil
+}
@@ -0,0 +1,91 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestFederation(t *testing.T) {
+	drivers := []*Driver{newTestDB(t, nil), newTestDB(t, nil), newTestDB(t, nil)}
+
+	f, err := NewFederation(nil, drivers...)
+	if err != nil {
+		t.Fatal("NewFederation failed: ", err.Error())
+	}
+
+	for i := 0; i < 12; i++ {
+		if err := f.Write(collection, fmt.Sprint(i), Fish{Type: fmt.Sprint(i)}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// the records are spread over the drivers
+	for i, d := range drivers {
+		if names, err := d.list(collection); err != nil || len(names) == 0 {
+			t.Error("Expected driver ", i, " to hold some fish, got: ", names, err)
+		}
+	}
+
+	fish := Fish{}
+	if err := f.Read(collection, "7", &fish); err != nil || fish.Type != "7" {
+		t.Error("Expected fish 7, got: ", fish, err)
+	}
+
+	if err := f.Delete(collection, "7"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	records, err := f.ReadAll(collection)
+	if err != nil || len(records) != 11 {
+		t.Fatal("Expected 11 fish, got: ", len(records), err)
+	}
+
+	// merged in sorted resource order
+	var got []string
+	for _, b := range records {
+		if err := json.Unmarshal(b, &fish); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fish.Type)
+	}
+	if want := "[0 1 10 11 2 3 4 5 6 8 9]"; fmt.Sprint(got) != want {
+		t.Error("Expected ", want, ", got: ", got)
+	}
+
+	if _, err := f.ReadAll("missing"); err == nil {
+		t.Error("Expected an error reading a collection that exists nowhere")
+	}
+
+	// a reserved name is listed but not read, and mustn't shift the others
+	d, err := f.driver(collection, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Reserve(collection, "a"); err != nil {
+		t.Fatal("Failed to reserve: ", err.Error())
+	}
+
+	records, err = f.ReadAll(collection)
+	if err != nil || len(records) != 11 {
+		t.Fatal("Expected 11 fish, got: ", len(records), err)
+	}
+	if err := json.Unmarshal(records[0], &fish); err != nil || fish.Type != "0" {
+		t.Error("Expected fish 0 first, got: ", fish, err)
+	}
+}
+
+func TestFederationShardRange(t *testing.T) {
+	f, err := NewFederation(func(collection, resource string) int { return 1 }, newTestDB(t, nil))
+	if err != nil {
+		t.Fatal("NewFederation failed: ", err.Error())
+	}
+
+	if err := f.Write(collection, "red", redfish); err == nil {
+		t.Error("Expected an error for an out of range shard")
+	}
+
+	if _, err := NewFederation(nil); err == nil {
+		t.Error("Expected an error for a federation without drivers")
+	}
+}
@@ -0,0 +1,83 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ReadFields reads the top-level [fields] of a record into [out], which
+// mustn't be nil. The record is streamed through a decoder that only builds
+// values for the requested fields and skips over the rest, sparing the cost
+// of decoding wide records in full. A requested field the record doesn't have
+// is left out of [out]. The record must hold a JSON object.
+func (d *Driver) ReadFields(collection, resource string, fields []string, out map[string]interface{}) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "read", collection, resource)
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a place to read from
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource to read
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	b, err := d.read(collection, resource)
+	if err != nil {
+		return err
+	}
+
+	// bring records stored by older versions of the program up to date
+	if b, err = d.migrateRecord(collection, resource, b, d.rewriteMigrated); err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[field] = true
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if d.useNumber {
+		dec.UseNumber()
+	}
+
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return ErrInvalidRecordShape
+	}
+
+	for dec.More() && len(wanted) > 0 {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		name, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %v in place of a field name", t)
+		}
+
+		if !wanted[name] {
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		out[name] = value
+		delete(wanted, name)
+	}
+
+	return nil
+}
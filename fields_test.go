@@ -0,0 +1,68 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestReadFields(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	wide := map[string]interface{}{
+		"type":   "red",
+		"teeth":  12,
+		"tags":   []string{"a", "b"},
+		"nested": map[string]interface{}{"type": "inner"},
+	}
+	if err := d.Write(collection, "redfish", wide); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	out := map[string]interface{}{}
+	if err := d.ReadFields(collection, "redfish", []string{"type", "teeth", "missing"}, out); err != nil {
+		t.Fatal("Failed to read fields: ", err.Error())
+	}
+
+	if len(out) != 2 || out["type"] != "red" || out["teeth"] != float64(12) {
+		t.Error("Expected type and teeth, got: ", out)
+	}
+
+	if _, ok := out["missing"]; ok {
+		t.Error("Expected a missing field to be left out")
+	}
+
+	if err := d.ReadFields(collection, "bluefish", []string{"type"}, out); err == nil {
+		t.Error("Expected an error reading a missing record")
+	}
+}
+
+func TestReadFieldsUseNumber(t *testing.T) {
+	d := newTestDB(t, &Options{UseNumber: true})
+
+	if err := d.Write(collection, "shark", Shark{Type: "shark", Teeth: 300}); err != nil {
+		t.Fatal("Create shark failed: ", err.Error())
+	}
+
+	out := map[string]interface{}{}
+	if err := d.ReadFields(collection, "shark", []string{"teeth"}, out); err != nil {
+		t.Fatal("Failed to read fields: ", err.Error())
+	}
+
+	if out["teeth"] != json.Number("300") {
+		t.Error("Expected teeth as a json.Number, got: ", out)
+	}
+}
+
+func TestReadFieldsNotObject(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "list", []string{"a"}); err != nil {
+		t.Fatal("Create list failed: ", err.Error())
+	}
+
+	err := d.ReadFields(collection, "list", []string{"a"}, map[string]interface{}{})
+	if !errors.Is(err, ErrInvalidRecordShape) {
+		t.Error("Expected ErrInvalidRecordShape, got: ", err)
+	}
+}
@@ -0,0 +1,178 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// FindFirst returns the first record of [collection], in sorted resource
+// order, that [pred] accepts, along with whether there was one. Records are
+// read one at a time under the collection's read lock, like FindAll, and the
+// scan stops at the first match.
+func FindFirst[T any](d *Driver, collection string, pred func(T) bool) (found T, ok bool, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "find", collection, "")
+
+	// ensure there is a collection to search
+	if collection == "" {
+		return found, false, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return found, false, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		return found, false, nil
+	}
+	if err != nil {
+		return found, false, err
+	}
+
+	for _, name := range names {
+		b, err := d.read(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return found, false, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		var v T
+		if err := d.decodeLocked(collection, name, b, &v); err != nil {
+			return found, false, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		if pred(v) {
+			return v, true, nil
+		}
+	}
+
+	return found, false, nil
+}
+
+// Find returns the raw bytes of the records of [collection], in sorted
+// resource order, that [match] accepts. It reads like ReadAll, holding the
+// collection's read lock and skipping temp files and reserved names, but
+// reads one record at a time and only keeps the matches, so a large
+// collection is never loaded whole.
+func (d *Driver) Find(collection string, match func(raw []byte) bool) (records [][]byte, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "find", collection, "")
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a collection to search
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	// records a BufferedWriter hasn't stored yet are searched too
+	if pending := d.overlay.merge(collection, names); len(pending) != len(names) {
+		names = pending
+		d.sortNames(names)
+	}
+
+	records = [][]byte{}
+	for _, name := range names {
+		b, err := d.readPending(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		// a reserved name that hasn't been written yet
+		if len(b) == 0 {
+			continue
+		}
+
+		if match(b) {
+			records = append(records, b)
+		}
+	}
+
+	return records, nil
+}
+
+// FindAll is Find decoding each record into a T like Read does, migrating
+// it when the collection has a schema, and returning the ones [pred]
+// accepts. Only one record besides the matches is held at a time. The
+// collection is read locked throughout, so records it migrates aren't
+// written back even with Options.RewriteMigrated.
+func FindAll[T any](d *Driver, collection string, pred func(T) bool) (all []T, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "find", collection, "")
+
+	// ensure there is a collection to search
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []T{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	all = []T{}
+	for _, name := range names {
+		b, err := d.read(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, ErrEmptyRecord) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		var v T
+		if err := d.decodeLocked(collection, name, b, &v); err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		if pred(v) {
+			all = append(all, v)
+		}
+	}
+
+	return all, nil
+}
+
+// decodeLocked is decodeRecord for a scan holding the collection's read lock,
+// which writing back a migrated record would wait on forever
+func (d *Driver) decodeLocked(collection, resource string, b []byte, v interface{}) error {
+	b, err := d.migrateRecord(collection, resource, b, false)
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshal(b, v)
+}
@@ -0,0 +1,147 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFindFirst(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"c", "a", "b"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// the scan stops at the first match in sorted order
+	calls := 0
+	fish, ok, err := FindFirst(d, collection, func(f Fish) bool {
+		calls++
+		return f.Type >= "b"
+	})
+	if err != nil || !ok || fish.Type != "b" {
+		t.Error("Expected fish b, got: ", fish, ok, err)
+	}
+	if calls != 2 {
+		t.Error("Expected 2 records checked, got: ", calls)
+	}
+
+	if _, ok, err := FindFirst(d, collection, func(f Fish) bool { return false }); err != nil || ok {
+		t.Error("Expected no match, got: ", ok, err)
+	}
+
+	if _, ok, err := FindFirst(d, "missing", func(f Fish) bool { return true }); err != nil || ok {
+		t.Error("Expected no match in a missing collection, got: ", ok, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"c", "a", "b"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// neither a temp file nor a reserved name is matched
+	if err := os.WriteFile(d.recordPath(collection, "d")+tmpSuffix, []byte(`{"type":"d"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Reserve(collection, "e"); err != nil {
+		t.Fatal("Failed to reserve: ", err.Error())
+	}
+
+	calls := 0
+	records, err := d.Find(collection, func(raw []byte) bool {
+		calls++
+		return !bytes.Contains(raw, []byte(`"b"`))
+	})
+	if err != nil {
+		t.Fatal("Failed to find: ", err.Error())
+	}
+	if calls != 3 || len(records) != 2 {
+		t.Fatal("Expected 2 matches of 3 records, got: ", len(records), calls)
+	}
+
+	for i, want := range []string{"a", "c"} {
+		fish := Fish{}
+		if err := json.Unmarshal(records[i], &fish); err != nil || fish.Type != want {
+			t.Error("Expected fish ", want, ", got: ", fish, err)
+		}
+	}
+
+	if records, err := d.Find("missing", func([]byte) bool { return true }); err != nil || len(records) != 0 {
+		t.Error("Expected no match in a missing collection, got: ", records, err)
+	}
+
+	if _, err := d.Find("../fish", func([]byte) bool { return true }); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"c", "a", "b"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	fish, err := FindAll(d, collection, func(f Fish) bool { return f.Type != "b" })
+	if err != nil {
+		t.Fatal("Failed to find: ", err.Error())
+	}
+	if len(fish) != 2 || fish[0].Type != "a" || fish[1].Type != "c" {
+		t.Error("Expected fish a and c, got: ", fish)
+	}
+
+	if fish, err := FindAll(d, "missing", func(Fish) bool { return true }); err != nil || len(fish) != 0 {
+		t.Error("Expected no match in a missing collection, got: ", fish, err)
+	}
+}
+
+func TestFindLocksCollection(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	unlock, err := d.lockCollections(collection)
+	if err != nil {
+		t.Fatal("Failed to lock: ", err.Error())
+	}
+
+	// neither scan reads while the collection is write locked
+	done := make(chan error, 2)
+	go func() {
+		_, _, err := FindFirst(d, collection, func(f Fish) bool { return true })
+		done <- err
+	}()
+	go func() {
+		_, err := ReadAll[Fish](d, collection)
+		done <- err
+	}()
+
+	waiting := 2
+	select {
+	case err := <-done:
+		t.Error("Expected the scan to wait for the lock, got: ", err)
+		waiting--
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+
+	for ; waiting > 0; waiting-- {
+		if err := <-done; err != nil {
+			t.Error("Failed to scan: ", err.Error())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package jsondb
+
+import "sync"
+
+// flightGroup coalesces concurrent reads of the same key, so only one of
+// them does the work while the others wait for and share its result
+type flightGroup struct {
+	mutex sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is a read in flight
+type flightCall struct {
+	wg  sync.WaitGroup
+	b   []byte
+	err error
+}
+
+// do calls [fn] for [key] unless a call for it is already in flight, in
+// which case it waits for that one and returns its result instead
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mutex.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		c.wg.Wait()
+		return c.b, c.err
+	}
+
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	c.b, c.err = fn()
+
+	g.mutex.Lock()
+	delete(g.calls, key)
+	g.mutex.Unlock()
+	c.wg.Done()
+
+	return c.b, c.err
+}
@@ -0,0 +1,73 @@
+package jsondb
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroup(t *testing.T) {
+	g := flightGroup{calls: make(map[string]*flightCall)}
+
+	var calls uint32
+	release := make(chan struct{})
+	fn := func() ([]byte, error) {
+		atomic.AddUint32(&calls, 1)
+		<-release
+		return []byte(`{"type":"red"}`), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]byte, 10)
+	for i := range results {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = g.do("fish/redfish", fn)
+		}()
+	}
+
+	// give every reader the time to join the first one
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Error("Expected one read, got: ", calls)
+	}
+
+	for _, b := range results {
+		if string(b) != `{"type":"red"}` {
+			t.Error("Expected every reader to share the result, got: ", string(b))
+		}
+	}
+
+	// once the read is done the next one goes to disk again
+	if _, err := g.do("fish/redfish", fn); err != nil || calls != 2 {
+		t.Error("Expected a second read, got: ", calls, err)
+	}
+}
+
+func TestReadSingleFlight(t *testing.T) {
+	d := newTestDB(t, &Options{CacheTTL: time.Minute})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			fish := Fish{}
+			if err := d.Read(collection, "redfish", &fish); err != nil || fish != redfish {
+				t.Error("Expected redfish, got: ", fish, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,68 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// generationName is the file in each collection holding its generation
+const generationName = ".generation"
+
+// Generation returns the generation of [collection]: a number stored with
+// the collection that every change to one of its records made through the
+// driver increases, so reading it before some work and comparing it again
+// later, e.g. inside CollectionTxn, tells whether the collection changed
+// meanwhile. A collection nothing was written to yet is at generation 0.
+func (d *Driver) Generation(collection string) (generation uint64, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "generation", collection, "")
+
+	// ensure there is a collection to look at
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	return d.readGeneration(collection)
+}
+
+// Generation returns the generation of the transaction's collection; the
+// collection is locked, so it can't change before the transaction commits
+func (t *CollectionTxn) Generation() (uint64, error) {
+	return t.d.readGeneration(t.collection)
+}
+
+// readGeneration returns the stored generation of [collection]
+func (d *Driver) readGeneration(collection string) (uint64, error) {
+	b, err := os.ReadFile(filepath.Join(d.collectionDir(collection), generationName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	generation, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid generation %q", b)
+	}
+
+	return generation, nil
+}
+
+// bumpGeneration moves [collection] to its next generation; the caller holds
+// the collection lock and serializes bumps
+func (d *Driver) bumpGeneration(collection string) error {
+	generation, err := d.readGeneration(collection)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(d.collectionDir(collection), generationName)
+	return writeFileAtomic(path, []byte(strconv.FormatUint(generation+1, 10)))
+}
@@ -0,0 +1,61 @@
+package jsondb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGeneration(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if generation, err := d.Generation(collection); err != nil || generation != 0 {
+		t.Error("Expected generation 0, got: ", generation, err)
+	}
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Write(collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Delete(collection, "bluefish"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	seen, err := d.Generation(collection)
+	if err != nil || seen != 3 {
+		t.Error("Expected generation 3, got: ", seen, err)
+	}
+
+	// the generation is kept with the collection
+	reopened, err := New(d.dir, &Options{Debug: t.Logf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if generation, err := reopened.Generation(collection); err != nil || generation != seen {
+		t.Error("Expected generation ", seen, " after reopening, got: ", generation, err)
+	}
+
+	// a transaction can refuse to commit once the collection moved on
+	if err := d.Write(collection, "greenfish", Fish{Type: "green"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	stale := errors.New("stale")
+	err = d.CollectionTxn(collection, func(txn *CollectionTxn) error {
+		generation, err := txn.Generation()
+		if err != nil {
+			return err
+		}
+		if generation != seen {
+			return stale
+		}
+		return txn.Write("redfish", Fish{Type: "crimson"})
+	})
+	if !errors.Is(err, stale) {
+		t.Error("Expected the transaction to see a newer generation, got: ", err)
+	}
+}
@@ -0,0 +1,69 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+)
+
+// Glob returns the sorted resource names of a collection matching the
+// filepath.Match [pattern], such as "2024-*" for date prefixed keys. A
+// collection that doesn't exist matches nothing.
+func (d *Driver) Glob(collection, pattern string) (matches []string, err error) {
+	collection, pattern = d.normalize(collection), d.normalize(pattern)
+
+	defer wrapOpError(&err, "glob", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	// reject a malformed pattern even when there is nothing to match
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	matches = []string{}
+	for _, name := range names {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			matches = append(matches, name)
+		}
+	}
+
+	return matches, nil
+}
+
+// CollectionsGlob returns the sorted names of the collections matching the
+// filepath.Match [pattern], such as "tenant_*" for namespaced collections. A
+// nested collection is matched by its whole name, so "*" only matches those
+// at the top level. A database without collections matches nothing.
+func (d *Driver) CollectionsGlob(pattern string) (matches []string, err error) {
+	pattern = d.normalize(pattern)
+
+	defer wrapOpError(&err, "glob", "", "")
+
+	// reject a malformed pattern even when there is nothing to match
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	names, err := d.Collections()
+	if err != nil {
+		return nil, err
+	}
+
+	matches = []string{}
+	for _, name := range names {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			matches = append(matches, name)
+		}
+	}
+
+	return matches, nil
+}
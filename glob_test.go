@@ -0,0 +1,72 @@
+package jsondb
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"2023-12-31", "2024-01-01", "2024-02-01", "other"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	matches, err := d.Glob(collection, "2024-*")
+	if err != nil {
+		t.Fatal("Glob failed: ", err.Error())
+	}
+	if want := []string{"2024-01-01", "2024-02-01"}; !reflect.DeepEqual(matches, want) {
+		t.Error("Expected ", want, ", got: ", matches)
+	}
+
+	if _, err := d.Glob(collection, "[2024"); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Error("Expected ErrBadPattern, got: ", err)
+	}
+
+	// a missing collection matches nothing
+	matches, err = d.Glob("missing", "*")
+	if err != nil || matches == nil || len(matches) != 0 {
+		t.Error("Expected an empty match, got: ", matches, err)
+	}
+}
+
+func TestCollectionsGlob(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// a database without collections matches nothing
+	matches, err := d.CollectionsGlob("*")
+	if err != nil || matches == nil || len(matches) != 0 {
+		t.Error("Expected an empty match, got: ", matches, err)
+	}
+
+	for _, c := range []string{"tenant_b", "tenant_a", "cache_a", "tenant_a/nested"} {
+		if err := d.Write(c, "redfish", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	matches, err = d.CollectionsGlob("tenant_*")
+	if err != nil {
+		t.Fatal("CollectionsGlob failed: ", err.Error())
+	}
+	if want := []string{"tenant_a", "tenant_b"}; !reflect.DeepEqual(matches, want) {
+		t.Error("Expected ", want, ", got: ", matches)
+	}
+
+	matches, err = d.CollectionsGlob("tenant_*/*")
+	if err != nil {
+		t.Fatal("CollectionsGlob failed: ", err.Error())
+	}
+	if want := []string{"tenant_a/nested"}; !reflect.DeepEqual(matches, want) {
+		t.Error("Expected ", want, ", got: ", matches)
+	}
+
+	if _, err := d.CollectionsGlob("[tenant"); !errors.Is(err, filepath.ErrBadPattern) {
+		t.Error("Expected ErrBadPattern, got: ", err)
+	}
+}
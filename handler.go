@@ -0,0 +1,159 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// maxHandlerBody bounds the size of a record written through Handler
+const maxHandlerBody = 32 << 20
+
+// Handler returns an http.Handler serving a small REST interface to the
+// database, for admin and debugging tools: GET /{collection} lists the
+// collection's resource names as a JSON array, GET /{collection}/{resource}
+// returns a record, PUT stores the JSON in the request body as one and
+// DELETE removes it. Missing records answer 404 Not Found, invalid names and
+// records 400 Bad Request, and other methods 405 Method Not Allowed. The
+// last path segment names the resource, so collections nested in others
+// aren't reachable. The handler does no authentication of its own.
+func (d *Driver) Handler() http.Handler {
+	return http.HandlerFunc(d.serveHTTP)
+}
+
+func (d *Driver) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	collection, resource, err := handlerPath(r.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case resource == "" && r.Method == http.MethodGet:
+		d.serveList(w, collection)
+	case resource == "":
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	case r.Method == http.MethodGet:
+		d.serveRead(w, collection, resource)
+	case r.Method == http.MethodPut:
+		d.serveWrite(w, r, collection, resource)
+	case r.Method == http.MethodDelete:
+		d.serveDelete(w, collection, resource)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (d *Driver) serveList(w http.ResponseWriter, collection string) {
+	names, err := d.list(d.normalize(collection))
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	if names == nil {
+		names = []string{}
+	}
+
+	serveJSON(w, names)
+}
+
+func (d *Driver) serveRead(w http.ResponseWriter, collection, resource string) {
+	var record json.RawMessage
+	if err := d.Read(collection, resource, &record); err != nil {
+		httpError(w, err)
+		return
+	}
+
+	serveJSON(w, record)
+}
+
+func (d *Driver) serveWrite(w http.ResponseWriter, r *http.Request, collection, resource string) {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHandlerBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !json.Valid(b) {
+		http.Error(w, "the request body is not valid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := d.Write(collection, resource, json.RawMessage(b)); err != nil {
+		httpError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (d *Driver) serveDelete(w http.ResponseWriter, collection, resource string) {
+	// Delete can't tell a missing record from other failures
+	if _, err := d.statRecord(d.normalize(collection), d.normalize(resource)); err != nil {
+		httpError(w, err)
+		return
+	}
+
+	if err := d.Delete(collection, resource); err != nil {
+		httpError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerPath splits a request path into the collection and resource it
+// names, refusing empty and hidden names
+func handlerPath(path string) (collection, resource string, err error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 2 {
+		return "", "", fmt.Errorf("invalid path %q", path)
+	}
+
+	for _, part := range parts {
+		if part == "" || strings.HasPrefix(part, ".") {
+			return "", "", fmt.Errorf("invalid name %q", part)
+		}
+	}
+
+	if len(parts) == 2 {
+		return parts[0], parts[1], nil
+	}
+
+	return parts[0], "", nil
+}
+
+// httpError answers a request that failed with [err] with a fitting status
+func httpError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrMissingCollection), errors.Is(err, ErrMissingResource),
+		errors.Is(err, ErrNameCollision), errors.Is(err, ErrValidation),
+		errors.Is(err, ErrInvalidRecordShape), errors.Is(err, ErrEmptyRecord):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrLockTimeout):
+		status = http.StatusServiceUnavailable
+	}
+
+	http.Error(w, err.Error(), status)
+}
+
+func serveJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
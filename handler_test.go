@@ -0,0 +1,66 @@
+package jsondb
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	d := newTestDB(t, nil)
+	srv := httptest.NewServer(d.Handler())
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	if status, _ := do(http.MethodPut, "/fish/redfish", `{"type":"red"}`); status != http.StatusNoContent {
+		t.Error("Expected the write to succeed, got: ", status)
+	}
+
+	if status, body := do(http.MethodGet, "/fish/redfish", ""); status != http.StatusOK || body != `{"type":"red"}` {
+		t.Error("Expected red fish, got: ", status, body)
+	}
+
+	if status, body := do(http.MethodGet, "/fish", ""); status != http.StatusOK || body != `["redfish"]` {
+		t.Error("Expected the fish to be listed, got: ", status, body)
+	}
+
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/fish/bluefish", "", http.StatusNotFound},
+		{http.MethodGet, "/tank", "", http.StatusNotFound},
+		{http.MethodPut, "/fish/badfish", `{"type":`, http.StatusBadRequest},
+		{http.MethodGet, "/fish/.config.json", "", http.StatusBadRequest},
+		{http.MethodGet, "/fish/a/b", "", http.StatusBadRequest},
+		{http.MethodPost, "/fish/redfish", "", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/fish", "", http.StatusMethodNotAllowed},
+		{http.MethodDelete, "/fish/bluefish", "", http.StatusNotFound},
+		{http.MethodDelete, "/fish/redfish", "", http.StatusNoContent},
+		{http.MethodGet, "/fish/redfish", "", http.StatusNotFound},
+	} {
+		if status, body := do(tc.method, tc.path, tc.body); status != tc.status {
+			t.Error("Expected ", tc.status, " for ", tc.method, " ", tc.path, ", got: ", status, body)
+		}
+	}
+}
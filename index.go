@@ -0,0 +1,269 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// indexDir is the directory in each collection holding its indexes
+const indexDir = ".indexes"
+
+// ErrMissingIndex is returned when looking up a field that isn't indexed
+var ErrMissingIndex = errors.New("missing index - field is not indexed")
+
+// index maps the JSON encoding of a field's values to the sorted names of the
+// resources holding that value
+type index map[string][]string
+
+// RebuildIndex locks the collection and computes the index of the top-level
+// [field] from scratch, creating the index if it doesn't exist yet. Indexes
+// are kept up to date by every change made through the driver; rebuilding
+// repairs them after records were changed outside of jsondb.
+func (d *Driver) RebuildIndex(collection, field string) error {
+	if err := d.checkIndexArgs(collection, field); err != nil {
+		return err
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	idx := make(index)
+	for _, name := range names {
+		// expired records don't hold their values any more
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if key, ok := indexKey(b, field); ok {
+			idx[key] = append(idx[key], name)
+		}
+	}
+
+	dir := filepath.Join(d.collectionDir(collection), indexDir)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	return writeIndex(dir, field, idx)
+}
+
+// DropIndex locks the collection and removes the index of [field]; the
+// index backing a unique constraint stays
+func (d *Driver) DropIndex(collection, field string) error {
+	if err := d.checkIndexArgs(collection, field); err != nil {
+		return err
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// unique constraints are checked against the index
+	fields, err := d.uniqueFields(collection)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f == field {
+			return fmt.Errorf("index %q backs a unique constraint", field)
+		}
+	}
+
+	err = os.Remove(filepath.Join(d.collectionDir(collection), indexDir, field+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// LookupIndex returns the sorted names of the resources whose indexed [field]
+// equals [value]
+func (d *Driver) LookupIndex(collection, field string, value interface{}) ([]string, error) {
+	if err := d.checkIndexArgs(collection, field); err != nil {
+		return nil, err
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx, err := readIndex(filepath.Join(d.collectionDir(collection), indexDir), field)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrMissingIndex
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ := normalizeKey(b)
+	return idx[key], nil
+}
+
+// updateIndexes moves [resource] to its current value in each of the
+// collection's indexes; the caller holds the collection lock
+func (d *Driver) updateIndexes(collection, resource string) error {
+	dir := filepath.Join(d.collectionDir(collection), indexDir)
+
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// a removed record simply drops out of every index
+	b, err := d.readFile(collection, resource)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		field := strings.TrimSuffix(file.Name(), ".json")
+
+		idx, err := readIndex(dir, field)
+		if err != nil {
+			return err
+		}
+
+		for key, names := range idx {
+			if names = removeName(names, resource); len(names) == 0 {
+				delete(idx, key)
+			} else {
+				idx[key] = names
+			}
+		}
+
+		if key, ok := indexKey(b, field); ok {
+			idx[key] = insertName(idx[key], resource)
+		}
+
+		if err := writeIndex(dir, field, idx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Driver) checkIndexArgs(collection, field string) error {
+	// ensure there is a collection to index
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	if err := d.checkNames(collection, ""); err != nil {
+		return err
+	}
+
+	if field == "" || strings.ContainsAny(field, `/\`) || strings.HasPrefix(field, ".") {
+		return fmt.Errorf("invalid index field %q", field)
+	}
+
+	return nil
+}
+
+// indexKey returns the normalized JSON encoding of the top-level [field] of a
+// record, or false if the record isn't an object or lacks the field
+func indexKey(b []byte, field string) (string, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return "", false
+	}
+
+	raw, ok := obj[field]
+	if !ok {
+		return "", false
+	}
+
+	return normalizeKey(raw)
+}
+
+// normalizeKey re-encodes a JSON value so equal values written differently
+// share a key
+func normalizeKey(raw []byte) (string, bool) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", false
+	}
+
+	key, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+
+	return string(key), true
+}
+
+// insertName adds [name] to the sorted [names] unless it's already there
+func insertName(names []string, name string) []string {
+	i := sort.SearchStrings(names, name)
+	if i < len(names) && names[i] == name {
+		return names
+	}
+
+	names = append(names, "")
+	copy(names[i+1:], names[i:])
+	names[i] = name
+	return names
+}
+
+// removeName removes [name] from the sorted [names]
+func removeName(names []string, name string) []string {
+	i := sort.SearchStrings(names, name)
+	if i == len(names) || names[i] != name {
+		return names
+	}
+
+	return append(names[:i], names[i+1:]...)
+}
+
+func readIndex(dir, field string) (index, error) {
+	b, err := os.ReadFile(filepath.Join(dir, field+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	idx := make(index)
+	return idx, json.Unmarshal(b, &idx)
+}
+
+func writeIndex(dir, field string, idx index) error {
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(dir, field+".json"), b)
+}
@@ -0,0 +1,66 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for name, f := range map[string]Fish{"a": {Type: "red"}, "b": {Type: "blue"}, "c": {Type: "red"}} {
+		if err := d.Write(collection, name, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if _, err := d.LookupIndex(collection, "type", "red"); !errors.Is(err, ErrMissingIndex) {
+		t.Error("Expected ErrMissingIndex, got: ", err)
+	}
+
+	if err := d.RebuildIndex(collection, "type"); err != nil {
+		t.Fatal("Failed to build index: ", err.Error())
+	}
+
+	if names, err := d.LookupIndex(collection, "type", "red"); err != nil || fmt.Sprint(names) != "[a c]" {
+		t.Error("Expected a and c, got: ", names, err)
+	}
+
+	// writes and deletes keep the index current
+	if err := d.Write(collection, "a", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Delete(collection, "b"); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+
+	if names, err := d.LookupIndex(collection, "type", "blue"); err != nil || fmt.Sprint(names) != "[a]" {
+		t.Error("Expected only a, got: ", names, err)
+	}
+
+	// out of band edits are picked up by a rebuild
+	path := filepath.Join(d.dir, collection, "c")
+	if err := os.WriteFile(path, []byte(`{"type":"blue"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.RebuildIndex(collection, "type"); err != nil {
+		t.Fatal("Failed to rebuild index: ", err.Error())
+	}
+
+	if names, err := d.LookupIndex(collection, "type", "blue"); err != nil || fmt.Sprint(names) != "[a c]" {
+		t.Error("Expected a and c, got: ", names, err)
+	}
+
+	if err := d.DropIndex(collection, "type"); err != nil {
+		t.Fatal("Failed to drop index: ", err.Error())
+	}
+
+	if _, err := d.LookupIndex(collection, "type", "blue"); !errors.Is(err, ErrMissingIndex) {
+		t.Error("Expected ErrMissingIndex after drop, got: ", err)
+	}
+}
@@ -0,0 +1,145 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// schemaDraft is the JSON Schema dialect InferSchema describes records in
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema InferSchema produces
+type jsonSchema struct {
+	Schema     string                 `json:"$schema,omitempty"`
+	Type       interface{}            `json:"type,omitempty"` // a type name or a list of them
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Items      *jsonSchema            `json:"items,omitempty"`
+}
+
+// InferSchema reads every record of a collection and returns a JSON Schema
+// describing them: the types seen for each value, the members seen in
+// objects, with those missing from some objects left optional, and what
+// arrays hold. A collection without records yields a schema allowing
+// anything.
+func (d *Driver) InferSchema(collection string) (schema []byte, err error) {
+	defer wrapOpError(&err, "inferschema", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	root := &inferred{}
+	for _, name := range names {
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(b) == 0) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		v, err := decodeValue(b)
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		root.observe(v)
+	}
+
+	s := root.schema()
+	s.Schema = schemaDraft
+	return json.Marshal(s)
+}
+
+// inferred accumulates what was seen at one place in the records
+type inferred struct {
+	types   map[string]bool
+	objects int                  // how many objects were seen here
+	members map[string]*inferred // what each object member held
+	counts  map[string]int       // how many objects had each member
+	items   *inferred            // what arrays held
+}
+
+func (n *inferred) observe(v interface{}) {
+	if n.types == nil {
+		n.types = make(map[string]bool)
+	}
+
+	n.types[jsonType(v)] = true
+
+	switch v := v.(type) {
+	case []interface{}:
+		if n.items == nil {
+			n.items = &inferred{}
+		}
+		for _, item := range v {
+			n.items.observe(item)
+		}
+
+	case map[string]interface{}:
+		if n.members == nil {
+			n.members = make(map[string]*inferred)
+			n.counts = make(map[string]int)
+		}
+
+		n.objects++
+		for name, value := range v {
+			if n.members[name] == nil {
+				n.members[name] = &inferred{}
+			}
+			n.members[name].observe(value)
+			n.counts[name]++
+		}
+	}
+}
+
+func (n *inferred) schema() *jsonSchema {
+	s := &jsonSchema{}
+
+	// every integer is a number too
+	if n.types["number"] {
+		delete(n.types, "integer")
+	}
+
+	types := make([]string, 0, len(n.types))
+	for t := range n.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	switch len(types) {
+	case 0:
+	case 1:
+		s.Type = types[0]
+	default:
+		s.Type = types
+	}
+
+	if len(n.members) > 0 {
+		s.Properties = make(map[string]*jsonSchema, len(n.members))
+		for name, member := range n.members {
+			s.Properties[name] = member.schema()
+			if n.counts[name] == n.objects {
+				s.Required = append(s.Required, name)
+			}
+		}
+		sort.Strings(s.Required)
+	}
+
+	// arrays that were always empty say nothing about their items
+	if n.items != nil && len(n.items.types) > 0 {
+		s.Items = n.items.schema()
+	}
+
+	return s
+}
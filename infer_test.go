@@ -0,0 +1,38 @@
+package jsondb
+
+import "testing"
+
+func TestInferSchema(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	records := map[string]interface{}{
+		"red":  map[string]interface{}{"type": "red", "size": 1, "fins": []int{1, 2}},
+		"blue": map[string]interface{}{"type": "blue", "size": 1.5, "tank": nil},
+	}
+	for name, record := range records {
+		if err := d.Write(collection, name, record); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	schema, err := d.InferSchema(collection)
+	if err != nil {
+		t.Fatal("InferSchema failed: ", err.Error())
+	}
+
+	want := `{"$schema":"` + schemaDraft + `","type":"object","properties":{` +
+		`"fins":{"type":"array","items":{"type":"integer"}},` +
+		`"size":{"type":"number"},` +
+		`"tank":{"type":"null"},` +
+		`"type":{"type":"string"}},` +
+		`"required":["size","type"]}`
+	if string(schema) != want {
+		t.Error("Expected ", want, ", got: ", string(schema))
+	}
+
+	// no records, no constraints
+	schema, err = d.InferSchema("missing")
+	if err != nil || string(schema) != `{"$schema":"`+schemaDraft+`"}` {
+		t.Error("Expected an empty schema, got: ", string(schema), err)
+	}
+}
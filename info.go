@@ -0,0 +1,76 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// RecordInfo describes a stored record
+type RecordInfo struct {
+	Size    int64     // bytes on disk
+	ModTime time.Time // last time the record was written
+}
+
+// ReadWithInfo reads a record into [v] the way Read does and returns its
+// size and modification time. Both are taken under the collection's read
+// lock, so no write lands between them and the bytes decoded, unless
+// resource level locking is on, when writers only hold that lock shared.
+// The records of a SingleFile collection report the size and modification
+// time of the shared file. A missing record returns ErrNotFound and an
+// expired one ErrExpired.
+func (d *Driver) ReadWithInfo(collection, resource string, v interface{}) (info RecordInfo, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "read", collection, resource)
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a place to read record from
+	if collection == "" {
+		return RecordInfo{}, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to read
+	if resource == "" {
+		return RecordInfo{}, ErrMissingResource
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return RecordInfo{}, err
+	}
+
+	b, stat, err := d.readWithStat(collection, resource)
+	unlock()
+	if errors.Is(err, ErrExpired) {
+		d.dropExpired(collection, resource)
+	}
+	if err != nil {
+		return RecordInfo{}, err
+	}
+
+	if err := d.decodeRecord(collection, resource, b, v); err != nil {
+		return RecordInfo{}, err
+	}
+
+	return RecordInfo{Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+// readWithStat reads a record along with the file info of what holds it;
+// the caller holds the collection lock
+func (d *Driver) readWithStat(collection, resource string) ([]byte, fs.FileInfo, error) {
+	stat, err := d.statRecord(collection, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := d.read(collection, resource)
+	if errors.Is(err, fs.ErrNotExist) && !errors.Is(err, ErrExpired) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, stat, nil
+}
@@ -0,0 +1,73 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadWithInfo(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	info, err := d.ReadWithInfo(collection, "red", &fish)
+	if err != nil {
+		t.Fatal("ReadWithInfo failed: ", err.Error())
+	}
+	if fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type)
+	}
+
+	stat, err := os.Stat(d.recordPath(collection, "red"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != stat.Size() || !info.ModTime.Equal(stat.ModTime()) {
+		t.Error("Expected ", stat.Size(), stat.ModTime(), ", got: ", info)
+	}
+
+	if _, err := d.ReadWithInfo(collection, "missing", &fish); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+}
+
+func TestReadWithInfoLikeRead(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection("s", CollectionConfig{SingleFile: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+	if err := d.Write("s", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a single file collection's record is described by the shared file
+	fish := Fish{}
+	info, err := d.ReadWithInfo("s", "red", &fish)
+	if err != nil || fish != redfish {
+		t.Fatal("Expected red fish, got: ", fish, err)
+	}
+
+	stat, err := os.Stat(d.singlePath("s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != stat.Size() || !info.ModTime.Equal(stat.ModTime()) {
+		t.Error("Expected ", stat.Size(), stat.ModTime(), ", got: ", info)
+	}
+
+	// an expired record isn't read
+	if err := d.WriteTTL(collection, "old", redfish, time.Nanosecond); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := d.ReadWithInfo(collection, "old", &Fish{}); !errors.Is(err, ErrExpired) {
+		t.Error("Expected ErrExpired, got: ", err)
+	}
+}
@@ -0,0 +1,140 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// IterateAll walks every collection in the database, including nested ones,
+// and calls [fn] with the raw bytes of each record, collection by collection
+// in sorted resource order. The records of SingleFile collections are
+// visited like any other, and records deleted while iterating are skipped.
+// Iteration stops at the first error returned by [fn].
+func (d *Driver) IterateAll(fn func(collection, resource string, raw []byte) error) error {
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range collections {
+		names, err := d.list(collection)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return &OpError{Op: "iterate", Collection: collection, Err: err}
+		}
+
+		for _, name := range names {
+			b, err := d.readLive(collection, name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return &OpError{Op: "iterate", Collection: collection, Resource: name, Err: err}
+			}
+
+			if err := fn(collection, name, b); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// IterateShard calls [fn] with the raw bytes of the records of [collection]
+// that fall into [shard], counting from 0, of [totalShards], in sorted
+// resource order. A record's shard only depends on its resource name, so the
+// shards are disjoint and together cover the whole collection: running every
+// shard in its own goroutine scans the collection in parallel. Records
+// deleted while iterating are skipped, and iteration stops at the first
+// error returned by [fn].
+func (d *Driver) IterateShard(collection string, shard, totalShards int, fn func(resource string, raw []byte) error) (err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "iterate", collection, "")
+
+	// ensure there is a collection to iterate
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	if totalShards <= 0 || shard < 0 || shard >= totalShards {
+		return fmt.Errorf("invalid shard %d of %d", shard, totalShards)
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for _, name := range names {
+		if shardOf(name, totalShards) != shard {
+			continue
+		}
+
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		if err := fn(name, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shardOf returns which of [totalShards] shards [resource] belongs to
+func shardOf(resource string, totalShards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(resource))
+	return int(h.Sum32() % uint32(totalShards))
+}
+
+// allCollections returns the sorted names of every collection in the
+// database, nested ones included
+func (d *Driver) allCollections() ([]string, error) {
+	return d.collectionsIn(d.dir)
+}
+
+// collectionsIn is allCollections of the database at [root]
+func (d *Driver) collectionsIn(root string) ([]string, error) {
+	var names []string
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() || path == root {
+			return nil
+		}
+
+		// hidden directories hold jsondb's own bookkeeping, not collections
+		if strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if name, ok := d.collectionName(rel); ok {
+			names = append(names, name)
+		}
+		return nil
+	})
+
+	return names, err
+}
@@ -0,0 +1,119 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestIterateAll(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, c := range []string{"fish", "birds", "deep/sea"} {
+		if err := d.Write(c, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// a leftover temp file must not be reported
+	if err := os.WriteFile(filepath.Join(d.dir, "fish", "blue.tmp"), []byte("{"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	err := d.IterateAll(func(collection, resource string, raw []byte) error {
+		seen[collection+"/"+resource] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Failed to iterate: ", err.Error())
+	}
+
+	if len(seen) != 3 || !seen["fish/red"] || !seen["birds/red"] || !seen["deep/sea/red"] {
+		t.Error("Expected every collection's record, got: ", seen)
+	}
+
+	// neither content addressed objects nor tombstones are records
+	d = newTestDB(t, &Options{ContentAddressed: true, Tombstones: true})
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+	if err := d.Delete(collection, "blue"); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+
+	seen = map[string]bool{}
+	err = d.IterateAll(func(collection, resource string, raw []byte) error {
+		seen[collection+"/"+resource] = true
+		return nil
+	})
+	if err != nil || len(seen) != 1 || !seen["fish/red"] {
+		t.Error("Expected only fish/red, got: ", seen, err)
+	}
+
+	// fn errors stop the walk
+	stop := errors.New("stop")
+	calls := 0
+	err = d.IterateAll(func(collection, resource string, raw []byte) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Error("Expected iteration to stop on first error, got: ", calls, err)
+	}
+}
+
+func TestIterateShard(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for i := 0; i < 50; i++ {
+		if err := d.Write(collection, fmt.Sprintf("fish%d", i), redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	const shards = 4
+
+	var mutex sync.Mutex
+	seen := make(map[string]int)
+
+	var wg sync.WaitGroup
+	for shard := 0; shard < shards; shard++ {
+		shard := shard
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := d.IterateShard(collection, shard, shards, func(resource string, raw []byte) error {
+				mutex.Lock()
+				seen[resource]++
+				mutex.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Error("Failed to iterate shard: ", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every record is visited exactly once across the shards
+	if len(seen) != 50 {
+		t.Error("Expected 50 fish, got: ", len(seen))
+	}
+
+	for name, n := range seen {
+		if n != 1 {
+			t.Error("Expected ", name, " to be visited once, got: ", n)
+		}
+	}
+
+	if err := d.IterateShard(collection, shards, shards, nil); err == nil {
+		t.Error("Expected an invalid shard to be refused")
+	}
+}
@@ -2,23 +2,44 @@
 package jsondb
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
 const (
 	dirMode  = 0o700
 	fileMode = 0o600
+
+	// tmpSuffix is appended to a record's path while it is being written
+	tmpSuffix = ".tmp"
+
+	// defaultTempFileAge is the default for Options.TempFileAge
+	defaultTempFileAge = time.Hour
 )
 
 var (
 	ErrMissingResource   = errors.New("missing resource - unable to save record")
 	ErrMissingCollection = errors.New("missing collection - no place to save record")
+	ErrNotFound          = errors.New("record not found")
+	ErrEmptyRecord       = errors.New("empty record - the record file has no content")
+	ErrNameCollision     = errors.New("name collision - a record and a collection share a name")
+	ErrAlreadyExists     = errors.New("record already exists")
+
+	ErrInvalidRecordShape = errors.New("invalid record shape - the record is not a JSON object")
 )
 
 // Debug is a function type to print log.
@@ -27,15 +48,371 @@ type Debug func(string, ...interface{})
 // Driver is what is used to interact with the jsondb database.
 // It runs transactions, and provides log output
 type Driver struct {
+	// mutex guards mutexes and dirs. It's only ever held for a map lookup or
+	// update, never while doing I/O or waiting for a collection lock, so it
+	// can be taken whatever collection locks the caller holds.
 	mutex   sync.Mutex
-	mutexes map[string]*sync.Mutex
+	mutexes map[string]*sync.RWMutex
 	dir     string // the directory where jsondb will create the database
 	log     Debug  // the logger jsondb will log to
+	cache   *cache // recently read records; nil when caching is disabled
+	ext     string // the extension appended to record filenames
+
+	dirs map[string]bool // collection directories known to exist, guarded by mutex
+
+	contentAddressed bool  // records are symlinks to shared content-addressed objects
+	source           fs.FS // seed records read when a record is absent on disk
+
+	tempFileAge time.Duration // how old a temp file must be before CleanTempFiles removes it
+
+	resolveConflict func(existing, incoming []byte) ([]byte, error)
+
+	manifest bool // collections keep a manifest of their records
+
+	disallowUnknownFields bool // decoding fails on fields the target doesn't have
+	useNumber             bool // numbers decode into interfaces as json.Number
+
+	encodeKey func(string) string          // maps resource names to filenames
+	decodeKey func(string) (string, error) // maps filenames back to resource names
+
+	encodeCollection func(string) string          // maps collection names to directories
+	decodeCollection func(string) (string, error) // maps directories back to collection names
+
+	tombstones bool // deletes leave tombstones behind
+
+	wal     bool // batches are logged ahead so a crash can't leave them half applied
+	durable bool // writes are flushed to disk before they return
+
+	reconcilePolicy ReconcilePolicy // how Reconcile resolves conflicts
+
+	tagKey string // struct tag naming record fields; empty for the json tag
+
+	emptyAsNotFound bool // empty record files read as missing records
+
+	lockTimeout time.Duration // how long writers wait for a lock; zero waits forever
+
+	stats opStats // operation counts per collection
+
+	copyOnRead bool // raw records handed out never alias the cache
+
+	stripFields []string // top-level fields removed from records before they're stored
+
+	beforeWrite func(collection, resource string, v interface{}) (interface{}, error)
+
+	beforeDelete func(collection, resource string) error
+
+	caseInsensitiveKeys bool // collection and resource names are lower cased
+
+	skipCorruptRecords bool // streams leave out records that aren't valid JSON
+
+	migrations      migrations // per collection chains registered with RegisterMigration
+	rewriteMigrated bool       // records migrated on read are written back
+
+	validators validators // per collection checks registered with SetValidator
+	schemas    schemas    // per collection JSON Schemas stored by InitSchema
+
+	keyLess func(a, b string) bool // orders resource names; nil is lexical
+
+	crossDeviceCopy bool // renames across file systems fall back to copying
+
+	mirrors    []string // copies of the database reads are spread across
+	nextMirror uint32   // round robin position in mirrors
+
+	trailingNewline bool // records end with a newline on disk
+
+	writeChecksum bool // records are stored with a checksum sidecar that reads verify
+
+	replica *replicator // copies changes to Options.ReplicaDir; nil when there is none
+
+	requireObject bool // records must be JSON objects
+	allowArrays   bool // with requireObject, JSON arrays are records too
+
+	tombstoneAge time.Duration // how old tombstones maintenance purges are
+
+	flights flightGroup // cache misses being read from disk
+
+	canonicalJSON bool // object members are stored sorted by name
+
+	detectKeyCollisions bool // records are stored with a sidecar naming their resource
+
+	strictDelete bool // deleting a missing record or collection fails with ErrNotFound
+
+	overlay overlay // records buffered by a BufferedWriter but not stored yet
+
+	nonRegular NonRegularPolicy // what listings do with entries that aren't regular files
+
+	afterWrite func(collection, resource string, raw []byte) // called with every record once it is stored
+
+	blooms blooms // filters of the names stored in collections configured with Bloom
+
+	codec Codec // encodes and decodes records; nil is encoding/json
+
+	resourceLevelLocking bool       // writers of distinct resources don't exclude each other
+	resourceLocks        keyedMutex // per resource locks when resourceLevelLocking is set
+
+	defaults CollectionConfig // settings of collections without their own
+	configs  configs          // settings of collections configured with ConfigureCollection
 }
 
 // Options uses for specification of working golang-jsondb
 type Options struct {
 	Debug // the logger jsondb will use (configurable)
+
+	// VerifyWritable makes New probe the database directory with a temporary
+	// file so permission problems surface at startup instead of on first Write
+	VerifyWritable bool
+
+	// CacheTTL enables caching of read records for the given duration. Writes
+	// and deletes made through the driver invalidate the cache immediately;
+	// the ttl bounds how long edits made outside of jsondb go unnoticed.
+	// Concurrent reads of a record missing from the cache share a single
+	// read from disk.
+	CacheTTL time.Duration
+
+	// Extension is appended to every record's filename on disk (e.g. ".json")
+	// and stripped again when listing, so resource names never include it
+	Extension string
+
+	// ContentAddressed stores each distinct record payload once, under its
+	// SHA-256 hash in a hidden objects directory, and makes every record a
+	// symlink to its payload so duplicate writes share storage. Objects are
+	// not removed when the records pointing at them are.
+	ContentAddressed bool
+
+	// SourceFS provides seed records, laid out like the database directory,
+	// for reads of records that don't exist on disk. Writes always go to disk,
+	// so on-disk records override their seeds; seed-only records can't be
+	// deleted. This is typically an embed.FS.
+	SourceFS fs.FS
+
+	// TempFileAge is how long a temp file must have gone unmodified before
+	// CleanTempFiles considers it abandoned; defaults to an hour
+	TempFileAge time.Duration
+
+	// ResolveConflict is called by Write when the record being written
+	// already exists, with the stored and the newly marshaled bytes; whatever
+	// it returns is stored instead. Returning [incoming] overwrites as usual,
+	// returning [existing] keeps the stored record, and an error aborts the
+	// write.
+	ResolveConflict func(existing, incoming []byte) ([]byte, error)
+
+	// Manifest maintains a manifest file in every collection listing its
+	// records, updated by every change made through the driver, so listing a
+	// collection doesn't need to read its directory. Use RebuildManifest after
+	// changing records outside of jsondb.
+	Manifest bool
+
+	// DisallowUnknownFields makes reads fail when a record has a field the
+	// struct it's read into doesn't, instead of silently dropping it
+	DisallowUnknownFields bool
+
+	// UseNumber makes reads decode numbers into interface{} values as
+	// json.Number instead of float64, preserving the precision of large integers
+	UseNumber bool
+
+	// KeyEncoder maps resource names to the filenames storing them, so keys
+	// with characters filesystems don't allow can be used as is; KeyDecoder
+	// maps filenames back, and files it fails to decode aren't records.
+	// PercentEncodeKey and PercentDecodeKey are a ready-made pair.
+	KeyEncoder func(string) string
+	KeyDecoder func(string) (string, error)
+
+	// CollectionEncoder maps collection names to the directories, relative
+	// to the database, storing them, so any string can name a collection;
+	// CollectionDecoder maps the slash separated directory paths back, and
+	// directories it fails to decode aren't collections. Without them a
+	// collection name is its directory path, and slashes nest collections.
+	// PercentEncodeKey and PercentDecodeKey work here too.
+	CollectionEncoder func(string) string
+	CollectionDecoder func(string) (string, error)
+
+	// Tombstones makes Delete of a record leave a tombstone with the deletion
+	// time behind, so replicas can learn about the delete through
+	// ListTombstones. Tombstones also hide seed records from SourceFS. Writing
+	// the record again removes its tombstone; deleting a whole collection
+	// leaves none.
+	Tombstones bool
+
+	// Compress gzips records at rest. Records stored uncompressed, e.g.
+	// before compression was turned on, remain readable.
+	Compress bool
+
+	// Indent, when not empty, indents records on disk with it for readability
+	Indent string
+
+	// ResourceLevelLocking lets writes and deletes of different resources in
+	// the same collection run concurrently; only operations on the same
+	// resource, or on the collection as a whole, exclude each other
+	ResourceLevelLocking bool
+
+	// WAL logs every batch to the collection's write-ahead log, flushed to
+	// disk, before applying it, and New replays batches a crash interrupted.
+	// This makes batches all or nothing at the cost of extra disk syncs.
+	WAL bool
+
+	// Durable flushes every record and its directory to disk before a write
+	// returns, so a write that succeeded survives a power loss. Without it
+	// writes are atomic but may be lost if the machine crashes soon after.
+	Durable bool
+
+	// ReconcilePolicy decides how Reconcile resolves a record whose temp file
+	// differs from it; the zero value only reports conflicts
+	ReconcilePolicy ReconcilePolicy
+
+	// TagKey names the struct tag whose field names and options are used for
+	// records on disk instead of the json tag, e.g. "jsondb"; the json tag
+	// of other fields is then ignored. Types implementing json.Marshaler or
+	// json.Unmarshaler still encode themselves.
+	TagKey string
+
+	// EmptyAsNotFound makes reading a record whose file is empty, e.g. after
+	// being truncated by a crash, fail like reading a missing record instead
+	// of with ErrEmptyRecord
+	EmptyAsNotFound bool
+
+	// LockTimeout, when set, makes writes and deletes give up with
+	// ErrLockTimeout if the lock they need isn't free within it, rather than
+	// queueing forever behind a wedged operation
+	LockTimeout time.Duration
+
+	// CopyOnRead makes every method returning raw record bytes return a
+	// private copy the caller may modify. Without it, bytes served from the
+	// cache (see Scan) are shared with it and must be treated as read-only;
+	// ReadAll and ReadPage always return freshly read bytes.
+	CopyOnRead bool
+
+	// StripFields lists top-level fields removed from every record before it
+	// is stored, such as computed or sensitive ones. Records that aren't JSON
+	// objects are stored as they are.
+	StripFields []string
+
+	// BeforeWrite, when set, is called with every value about to be stored,
+	// counters included, and may return a replacement such as a copy with
+	// an updated timestamp; returning nil stores the value as is, and an
+	// error aborts the write
+	BeforeWrite func(collection, resource string, v interface{}) (interface{}, error)
+
+	// BeforeDelete, when set, is called with every record or collection about
+	// to be deleted, including those of DeleteMany; an error aborts the
+	// delete, so it can protect records or log deletions for an audit trail
+	BeforeDelete func(collection, resource string) error
+
+	// CaseInsensitiveKeys lower cases collection and resource names, so
+	// "Bob" and "bob" are the same record on every file system; listings
+	// report the lower cased names
+	CaseInsensitiveKeys bool
+
+	// SkipCorruptRecords makes StreamNDJSON leave out records that aren't
+	// valid JSON instead of writing an error line for them
+	SkipCorruptRecords bool
+
+	// RewriteMigrated makes Read write records it migrated back to disk, so
+	// the migrations registered with RegisterMigration run once per record
+	// rather than on every read
+	RewriteMigrated bool
+
+	// KeyLess orders resource names wherever the driver sorts them, such as
+	// in ReadAll, ReadPage and Scan, so keys like "2" and "10" can come in
+	// numeric order; nil orders them lexically
+	KeyLess func(a, b string) bool
+
+	// CrossDeviceCopy makes a write whose temp file can't be renamed onto
+	// the record because they're on different file systems (EXDEV) copy the
+	// temp file over the record instead. The copy isn't atomic: a crash or a
+	// concurrent reader may see the record partly written, so only set this
+	// when the mount layout makes renames fail outright.
+	CrossDeviceCopy bool
+
+	// ReadMirrors are directories holding copies of the database that Read
+	// and ReadAll take records from in turn, falling back to the database
+	// itself when a mirror doesn't have a record. Writes only go to the
+	// database; keeping the mirrors in sync, and how far they may lag
+	// behind, is up to whatever copies it.
+	ReadMirrors []string
+
+	// TrailingNewline ends every record written with a newline, the way
+	// editors and git expect text files to end
+	TrailingNewline bool
+
+	// ExpectEmptyOrDatabase makes New refuse a directory that is neither
+	// empty nor laid out like a jsondb database, i.e. holding anything but
+	// collection directories and the hidden directories jsondb keeps, so a
+	// misconfigured path, like a home folder, isn't taken for a database
+	ExpectEmptyOrDatabase bool
+
+	// WriteChecksum stores the SHA-256 of every record written next to it,
+	// in a file named after the record with a ".sum" suffix, and makes reads
+	// from disk fail with ErrChecksumMismatch when the bytes stored don't
+	// match it, catching corruption JSON validation can't. Records without a
+	// checksum, like those written before this was set, are read unchecked;
+	// VerifyChecksums scans a collection for corrupted records.
+	WriteChecksum bool
+
+	// ReplicaDir is a directory every change to a record is copied to in the
+	// background, keeping a hot standby of the database. Copying is best
+	// effort and eventually consistent: it happens after the change was made
+	// and never fails it, a change that finds the bounded queue full is
+	// dropped, and failures are only logged through Debug. The replica holds
+	// the records as stored, not configurations, indexes or tombstones, so
+	// it can serve as one of ReadMirrors.
+	ReplicaDir string
+
+	// RequireObject makes writes fail with ErrInvalidRecordShape when the
+	// marshaled record isn't a JSON object, catching scalars written by
+	// accident that field operations like SetField can't work on
+	RequireObject bool
+
+	// AllowArrays makes RequireObject accept JSON arrays as well
+	AllowArrays bool
+
+	// TombstoneAge is how old a tombstone must be before the passes of
+	// StartMaintenance purge it; zero leaves tombstones to PurgeTombstones
+	TombstoneAge time.Duration
+
+	// CanonicalJSON stores every record with the members of its objects
+	// sorted by name, at every depth, so the same logical value is always
+	// stored as the same bytes whatever the field order of the struct it was
+	// marshaled from; checksums, content addressing and DiffCollections then
+	// only see real changes
+	CanonicalJSON bool
+
+	// DetectKeyCollisions stores the resource name of every record next to
+	// it, in a file named after the record with a ".key" suffix, and makes a
+	// write fail with ErrKeyCollision rather than overwrite a record stored
+	// under another name that KeyEncoder maps to the same file. Names equal
+	// once CaseInsensitiveKeys lower cases them are the same name, not a
+	// collision.
+	DetectKeyCollisions bool
+
+	// StrictDelete makes Delete of a record or collection that doesn't exist
+	// fail with ErrNotFound; by default such a Delete succeeds, so retries and
+	// "ensure it's gone" calls need no special casing
+	StrictDelete bool
+
+	// NonRegularFiles decides what ReadAll, listings and IterateAll do with
+	// entries of a collection named like records that aren't regular files,
+	// such as symlinks, sockets and devices; the zero value skips them, so
+	// nothing surprising is read. Content addressed records are symlinks
+	// that are always listed.
+	NonRegularFiles NonRegularPolicy
+
+	// AfterWrite, when set, is called with every record a write stored once
+	// it is on disk, after the rename and any flush of Durable, and never for
+	// a write that failed, so side effects like publishing the change only
+	// happen for persisted records. [raw] is the record as marshaled, before
+	// any compression, and must not be modified. It runs synchronously while
+	// the record is still locked, so calls come in the order of the writes;
+	// slow work is best handed off, and reading the same collection from it
+	// would wait for the lock forever.
+	AfterWrite func(collection, resource string, raw []byte)
+
+	// Codec, when set, encodes and decodes records instead of encoding/json,
+	// e.g. to store them indented; TagKey, DisallowUnknownFields and
+	// UseNumber then don't apply. Everything that looks into records, like
+	// indexes, SetField, patches, StripFields and schemas, reads them as
+	// JSON, so a codec storing another format only suits plain reads and
+	// writes.
+	Codec Codec
 }
 
 // New creates a new jsondb database at the desired directory location, and
@@ -56,26 +433,182 @@ func New(dir string, options *Options) (*Driver, error) {
 		opts.Debug = log.Printf
 	}
 
+	if opts.TempFileAge <= 0 {
+		opts.TempFileAge = defaultTempFileAge
+	}
+
+	// the json tag is what encoding/json uses anyway
+	if opts.TagKey == "json" {
+		opts.TagKey = ""
+	}
+
 	driver := Driver{
 		dir:     dir,
-		mutexes: make(map[string]*sync.Mutex),
+		mutexes: make(map[string]*sync.RWMutex),
+		dirs:    make(map[string]bool),
 		log:     opts.Debug,
+		cache:   newCache(opts.CacheTTL, opts.CaseInsensitiveKeys),
+		ext:     opts.Extension,
+
+		contentAddressed: opts.ContentAddressed,
+		source:           opts.SourceFS,
+
+		tempFileAge:     opts.TempFileAge,
+		resolveConflict: opts.ResolveConflict,
+		manifest:        opts.Manifest,
+
+		disallowUnknownFields: opts.DisallowUnknownFields,
+		useNumber:             opts.UseNumber,
+
+		encodeKey: opts.KeyEncoder,
+		decodeKey: opts.KeyDecoder,
+
+		encodeCollection: opts.CollectionEncoder,
+		decodeCollection: opts.CollectionDecoder,
+
+		tombstones: opts.Tombstones,
+
+		defaults: CollectionConfig{Compress: opts.Compress, Indent: opts.Indent},
+		configs:  configs{byName: make(map[string]CollectionConfig)},
+
+		wal:     opts.WAL,
+		durable: opts.Durable,
+
+		reconcilePolicy: opts.ReconcilePolicy,
+		tagKey:          opts.TagKey,
+		emptyAsNotFound: opts.EmptyAsNotFound,
+		lockTimeout:     opts.LockTimeout,
+
+		resourceLevelLocking: opts.ResourceLevelLocking,
+		resourceLocks:        keyedMutex{locks: make(map[string]*refMutex)},
+
+		stats: opStats{byName: make(map[string]*OpCounts)},
+
+		copyOnRead:  opts.CopyOnRead,
+		stripFields: opts.StripFields,
+		beforeWrite: opts.BeforeWrite,
+
+		beforeDelete: opts.BeforeDelete,
+
+		caseInsensitiveKeys: opts.CaseInsensitiveKeys,
+		skipCorruptRecords:  opts.SkipCorruptRecords,
+
+		migrations:      migrations{byName: make(map[string]map[int]migrateFunc)},
+		rewriteMigrated: opts.RewriteMigrated,
+
+		validators: validators{byName: make(map[string]func(raw []byte) error)},
+		schemas:    schemas{byName: make(map[string]*jsonSchema)},
+		keyLess:    opts.KeyLess,
+
+		crossDeviceCopy: opts.CrossDeviceCopy,
+		mirrors:         opts.ReadMirrors,
+		trailingNewline: opts.TrailingNewline,
+
+		writeChecksum: opts.WriteChecksum,
+
+		requireObject: opts.RequireObject,
+		allowArrays:   opts.AllowArrays,
+
+		tombstoneAge: opts.TombstoneAge,
+
+		flights: flightGroup{calls: make(map[string]*flightCall)},
+
+		canonicalJSON: opts.CanonicalJSON,
+
+		detectKeyCollisions: opts.DetectKeyCollisions,
+
+		strictDelete: opts.StrictDelete,
+
+		overlay: overlay{fold: opts.CaseInsensitiveKeys, byName: make(map[string]map[string][]byte)},
+
+		nonRegular: opts.NonRegularFiles,
+
+		afterWrite: opts.AfterWrite,
+
+		blooms: blooms{byName: make(map[string]*bloomFilter)},
+
+		codec: opts.Codec,
+	}
+
+	if opts.ReplicaDir != "" {
+		driver.replica = &replicator{dir: filepath.Clean(opts.ReplicaDir), queue: make(chan replicaOp, replicaQueueSize)}
 	}
 
 	// if the database already exists, just use it
 	if _, err := os.Stat(dir); err == nil {
 		opts.Debug("Using '%s' (database already exists)\n", dir)
-		return &driver, nil
+
+		if opts.ExpectEmptyOrDatabase {
+			if err := driver.checkLayout(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// if the database doesn't exist create it
+		opts.Debug("Creating jsondb database at '%s'...\n", dir)
+		if err := os.MkdirAll(dir, dirMode); err != nil {
+			return &driver, err
+		}
 	}
 
-	// if the database doesn't exist create it
-	opts.Debug("Creating jsondb database at '%s'...\n", dir)
-	return &driver, os.MkdirAll(dir, dirMode)
+	// fail fast if records could never be written
+	if opts.VerifyWritable {
+		if err := verifyWritable(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	// finish any batch a crash interrupted
+	if opts.WAL {
+		if err := driver.Recover(); err != nil {
+			return nil, err
+		}
+	}
+
+	if driver.replica != nil {
+		go driver.runReplica()
+	}
+
+	return &driver, nil
+}
+
+// verifyWritable writes and removes a marker file in [dir] to make sure the
+// database directory accepts new files
+func verifyWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".jsondb-probe-*")
+	if err != nil {
+		return fmt.Errorf("database directory '%s' is not writable: %w", dir, err)
+	}
+
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		os.Remove(name)
+		return fmt.Errorf("database directory '%s' is not writable: %w", dir, err)
+	}
+
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("database directory '%s' is not writable: %w", dir, err)
+	}
+
+	return nil
 }
 
 // Write locks the database and attempts to write the record to the database under
 // the [collection] specified with the [resource] name given
 func (d *Driver) Write(collection, resource string, v interface{}) error {
+	return d.WriteContext(context.Background(), collection, resource, v)
+}
+
+// WriteContext is Write giving up with the context's error once [ctx] is
+// done, whether it's waiting for the collection lock or about to write. A
+// write already under way isn't interrupted, so it's stored whole or not at
+// all.
+func (d *Driver) WriteContext(ctx context.Context, collection, resource string, v interface{}) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "write", collection, resource)
+	defer d.countOp(collection, opWrite, &err)
+
 	// ensure there is a place to save record
 	if collection == "" {
 		return ErrMissingCollection
@@ -86,151 +619,1111 @@ func (d *Driver) Write(collection, resource string, v interface{}) error {
 		return ErrMissingResource
 	}
 
-	mutex := d.getOrCreateMutex(collection)
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	dir := filepath.Join(d.dir, collection)
-	fnlPath := filepath.Join(dir, resource)
-	tmpPath := fnlPath + ".tmp"
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	return write(dir, tmpPath, fnlPath, v)
-}
+	unlock, err := d.lockResourceContext(ctx, collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
 
-func write(dir, tmpPath, dstPath string, v interface{}) error {
-	// create collection directory
-	if err := os.MkdirAll(dir, dirMode); err != nil {
+	// the context may have ended while the lock was awaited
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	b, err := json.Marshal(v)
+	return d.write(collection, resource, v)
+}
+
+// write marshals [v] and atomically stores it as [resource]; the caller holds
+// the collection lock
+func (d *Driver) write(collection, resource string, v interface{}) error {
+	b, err := d.marshal(collection, resource, v)
 	if err != nil {
 		return err
 	}
 
-	// write marshaled data to the temp file
-	if err := os.WriteFile(tmpPath, b, fileMode); err != nil {
+	return d.writeResolved(collection, resource, b)
+}
+
+// writeResolved stores the marshaled [b] as [resource], first letting the
+// conflict resolver decide what an overwrite stores
+func (d *Driver) writeResolved(collection, resource string, b []byte) error {
+	b, err := d.resolve(collection, resource, b)
+	if err != nil {
 		return err
 	}
 
-	// move final file into place
-	return os.Rename(tmpPath, dstPath)
+	return d.writeRaw(collection, resource, b)
 }
 
-// Read a record from the database
-func (d *Driver) Read(collection, resource string, v interface{}) error {
-	// ensure there is a place to save record
-	if collection == "" {
-		return ErrMissingCollection
+// resolve returns what writing the marshaled [b] as [resource] stores: what
+// the conflict resolver makes of an overwrite, at one past the stored
+// record's revision if WriteWithRev gave it one
+func (d *Driver) resolve(collection, resource string, b []byte) ([]byte, error) {
+	existing, err := d.readFile(collection, resource)
+	switch {
+	case err == nil && len(existing) == 0:
+		// a reserved name has nothing to conflict with
+		return b, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return b, nil
+	case err != nil && d.resolveConflict == nil:
+		// only the resolver needs the stored record; whatever is in the way,
+		// such as a collection of the same name, is left to the write
+		return b, nil
+	case err != nil:
+		return nil, err
 	}
 
-	// ensure there is a resource (name) to save record as
-	if resource == "" {
-		return ErrMissingResource
+	if d.resolveConflict != nil {
+		if b, err = d.resolveConflict(existing, b); err != nil {
+			return nil, err
+		}
 	}
 
-	record := filepath.Join(d.dir, collection, resource)
+	// a record written with its revision keeps counting, whichever way it's
+	// written next
+	rev, err := intMember(existing, revField, "record revision")
+	if err != nil || rev == 0 {
+		return b, err
+	}
 
-	// read record from database; if the file doesn't exist `read` will return an err
-	return read(record, v)
+	return stampInt(b, revField, rev+1)
 }
 
-func read(record string, v interface{}) error {
-	b, err := os.ReadFile(record)
+// writeRaw atomically stores the already marshaled [b] as [resource]; the
+// caller holds the collection lock
+func (d *Driver) writeRaw(collection, resource string, b []byte) error {
+	if err := d.checkNames(collection, resource); err != nil {
+		return err
+	}
+
+	if err := d.checkKey(collection, resource); err != nil {
+		return err
+	}
+
+	release, err := d.checkUnique(collection, resource, b)
 	if err != nil {
 		return err
 	}
+	defer release()
 
-	// unmarshal data
-	return json.Unmarshal(b, &v)
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return err
+	}
+	if single {
+		if err := d.updateSingle(collection, resource, b); err != nil {
+			return err
+		}
+		return d.written(collection, resource, b)
+	}
+
+	w, err := d.stageRecord(collection, resource, b)
+	if err != nil {
+		return err
+	}
+
+	return d.commitRecord(collection, w)
 }
 
-// ReadAll records from a collection; this is returned as a slice of strings because
-// there is no way of knowing what type the record is.
-func (d *Driver) ReadAll(collection string) ([][]byte, error) {
-	// ensure there is a collection to read
-	if collection == "" {
-		return nil, ErrMissingCollection
+// stagedWrite is a record written to its temp file by stageRecord, waiting
+// for commitRecord to move it into place
+type stagedWrite struct {
+	resource string
+	raw      []byte // the record as marshaled
+	data     []byte // the record as stored, once encoded
+	tmpPath  string
+	fnlPath  string
+}
+
+// stageRecord writes the already marshaled [b] to the temp file of
+// [resource], removing what it wrote if that fails
+func (d *Driver) stageRecord(collection, resource string, b []byte) (*stagedWrite, error) {
+	// create collection directory
+	if err := d.ensureDir(collection); err != nil {
+		return nil, err
+	}
+
+	data, err := d.encode(collection, b)
+	if err != nil {
+		return nil, err
 	}
 
-	dir := filepath.Join(d.dir, collection)
+	fnlPath := d.recordPath(collection, resource)
+	w := &stagedWrite{resource: resource, raw: b, data: data, tmpPath: fnlPath + tmpSuffix, fnlPath: fnlPath}
 
-	// read all the files in the transaction.Collection; an error here just means
-	// the collection is either empty or doesn't exist
-	files, err := os.ReadDir(dir)
+	err = d.stage(w.tmpPath, w.fnlPath, w.data)
+	if os.IsNotExist(err) {
+		// the directory went away behind our back; create it again
+		d.forgetDirs(collection)
+		if err = d.ensureDir(collection); err == nil {
+			err = d.stage(w.tmpPath, w.fnlPath, w.data)
+		}
+	}
 	if err != nil {
+		os.Remove(w.tmpPath)
 		return nil, err
 	}
 
-	return readAll(files, dir)
+	return w, nil
 }
 
-func readAll(files []os.DirEntry, dir string) ([][]byte, error) {
-	// the files read from the database
-	var records [][]byte
+// commitRecord moves the record [w] staged into place
+func (d *Driver) commitRecord(collection string, w *stagedWrite) error {
+	err := d.commit(w.tmpPath, w.fnlPath)
+	if os.IsNotExist(err) {
+		// the directory went away behind our back, along with the temp file
+		d.forgetDirs(collection)
+		if err = d.ensureDir(collection); err == nil {
+			err = d.writeBytes(w.tmpPath, w.fnlPath, w.data)
+		}
+	}
+	if err != nil {
+		os.Remove(w.tmpPath)
 
-	// iterate over each of the files, attempting to read the file. If successful
-	// append the files to the collection of read
-	for _, file := range files {
-		b, err := os.ReadFile(filepath.Join(dir, file.Name()))
-		if err != nil {
-			return nil, err
+		// a nested collection may be in the way of the record
+		if info, serr := os.Lstat(w.fnlPath); serr == nil && info.IsDir() {
+			return fmt.Errorf("%w: %s/%s is a collection", ErrNameCollision, collection, w.resource)
 		}
+		return err
+	}
 
-		// append read file
-		records = append(records, b)
+	if err := d.unbury(collection, w.resource); err != nil {
+		return err
 	}
 
-	// unmarhsal the read files as a comma delimeted byte array
-	return records, nil
+	return d.written(collection, w.resource, w.raw)
 }
 
-// Delete locks the database then attempts to remove the collection/resource
-// specified by [path]
-func (d *Driver) Delete(collection, resource string) error {
-	path := filepath.Join(collection, resource)
-	//
-	mutex := d.getOrCreateMutex(collection)
-	mutex.Lock()
-	defer mutex.Unlock()
+// written is recordChanged of a record just stored as [raw], telling
+// AfterWrite about it once everything else has been updated
+func (d *Driver) written(collection, resource string, raw []byte) error {
+	if err := d.recordChanged(collection, resource); err != nil {
+		return err
+	}
 
-	dir := filepath.Join(d.dir, path)
+	if d.afterWrite != nil {
+		d.afterWrite(collection, resource, raw)
+	}
+	return nil
+}
 
-	switch fi, err := stat(dir); {
-	// if fi is nil or error is not nil return
-	case fi == nil, err != nil:
-		return fmt.Errorf("unable to find file or directory named %v", path)
-	// remove directory and all contents
-	case fi.Mode().IsDir():
-		return os.RemoveAll(dir)
-	// remove file
-	case fi.Mode().IsRegular():
-		return os.RemoveAll(dir)
+// collectionCollision returns an ErrNameCollision if a record is stored
+// where [collection], or a collection it's nested in, would be
+func (d *Driver) collectionCollision(collection string) error {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(d.collectionPath(collection))), "/")
+	for i := range parts {
+		name := strings.Join(parts[:i+1], "/")
+
+		info, err := os.Lstat(filepath.Join(d.dir, name))
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%w: %s is a record, not a collection", ErrNameCollision, name)
+		}
 	}
 
 	return nil
 }
 
-func stat(path string) (fi os.FileInfo, err error) {
-	// check for dir, if path isn't a directory check to see if it's a file
-	if fi, err = os.Stat(path); os.IsNotExist(err) {
-		fi, err = os.Stat(path)
+// writeBytes atomically replaces [dstPath] with [b] by way of [tmpPath]
+func (d *Driver) writeBytes(tmpPath, dstPath string, b []byte) error {
+	if err := d.stage(tmpPath, dstPath, b); err != nil {
+		return err
 	}
 
-	return
+	return d.commit(tmpPath, dstPath)
 }
 
-// getOrCreateMutex creates a new collection specific mutex any time a collection
-// is being modified to avoid unsafe operations
-func (d *Driver) getOrCreateMutex(collection string) *sync.Mutex {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
+// commit moves the file [stage] wrote to [tmpPath] into place as [dstPath]
+func (d *Driver) commit(tmpPath, dstPath string) error {
+	// move final file into place
+	if err := d.rename(tmpPath, dstPath); err != nil {
+		return err
+	}
 
-	m, ok := d.mutexes[collection]
-	if !ok {
-		m = &sync.Mutex{}
-		d.mutexes[collection] = m
+	// the rename only survives a crash once the directory is flushed too
+	if d.durable {
+		return syncFile(filepath.Dir(dstPath))
 	}
 
-	return m
+	return nil
+}
+
+// writeFileAtomic replaces [path] with [b] by way of a temp file, outside of
+// any content addressing; used for files that aren't records
+func writeFileAtomic(path string, b []byte) error {
+	if err := os.WriteFile(path+tmpSuffix, b, fileMode); err != nil {
+		return err
+	}
+
+	return os.Rename(path+tmpSuffix, path)
+}
+
+// rename moves [tmpPath] onto [dstPath], copying it when the two are on
+// different file systems and Options.CrossDeviceCopy allows that
+func (d *Driver) rename(tmpPath, dstPath string) error {
+	err := os.Rename(tmpPath, dstPath)
+	if err == nil || !d.crossDeviceCopy || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	d.log("Copying '%s' across file systems\n", dstPath)
+	return copyReplace(tmpPath, dstPath)
+}
+
+// copyReplace overwrites [dstPath] with the contents of [tmpPath] and then
+// removes [tmpPath]
+func copyReplace(tmpPath, dstPath string) error {
+	b, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(dstPath, b, fileMode); err != nil {
+		return err
+	}
+
+	return os.Remove(tmpPath)
+}
+
+// stage writes [b] to [tmpPath] so that renaming it to [dstPath] commits it
+func (d *Driver) stage(tmpPath, dstPath string, b []byte) error {
+	// store the bytes once and point the record at them; the object is
+	// flushed, together with its directory, before the record points at it
+	if d.contentAddressed {
+		if err := d.stageObject(tmpPath, dstPath, b); err != nil || !d.durable {
+			return err
+		}
+		if err := syncFile(tmpPath); err != nil {
+			return err
+		}
+		return syncFile(filepath.Join(d.dir, objectsDir))
+	}
+
+	// write marshaled data to the temp file, flushing it before it's closed
+	if d.durable {
+		return writeFileSync(tmpPath, b)
+	}
+	return os.WriteFile(tmpPath, b, fileMode)
+}
+
+// Read a record from the database. Read holds the collection's read lock
+// while reading, so reads don't wait for one another but never overlap a
+// write of the collection, which matters when CrossDeviceCopy writes records
+// in place. Hooks like BeforeWrite and AfterWrite run under the write lock
+// and mustn't read the collection they're called for.
+func (d *Driver) Read(collection, resource string, v interface{}) error {
+	return d.ReadContext(context.Background(), collection, resource, v)
+}
+
+// ReadContext is Read giving up with the context's error once [ctx] is
+// done, whether it's waiting for the collection's read lock or about to read
+func (d *Driver) ReadContext(ctx context.Context, collection, resource string, v interface{}) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "read", collection, resource)
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a place to save record
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save record as
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	unlock, err := d.rlockContext(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	// read record from database; if the file doesn't exist this will return an err
+	var b []byte
+	if err = ctx.Err(); err == nil {
+		b, err = d.read(collection, resource)
+	}
+	unlock()
+	if errors.Is(err, ErrExpired) {
+		d.dropExpired(collection, resource)
+	}
+	if err != nil {
+		return err
+	}
+
+	// bring records stored by older versions of the program up to date; a
+	// rewrite takes the write lock, so the read lock is released first
+	if b, err = d.migrateRecord(collection, resource, b, d.rewriteMigrated); err != nil {
+		return err
+	}
+
+	// unmarshal data
+	return d.unmarshal(b, v)
+}
+
+// decodeRecord decodes the record [b] of [resource] into [v] the way Read
+// does, first bringing it up to the collection's latest version. A migrated
+// record may be written back, so the caller mustn't hold the collection lock.
+func (d *Driver) decodeRecord(collection, resource string, b []byte, v interface{}) error {
+	b, err := d.migrateRecord(collection, resource, b, d.rewriteMigrated)
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshal(b, v)
+}
+
+// unmarshal decodes a record into [v], applying the driver's decoding options
+func (d *Driver) unmarshal(b []byte, v interface{}) error {
+	return d.unmarshalWith(b, v, d.decodeOptions())
+}
+
+// unmarshalWith is unmarshal with [opts] in place of the driver's Options
+func (d *Driver) unmarshalWith(b []byte, v interface{}, opts DecodeOptions) error {
+	if d.codec != nil {
+		return d.codec.Unmarshal(b, v)
+	}
+
+	if d.tagKey != "" {
+		return d.unmarshalTagged(b, v, opts)
+	}
+
+	return d.unmarshalJSON(b, v, opts)
+}
+
+// decodeOptions returns the decoding settings given in Options
+func (d *Driver) decodeOptions() DecodeOptions {
+	return DecodeOptions{DisallowUnknownFields: d.disallowUnknownFields, UseNumber: d.useNumber}
+}
+
+// unmarshalJSON decodes [b] into [v] with encoding/json and the decode options
+func (d *Driver) unmarshalJSON(b []byte, v interface{}, opts DecodeOptions) error {
+	if !opts.DisallowUnknownFields && !opts.UseNumber {
+		return json.Unmarshal(b, &v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	// like json.Unmarshal, reject anything after the record
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+
+	return nil
+}
+
+// read is readStored failing with ErrExpired for a record past the expiry
+// WriteTTL gave it
+func (d *Driver) read(collection, resource string) ([]byte, error) {
+	b, err := d.readStored(collection, resource)
+	return d.checkExpiry(collection, resource, b, err)
+}
+
+// readLive is readFile failing with ErrExpired like read, for scans that
+// bypass the cache
+func (d *Driver) readLive(collection, resource string) ([]byte, error) {
+	b, err := d.readFile(collection, resource)
+	return d.checkExpiry(collection, resource, b, err)
+}
+
+// checkExpiry returns the record [b] read as [resource] along with the read's
+// [err], or ErrExpired if the record is past its expiry
+func (d *Driver) checkExpiry(collection, resource string, b []byte, err error) ([]byte, error) {
+	if err == nil && expired(b, time.Now()) {
+		return nil, &fs.PathError{Op: "read", Path: d.recordPath(collection, resource), Err: ErrExpired}
+	}
+
+	return b, err
+}
+
+// readStored returns the raw bytes of a record, from the cache while it's
+// fresh; they may be shared with the cache, see own. With the cache on,
+// concurrent reads of a record missing from it share a single read from
+// disk.
+func (d *Driver) readStored(collection, resource string) ([]byte, error) {
+	if b, ok := d.overlay.get(collection, resource); ok {
+		return b, nil
+	}
+
+	if b, ok := d.cache.get(collection, resource); ok {
+		return b, nil
+	}
+
+	if d.cache == nil {
+		return d.readDisk(collection, resource)
+	}
+
+	return d.flights.do(collection+"\x00"+resource, func() ([]byte, error) {
+		// a read that finished meanwhile may have filled the cache
+		if b, ok := d.cache.get(collection, resource); ok {
+			return b, nil
+		}
+
+		b, err := d.readDisk(collection, resource)
+		if err != nil {
+			return nil, err
+		}
+
+		d.cache.put(collection, resource, b)
+		return b, nil
+	})
+}
+
+// readDisk returns the raw bytes of a record, bypassing the cache
+func (d *Driver) readDisk(collection, resource string) ([]byte, error) {
+	b, err := d.readMirrored(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// a truncated file would otherwise fail as "unexpected end of JSON input"
+	if len(b) == 0 {
+		if d.emptyAsNotFound {
+			return nil, &fs.PathError{Op: "read", Path: d.recordPath(collection, resource), Err: fs.ErrNotExist}
+		}
+		return nil, ErrEmptyRecord
+	}
+
+	return b, nil
+}
+
+// ReadAll records from a collection; this is returned as a slice of strings because
+// there is no way of knowing what type the record is. The records are read fresh
+// from disk and belong to the caller. Like Read it holds the collection's read
+// lock, so the records are read between writes rather than amid one.
+func (d *Driver) ReadAll(collection string) (records [][]byte, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "readall", collection, "")
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	_, records, err = d.readAllNamed(collection)
+	return records, err
+}
+
+// readAllNamed is ReadAll also returning the name of each record read
+func (d *Driver) readAllNamed(collection string) (names []string, records [][]byte, err error) {
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	// read all the records in the transaction.Collection; an error here just means
+	// the collection is either empty or doesn't exist
+	names, err = d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	// records a BufferedWriter hasn't stored yet are read too
+	if pending := d.overlay.merge(collection, names); len(pending) != len(names) {
+		names = pending
+		d.sortNames(names)
+	}
+
+	// a collection with nothing on disk or pending doesn't exist
+	if len(names) == 0 && err != nil {
+		return nil, nil, err
+	}
+
+	return d.readAll(collection, names)
+}
+
+// readAll reads the records [names] of [collection], returning the names of
+// those it read along with their bytes, in the same order; records deleted
+// since they were listed, reserved names and expired records are left out
+func (d *Driver) readAll(collection string, names []string) (read []string, records [][]byte, err error) {
+	now := time.Now()
+
+	// iterate over each of the files, attempting to read the file. If successful
+	// append the files to the collection of read
+	for _, name := range names {
+		b, err := d.readPending(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		// a reserved name that hasn't been written yet, or a record past its
+		// expiry that Purge hasn't removed yet
+		if len(b) == 0 || expired(b, now) {
+			continue
+		}
+
+		// append read file
+		read = append(read, name)
+		records = append(records, b)
+	}
+
+	return read, records, nil
+}
+
+// readPending reads a record the way readAll does, preferring what a
+// BufferedWriter hasn't stored yet
+func (d *Driver) readPending(collection, resource string) ([]byte, error) {
+	if b, ok := d.overlay.get(collection, resource); ok {
+		return b, nil
+	}
+
+	return d.readMirrored(collection, resource)
+}
+
+// Delete locks the database then attempts to remove the collection/resource
+// specified by [path]. Deleting something that doesn't exist succeeds, unless
+// Options.StrictDelete is set, when it fails with ErrNotFound.
+func (d *Driver) Delete(collection, resource string) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "delete", collection, resource)
+	defer d.countOp(collection, opDelete, &err)
+
+	if err := d.checkNames(collection, resource); err != nil {
+		return err
+	}
+
+	if d.beforeDelete != nil {
+		if err := d.beforeDelete(collection, resource); err != nil {
+			return err
+		}
+	}
+
+	path := filepath.Join(d.collectionPath(collection), d.fileName(resource))
+	//
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	if resource != "" {
+		single, err := d.singleFile(collection)
+		if err != nil {
+			return err
+		}
+		if single {
+			return d.deleteSingle(collection, resource)
+		}
+	}
+
+	if d.tombstones && resource != "" {
+		return d.bury(collection, resource)
+	}
+
+	dir := filepath.Join(d.dir, path)
+
+	switch fi, err := stat(dir); {
+	// nothing to remove
+	case errors.Is(err, fs.ErrNotExist):
+		return d.deleteMissing(path)
+	case err != nil:
+		return err
+	// remove directory and all contents
+	case fi.Mode().IsDir():
+		name := filepath.ToSlash(filepath.Join(collection, resource))
+		d.configs.forget(name)
+		d.schemas.forget(name)
+		d.forgetDirs(name)
+		d.blooms.forget(name)
+		d.overlay.drop(name, "")
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		d.replicate(name, "")
+		return nil
+	// remove file
+	default:
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		return d.recordChanged(collection, resource)
+	}
+}
+
+// stat describes the file or directory at [path], or the symlink itself when
+// it's a content-addressed record whose object has gone missing
+func stat(path string) (fi os.FileInfo, err error) {
+	if fi, err = os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		fi, err = os.Lstat(path)
+	}
+
+	return
+}
+
+// deleteMissing is what Delete returns for [path] when there is nothing there
+func (d *Driver) deleteMissing(path string) error {
+	if d.strictDelete {
+		return fmt.Errorf("%w: no file or directory named %v", ErrNotFound, path)
+	}
+
+	return nil
+}
+
+// recordChanged brings the collection's manifest, checksums, key files and
+// indexes up to date after [resource] was written or removed, bumps its
+// generation and queues the change for the replica; the caller holds the
+// collection lock
+func (d *Driver) recordChanged(collection, resource string) error {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	// what is on disk now is newer than anything pending
+	d.overlay.drop(collection, resource)
+	d.blooms.add(collection, resource)
+
+	// writers of other resources may be updating the same manifest and indexes
+	if d.resourceLevelLocking {
+		key := collection + "\x00"
+		d.resourceLocks.lock(key)
+		defer d.resourceLocks.unlock(key)
+	}
+
+	if err := d.updateManifest(collection, resource); err != nil {
+		return err
+	}
+
+	if err := d.updateChecksum(collection, resource); err != nil {
+		return err
+	}
+
+	if err := d.updateKey(collection, resource); err != nil {
+		return err
+	}
+
+	if err := d.updateIndexes(collection, resource); err != nil {
+		return err
+	}
+
+	if err := d.bumpGeneration(collection); err != nil {
+		return err
+	}
+
+	d.replicate(collection, resource)
+	return nil
+}
+
+// collectionPath returns the directory holding [collection], relative to
+// the database
+func (d *Driver) collectionPath(collection string) string {
+	collection = d.normalize(collection)
+	if d.encodeCollection == nil {
+		return collection
+	}
+
+	return d.encodeCollection(collection)
+}
+
+// collectionDir returns the directory holding [collection]
+func (d *Driver) collectionDir(collection string) string {
+	return filepath.Join(d.dir, d.collectionPath(collection))
+}
+
+// collectionName returns the collection stored in the directory [rel],
+// relative to the database, or false if it can't be decoded
+func (d *Driver) collectionName(rel string) (string, bool) {
+	rel = filepath.ToSlash(rel)
+	if d.decodeCollection == nil {
+		return rel, true
+	}
+
+	name, err := d.decodeCollection(rel)
+	return name, err == nil
+}
+
+// recordPath returns the path of the file holding [resource]
+func (d *Driver) recordPath(collection, resource string) string {
+	return filepath.Join(d.collectionDir(collection), d.fileName(resource))
+}
+
+// fileName returns the name of the file holding [resource]; a name too long
+// for the file system is stored under its hash
+func (d *Driver) fileName(resource string) string {
+	if resource == "" {
+		return ""
+	}
+
+	return storedName(d.encodedName(resource)) + d.ext
+}
+
+// encodedName returns [resource] as KeyEncoder encodes it, never hashed
+func (d *Driver) encodedName(resource string) string {
+	resource = d.normalize(resource)
+	if d.encodeKey != nil {
+		resource = d.encodeKey(resource)
+	}
+
+	return resource
+}
+
+// normalize returns the form of a collection or resource name used on disk
+// and as the key of everything the driver keeps per collection or record
+func (d *Driver) normalize(name string) string {
+	return foldName(d.caseInsensitiveKeys, name)
+}
+
+// foldName returns [name] in lower case if [fold] is set
+func foldName(fold bool, name string) string {
+	if fold {
+		return strings.ToLower(name)
+	}
+
+	return name
+}
+
+// resourceName returns the resource held by the file named [file] in the
+// collection directory [dir], or false if the name doesn't decode to one. A
+// hashed name is looked up in its key file, so it's only found with [dir].
+func (d *Driver) resourceName(dir, file string) (string, bool) {
+	name := strings.TrimSuffix(file, d.ext)
+	if strings.HasPrefix(name, hashedPrefix) {
+		return hashedResource(dir, file)
+	}
+
+	if d.decodeKey == nil {
+		return name, true
+	}
+
+	decoded, err := d.decodeKey(name)
+	if err != nil {
+		return "", false
+	}
+
+	return decoded, true
+}
+
+// recordName returns the resource held by a directory entry, or false if the
+// entry isn't a record
+func (d *Driver) recordName(dir string, entry os.DirEntry) (string, bool) {
+	if !d.isRecord(entry) && !d.followed(dir, entry) {
+		return "", false
+	}
+
+	return d.resourceName(dir, entry.Name())
+}
+
+// isRecord reports whether a directory entry holds a record, as opposed to a
+// subdirectory, a hidden file or a temp file left by an in-flight write
+func (d *Driver) isRecord(entry os.DirEntry) bool {
+	if !d.isRecordName(entry.Name()) {
+		return false
+	}
+
+	// content addressed records are symlinks to their objects
+	if d.contentAddressed && entry.Type()&os.ModeSymlink != 0 {
+		return true
+	}
+
+	return entry.Type().IsRegular()
+}
+
+// isRecordName reports whether a file named [name] may hold a record
+func (d *Driver) isRecordName(name string) bool {
+	return !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, tmpSuffix) && strings.HasSuffix(name, d.ext) &&
+		!strings.HasSuffix(name, blobSuffix) && !strings.HasSuffix(name, sumSuffix) && !strings.HasSuffix(name, keySuffix) &&
+		name != manifestName
+}
+
+// readFile reads the raw bytes of a record from disk, falling back to the
+// seed records when it doesn't exist there
+func (d *Driver) readFile(collection, resource string) ([]byte, error) {
+	if err := d.checkNames(collection, resource); err != nil {
+		return nil, err
+	}
+
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return nil, err
+	}
+	if single {
+		return d.readSingle(collection, resource)
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, resource))
+	if err == nil {
+		if b, err = d.verifyChecksum(collection, resource, b); err != nil {
+			return nil, err
+		}
+		return d.decode(collection, b)
+	}
+
+	if d.source == nil || !errors.Is(err, fs.ErrNotExist) || d.buried(collection, resource) {
+		return nil, err
+	}
+
+	if seed, serr := fs.ReadFile(d.source, path.Join(d.collectionPath(collection), d.fileName(resource))); serr == nil {
+		return d.decode(collection, seed)
+	}
+
+	return nil, err
+}
+
+// list returns the sorted resource names of the records in [collection],
+// including the seed records that only exist in the source fs
+func (d *Driver) list(collection string) ([]string, error) {
+	names, err := d.listLexical(collection)
+	if d.keyLess != nil {
+		d.sortNames(names)
+	}
+
+	return names, err
+}
+
+// sortNames sorts resource names in the driver's order
+func (d *Driver) sortNames(names []string) {
+	if d.keyLess == nil {
+		sort.Strings(names)
+		return
+	}
+
+	sort.SliceStable(names, func(i, j int) bool { return d.keyLess(names[i], names[j]) })
+}
+
+// less reports whether the resource name [a] sorts before [b]
+func (d *Driver) less(a, b string) bool {
+	if d.keyLess == nil {
+		return a < b
+	}
+
+	return d.keyLess(a, b)
+}
+
+// listLexical is list in lexical order
+func (d *Driver) listLexical(collection string) ([]string, error) {
+	if err := d.checkNames(collection, ""); err != nil {
+		return nil, err
+	}
+
+	names, err := d.listDisk(collection)
+	if d.source == nil || (err != nil && !errors.Is(err, fs.ErrNotExist)) {
+		return names, err
+	}
+
+	seeds, serr := fs.ReadDir(d.source, d.collectionPath(collection))
+	if serr != nil {
+		// a collection that exists in neither place keeps the disk error
+		return names, err
+	}
+
+	// deleted seeds stay hidden behind their tombstones
+	var live []string
+	for _, name := range d.recordNames("", seeds) {
+		if !d.buried(collection, name) {
+			live = append(live, name)
+		}
+	}
+
+	return mergeNames(names, live), nil
+}
+
+// listDisk returns the sorted resource names of the records on disk, from the
+// collection's manifest when one is maintained
+func (d *Driver) listDisk(collection string) ([]string, error) {
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return nil, err
+	}
+	if single {
+		return d.listSingle(collection)
+	}
+
+	if d.manifest {
+		m, err := readManifest(d.collectionDir(collection))
+		if err == nil {
+			return m.names(), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	dir := d.collectionDir(collection)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if err := d.checkNonRegular(dir, file); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.recordNames(dir, files), nil
+}
+
+// recordNames returns the resource names of the records among [files]
+func (d *Driver) recordNames(dir string, files []fs.DirEntry) []string {
+	var names []string
+	for _, file := range files {
+		if name, ok := d.recordName(dir, file); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// mergeNames returns the sorted union of two sorted lists of names
+func mergeNames(a, b []string) []string {
+	merged := make([]string, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			merged, a = append(merged, a[0]), a[1:]
+		case a[0] > b[0]:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			merged, a, b = append(merged, a[0]), a[1:], b[1:]
+		}
+	}
+
+	return append(append(merged, a...), b...)
+}
+
+// getOrCreateMutex creates a new collection specific mutex any time a collection
+// is being modified to avoid unsafe operations
+func (d *Driver) getOrCreateMutex(collection string) *sync.RWMutex {
+	collection = d.normalize(collection)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	m, ok := d.mutexes[collection]
+	if !ok {
+		m = &sync.RWMutex{}
+		d.mutexes[collection] = m
+	}
+
+	return m
+}
+
+// ensureDir creates the directory of [collection] unless an earlier call
+// already did, sparing writes the MkdirAll syscalls
+func (d *Driver) ensureDir(collection string) error {
+	if err := d.checkNames(collection, ""); err != nil {
+		return err
+	}
+
+	key := filepath.ToSlash(filepath.Clean(d.normalize(collection)))
+
+	d.mutex.Lock()
+	known := d.dirs[key]
+	d.mutex.Unlock()
+
+	if known {
+		return nil
+	}
+
+	if err := os.MkdirAll(d.collectionDir(collection), dirMode); err != nil {
+		if cerr := d.collectionCollision(collection); cerr != nil {
+			return cerr
+		}
+		return err
+	}
+
+	d.mutex.Lock()
+	d.dirs[key] = true
+	d.mutex.Unlock()
+
+	return nil
+}
+
+// forgetDirs drops [collection] and the collections nested in it from the
+// known directories, so the next write creates them again
+func (d *Driver) forgetDirs(collection string) {
+	collection = filepath.ToSlash(filepath.Clean(d.normalize(collection)))
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for name := range d.dirs {
+		if name == collection || strings.HasPrefix(name, collection+"/") {
+			delete(d.dirs, name)
+		}
+	}
+}
+
+// lockCollections locks every named collection in sorted order, so callers
+// locking the same collections in a different order can't deadlock, and
+// returns a function that unlocks them again. Every name is checked before
+// anything is locked.
+func (d *Driver) lockCollections(names ...string) (unlock func(), err error) {
+	return d.lockSorted(false, names)
+}
+
+// rlockCollections is like lockCollections but only takes shared locks, so
+// readers of the same collections don't exclude each other
+func (d *Driver) rlockCollections(names ...string) (unlock func(), err error) {
+	return d.lockSorted(true, names)
+}
+
+func (d *Driver) lockSorted(shared bool, names []string) (unlock func(), err error) {
+	for _, name := range names {
+		if err := d.checkNames(name, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	// names differing only in case may share a mutex, which is locked once
+	sorted := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = d.normalize(name)
+		if !seen[name] {
+			seen[name] = true
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	mutexes := make([]*sync.RWMutex, 0, len(sorted))
+	for _, name := range sorted {
+		m := d.getOrCreateMutex(name)
+		if shared {
+			m.RLock()
+		} else {
+			m.Lock()
+		}
+		mutexes = append(mutexes, m)
+	}
+
+	return func() {
+		for i := len(mutexes) - 1; i >= 0; i-- {
+			if shared {
+				mutexes[i].RUnlock()
+			} else {
+				mutexes[i].Unlock()
+			}
+		}
+	}, nil
 }
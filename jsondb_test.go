@@ -1,9 +1,20 @@
 package jsondb
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type Fish struct {
@@ -56,6 +67,34 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewVerifyWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db")
+
+	// a fresh directory is writable
+	if _, err := New(dir, &Options{Debug: t.Logf, VerifyWritable: true}); err != nil {
+		t.Fatal("Expected writable database, got: ", err.Error())
+	}
+
+	// the probe must not leave anything behind
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Error("Expected empty database, got: ", len(files))
+	}
+
+	// permissions don't apply to root, so the failure can't be observed
+	if os.Geteuid() == 0 {
+		t.Skip("running as root")
+	}
+
+	if err := os.Chmod(dir, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, dirMode)
+
+	if _, err := New(dir, &Options{Debug: t.Logf, VerifyWritable: true}); err == nil {
+		t.Error("Expected error for read-only database, got nil")
+	}
+}
+
 func TestWriteAndRead(t *testing.T) {
 	createDB()
 
@@ -92,6 +131,102 @@ func TestReadall(t *testing.T) {
 	destroySchool()
 }
 
+func TestExtension(t *testing.T) {
+	d := newTestDB(t, &Options{Extension: ".json"})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the file on disk carries the extension
+	if _, err := os.Stat(filepath.Join(d.dir, collection, "redfish.json")); err != nil {
+		t.Error("Expected redfish.json on disk: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	// files without the extension aren't records
+	if err := os.WriteFile(filepath.Join(d.dir, collection, "notes.txt"), []byte("hi"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := d.list(collection)
+	if err != nil || len(names) != 1 || names[0] != "redfish" {
+		t.Error("Expected only redfish listed, got: ", names, err)
+	}
+
+	if records, err := d.ReadAll(collection); err != nil || len(records) != 1 {
+		t.Error("Expected 1 record, got: ", len(records), err)
+	}
+
+	if err := d.Delete(collection, "redfish"); err != nil {
+		t.Error("Failed to delete: ", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(d.dir, collection, "redfish.json")); err == nil {
+		t.Error("Expected redfish.json to be removed")
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	calls := 0
+	d := newTestDB(t, &Options{
+		// keep whatever was stored first
+		ResolveConflict: func(existing, incoming []byte) ([]byte, error) {
+			calls++
+			return existing, nil
+		},
+	})
+
+	if err := d.Write(collection, "fish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// no conflict on the first write
+	if calls != 0 {
+		t.Error("Expected no resolver call for a new record, got: ", calls)
+	}
+
+	if err := d.Write(collection, "fish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "fish", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected resolver to keep red fish, got: ", fish.Type, err)
+	}
+
+	if calls != 1 {
+		t.Error("Expected 1 resolver call, got: ", calls)
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	d := newTestDB(t, &Options{DisallowUnknownFields: true, UseNumber: true})
+
+	if err := d.Write(collection, "fish", map[string]interface{}{"type": "red", "weight": 9007199254740993}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// Fish has no weight field
+	if err := d.Read(collection, "fish", &Fish{}); err == nil {
+		t.Error("Expected unknown field error, got nil")
+	}
+
+	// large integers keep their precision
+	m := map[string]interface{}{}
+	if err := d.Read(collection, "fish", &m); err != nil {
+		t.Fatal("Failed to read: ", err.Error())
+	}
+
+	if n, ok := m["weight"].(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Error("Expected exact json.Number, got: ", m["weight"])
+	}
+}
+
 func TestWriteAndReadEmpty(t *testing.T) {
 	createDB()
 
@@ -134,6 +269,145 @@ func TestDelete(t *testing.T) {
 	destroySchool()
 }
 
+func TestReadWaitsForWrite(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a write in progress holds the collection lock
+	unlock, err := d.lockResource(collection, "red")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 2)
+	go func() { done <- d.Read(collection, "red", &Fish{}) }()
+	go func() {
+		_, err := d.ReadAll(collection)
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected the reads to wait for the write")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Error("Read failed: ", err.Error())
+		}
+	}
+
+	// readers don't exclude each other
+	runlock, err := d.rlockCollections(collection)
+	if err != nil {
+		t.Fatal("Failed to lock: ", err.Error())
+	}
+	defer runlock()
+	if err := d.Read(collection, "red", &Fish{}); err != nil {
+		t.Error("Expected a read beside another reader, got: ", err)
+	}
+}
+
+func TestReadWriteContext(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := d.WriteContext(cancelled, collection, "red", redfish); !errors.Is(err, context.Canceled) {
+		t.Error("Expected context.Canceled, got: ", err)
+	}
+	if _, err := os.Stat(d.recordPath(collection, "red")); !os.IsNotExist(err) {
+		t.Error("Expected the cancelled write to store nothing, got: ", err)
+	}
+
+	if err := d.WriteContext(context.Background(), collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.ReadContext(cancelled, collection, "red", &Fish{}); !errors.Is(err, context.Canceled) {
+		t.Error("Expected context.Canceled, got: ", err)
+	}
+
+	// a pending write or read gives up behind a long held lock
+	unlock, err := d.lockResource(collection, "red")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := d.WriteContext(ctx, collection, "red", Fish{Type: "blue"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected context.DeadlineExceeded, got: ", err)
+	}
+	if err := d.ReadContext(ctx, collection, "red", &Fish{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected context.DeadlineExceeded, got: ", err)
+	}
+}
+
+func TestDeleteMissing(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// deleting what isn't there is done already
+	if err := d.Delete(collection, "redfish"); err != nil {
+		t.Error("Expected deleting a missing record to succeed, got: ", err.Error())
+	}
+
+	if err := d.Delete("ocean", ""); err != nil {
+		t.Error("Expected deleting a missing collection to succeed, got: ", err.Error())
+	}
+
+	strict := newTestDB(t, &Options{StrictDelete: true})
+
+	if err := strict.Delete(collection, "redfish"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+
+	if err := strict.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := strict.Delete(collection, "redfish"); err != nil {
+		t.Error("Failed to delete: ", err.Error())
+	}
+
+	tombstones := newTestDB(t, &Options{Tombstones: true, StrictDelete: true})
+
+	if err := tombstones.Delete(collection, "redfish"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound with tombstones, got: ", err)
+	}
+}
+
+func TestDeleteStatError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("running as root")
+	}
+
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a record that can't be looked up isn't reported missing
+	dir := d.collectionDir(collection)
+	if err := os.Chmod(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, dirMode)
+
+	err := d.Delete(collection, "redfish")
+	if !errors.Is(err, fs.ErrPermission) || errors.Is(err, ErrNotFound) {
+		t.Error("Expected a permission error, got: ", err)
+	}
+}
+
 func TestDeleteall(t *testing.T) {
 	createDB()
 	createSchool()
@@ -149,6 +423,29 @@ func TestDeleteall(t *testing.T) {
 	destroySchool()
 }
 
+func TestLockCollections(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// locking the same pair in opposite orders must not deadlock
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if unlock, err := d.lockCollections("fish", "birds"); err == nil {
+				unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if unlock, err := d.lockCollections("birds", "fish", "birds"); err == nil {
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 // create a new json database
 func createDB() error {
 	var err error
@@ -184,3 +481,321 @@ func destroyFish(name string) error {
 func destroySchool() error {
 	return db.Delete(collection, "")
 }
+
+// create a json database in a temporary directory with the given options
+func newTestDB(t *testing.T, opts *Options) *Driver {
+	t.Helper()
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	if opts.Debug == nil {
+		opts.Debug = t.Logf
+	}
+
+	d, err := New(filepath.Join(t.TempDir(), "db"), opts)
+	if err != nil {
+		t.Fatal("Failed to create database: ", err.Error())
+	}
+
+	return d
+}
+
+func TestKnownDirs(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write("ocean/"+collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if !d.dirs["ocean/"+collection] {
+		t.Error("Expected the collection directory to be remembered")
+	}
+
+	// deleting a parent collection forgets the nested directories too
+	if err := d.Delete("ocean", ""); err != nil {
+		t.Fatal("Delete ocean failed: ", err.Error())
+	}
+	if len(d.dirs) != 0 {
+		t.Error("Expected no known directories after delete, got: ", d.dirs)
+	}
+
+	if err := d.Write("ocean/"+collection, "red", redfish); err != nil {
+		t.Fatal("Create fish after delete failed: ", err.Error())
+	}
+
+	// a directory removed behind the driver's back is created again
+	if err := os.RemoveAll(filepath.Join(d.dir, "ocean")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Write("ocean/"+collection, "red", redfish); err != nil {
+		t.Error("Create fish after external removal failed: ", err.Error())
+	}
+}
+
+func BenchmarkKnownDirs(b *testing.B) {
+	for _, known := range []bool{true, false} {
+		known := known
+		b.Run(fmt.Sprintf("known=%v", known), func(b *testing.B) {
+			d, err := New(b.TempDir(), &Options{Debug: b.Logf})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			for i := 0; i < b.N; i++ {
+				// forgetting the directory makes every write create it again
+				if !known {
+					d.forgetDirs(collection)
+				}
+				if err := d.Write(collection, strconv.Itoa(i%1000), redfish); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDurable(t *testing.T) {
+	for _, opts := range []*Options{{Durable: true}, {Durable: true, ContentAddressed: true}} {
+		d := newTestDB(t, opts)
+
+		if err := d.Write(collection, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		fish := Fish{}
+		if err := d.Read(collection, "red", &fish); err != nil || fish != redfish {
+			t.Error("Expected redfish, got: ", fish, err)
+		}
+
+		if _, err := os.Stat(d.recordPath(collection, "red") + tmpSuffix); !os.IsNotExist(err) {
+			t.Error("Expected no temp file left, got: ", err)
+		}
+
+		// batches and streamed records take the sync path too
+		if err := d.WriteAll(collection, map[string]interface{}{"blue": Fish{Type: "blue"}}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+		if err := d.WriteFrom(collection, "green", strings.NewReader(`{"type":"green"}`)); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+		if err := d.Delete(collection, "red"); err != nil {
+			t.Fatal("Delete fish failed: ", err.Error())
+		}
+
+		names, err := d.Keys(collection)
+		if err != nil || !reflect.DeepEqual(names, []string{"blue", "green"}) {
+			t.Error("Expected blue and green, got: ", names, err)
+		}
+	}
+}
+
+func TestReadEmptyRecord(t *testing.T) {
+	for _, emptyAsNotFound := range []bool{false, true} {
+		d := newTestDB(t, &Options{EmptyAsNotFound: emptyAsNotFound})
+
+		if err := d.Write(collection, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+		if err := os.Truncate(d.recordPath(collection, "red"), 0); err != nil {
+			t.Fatal(err)
+		}
+
+		err := d.Read(collection, "red", &Fish{})
+		if !emptyAsNotFound && !errors.Is(err, ErrEmptyRecord) {
+			t.Error("Expected ErrEmptyRecord, got: ", err)
+		}
+		if emptyAsNotFound && !errors.Is(err, fs.ErrNotExist) {
+			t.Error("Expected a missing record, got: ", err)
+		}
+	}
+}
+
+type stampedFish struct {
+	Type    string `json:"type"`
+	Updated string `json:"updated"`
+}
+
+func TestBeforeWrite(t *testing.T) {
+	d := newTestDB(t, &Options{
+		BeforeWrite: func(collection, resource string, v interface{}) (interface{}, error) {
+			switch f := v.(type) {
+			case stampedFish:
+				f.Updated = collection + "/" + resource
+				return f, nil
+			case Fish:
+				if f.Type == "" {
+					return nil, errors.New("fish without a type")
+				}
+			}
+			return nil, nil
+		},
+	})
+
+	if err := d.Write(collection, "red", stampedFish{Type: "red"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	stamped := stampedFish{}
+	if err := d.Read(collection, "red", &stamped); err != nil || stamped.Updated != "fish/red" {
+		t.Error("Expected the hook to stamp the fish, got: ", stamped, err)
+	}
+
+	// nil passes the value through
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Write(collection, "none", Fish{}); err == nil {
+		t.Error("Expected the hook's error to abort the write")
+	}
+	if err := d.Read(collection, "none", &Fish{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected the aborted record not to exist, got: ", err)
+	}
+}
+
+func TestAfterWrite(t *testing.T) {
+	var d *Driver
+	var written []string
+	d = newTestDB(t, &Options{
+		Durable: true,
+		AfterWrite: func(collection, resource string, raw []byte) {
+			// the record is already stored when the hook runs
+			if _, err := os.Stat(d.recordPath(collection, resource)); err != nil {
+				t.Error("Expected the record on disk, got: ", err)
+			}
+			written = append(written, collection+"/"+resource+" "+string(raw))
+		},
+	})
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{Compress: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.WriteFrom(collection, "blue", strings.NewReader(`{"type":"blue"}`)); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a failed write never reaches the hook
+	if err := d.Write(collection+"/school", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write(collection, "school", redfish); !errors.Is(err, ErrNameCollision) {
+		t.Error("Expected ErrNameCollision, got: ", err)
+	}
+
+	want := []string{`fish/red {"type":"red"}`, `fish/blue {"type":"blue"}`, `fish/school/red {"type":"red"}`}
+	if !reflect.DeepEqual(written, want) {
+		t.Error("Expected ", want, ", got: ", written)
+	}
+}
+
+func TestNameCollision(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write("ocean", "fish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the record ocean/fish is in the way of the collection ocean/fish
+	if err := d.Write("ocean/fish", "red", redfish); !errors.Is(err, ErrNameCollision) {
+		t.Error("Expected ErrNameCollision, got: ", err)
+	}
+
+	if err := d.Write("ocean/school", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// and the collection ocean/school is in the way of the record
+	if err := d.Write("ocean", "school", redfish); !errors.Is(err, ErrNameCollision) {
+		t.Error("Expected ErrNameCollision, got: ", err)
+	}
+
+	if _, err := os.Stat(d.recordPath("ocean", "school") + tmpSuffix); !os.IsNotExist(err) {
+		t.Error("Expected no temp file left behind, got: ", err)
+	}
+}
+
+func TestReadAllVanished(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a record deleted between listing and reading is left out
+	names, records, err := d.readAll(collection, []string{"gone", "red"})
+	if err != nil {
+		t.Fatal("readAll failed: ", err.Error())
+	}
+	if len(names) != 1 || names[0] != "red" || len(records) != 1 || string(records[0]) != `{"type":"red"}` {
+		t.Error("Expected only the red fish, got: ", names, records)
+	}
+
+	// other failures still fail the read
+	if err := os.Mkdir(d.recordPath(collection, "dir"), 0755); err != nil {
+		t.Fatal("Mkdir failed: ", err.Error())
+	}
+	if _, _, err := d.readAll(collection, []string{"dir", "red"}); err == nil {
+		t.Error("Expected an error reading a directory")
+	}
+}
+
+func TestCopyReplace(t *testing.T) {
+	dir := t.TempDir()
+	tmp, dst := filepath.Join(dir, "red"+tmpSuffix), filepath.Join(dir, "red")
+
+	if err := os.WriteFile(dst, []byte(`{"type":"old"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmp, []byte(`{"type":"red"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the cross device fallback replaces the record and cleans up
+	if err := copyReplace(tmp, dst); err != nil {
+		t.Fatal("copyReplace failed: ", err.Error())
+	}
+
+	if b, err := os.ReadFile(dst); err != nil || string(b) != `{"type":"red"}` {
+		t.Error("Expected the copied record, got: ", string(b), err)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Error("Expected the temp file removed, got: ", err)
+	}
+}
+
+func TestBeforeDelete(t *testing.T) {
+	protected := errors.New("protected")
+	d := newTestDB(t, &Options{BeforeDelete: func(collection, resource string) error {
+		if resource == "red" {
+			return protected
+		}
+		return nil
+	}})
+
+	for _, name := range []string{"red", "blue", "green"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.Delete(collection, "red"); !errors.Is(err, protected) {
+		t.Error("Expected the protected fish to stay, got: ", err)
+	}
+	if err := d.Delete(collection, "blue"); err != nil {
+		t.Error("Failed to delete: ", err.Error())
+	}
+
+	// one protected record keeps the whole batch
+	if deleted, err := d.DeleteMany(collection, []string{"green", "red"}); !errors.Is(err, protected) || deleted != 0 {
+		t.Error("Expected the batch refused, got: ", deleted, err)
+	}
+	if err := d.Read(collection, "green", &Fish{}); err != nil {
+		t.Error("Expected the green fish to stay, got: ", err)
+	}
+}
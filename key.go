@@ -0,0 +1,56 @@
+package jsondb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PercentEncodeKey is a KeyEncoder that percent-encodes every byte of a key
+// other than ASCII letters, digits, '-', '_', '~' and non-leading '.', so any
+// string makes a safe filename
+func PercentEncodeKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if isUnreservedKeyByte(c) || (c == '.' && i > 0) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+
+	return sb.String()
+}
+
+// PercentDecodeKey is the KeyDecoder reversing PercentEncodeKey
+func PercentDecodeKey(name string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if i+2 >= len(name) {
+			return "", fmt.Errorf("invalid escape in key %q", name)
+		}
+
+		b, err := strconv.ParseUint(name[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in key %q", name)
+		}
+
+		sb.WriteByte(byte(b))
+		i += 2
+	}
+
+	return sb.String(), nil
+}
+
+func isUnreservedKeyByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '~'
+}
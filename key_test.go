@@ -0,0 +1,199 @@
+package jsondb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPercentKey(t *testing.T) {
+	for _, key := range []string{"plain", "bob@example.com", "http://x/y?z", "..", ".hidden", "50%", "ünï"} {
+		name := PercentEncodeKey(key)
+		if filepath.Base(name) != name || name == ".." || name[0] == '.' {
+			t.Errorf("Unsafe filename %q for key %q", name, key)
+		}
+
+		decoded, err := PercentDecodeKey(name)
+		if err != nil || decoded != key {
+			t.Errorf("Expected %q back, got: %q %v", key, decoded, err)
+		}
+	}
+
+	if _, err := PercentDecodeKey("bad%4"); err == nil {
+		t.Error("Expected error for truncated escape")
+	}
+}
+
+func TestKeyEncoder(t *testing.T) {
+	d := newTestDB(t, &Options{KeyEncoder: PercentEncodeKey, KeyDecoder: PercentDecodeKey})
+
+	key := "http://fish.example/red"
+	if err := d.Write(collection, key, redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(d.dir, collection, PercentEncodeKey(key))); err != nil {
+		t.Error("Expected encoded filename on disk: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, key, &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	if names, err := d.list(collection); err != nil || fmt.Sprint(names) != "["+key+"]" {
+		t.Error("Expected decoded key listed, got: ", names, err)
+	}
+
+	if err := d.Delete(collection, key); err != nil {
+		t.Error("Failed to delete: ", err.Error())
+	}
+}
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	d := newTestDB(t, &Options{CaseInsensitiveKeys: true})
+
+	if err := d.Write("Fish", "Bob", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read("fish", "BOB", &fish); err != nil || fish != redfish {
+		t.Error("Expected to read Bob as BOB, got: ", fish, err)
+	}
+
+	names, err := d.list("fish")
+	if err != nil || len(names) != 1 || names[0] != "bob" {
+		t.Error("Expected the lower cased name listed, got: ", names, err)
+	}
+
+	if records, err := d.ReadAll("FISH"); err != nil || len(records) != 1 {
+		t.Error("Expected 1 record, got: ", len(records), err)
+	}
+
+	if err := d.Delete("fIsH", "bOb"); err != nil {
+		t.Error("Delete fish failed: ", err.Error())
+	}
+}
+
+func TestCaseInsensitiveKeysEverywhere(t *testing.T) {
+	d := newTestDB(t, &Options{CaseInsensitiveKeys: true, CacheTTL: time.Minute})
+
+	if err := d.Write("fish", "bob", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// bob is the record written, so it's kept and only al goes
+	if err := d.Write("fish", "al", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.WriteAll("Fish", map[string]interface{}{"BOB": Fish{Type: "blue"}}); err != nil {
+		t.Fatal("Failed to write all: ", err.Error())
+	}
+	if names, err := d.list("fish"); err != nil || len(names) != 1 || names[0] != "bob" {
+		t.Error("Expected only bob left, got: ", names, err)
+	}
+
+	// the write under another case drops the record cached
+	fish := Fish{}
+	if err := d.Read("fish", "bob", &fish); err != nil || fish.Type != "blue" {
+		t.Fatal("Expected blue fish, got: ", fish, err)
+	}
+	if err := d.Write("FISH", "Bob", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Read("fish", "bob", &fish); err != nil || fish != redfish {
+		t.Error("Expected red fish, got: ", fish, err)
+	}
+
+	// a record swapped with itself is left as it is
+	if err := d.Swap("FISH", "bob", "BOB"); err != nil {
+		t.Error("Failed to swap: ", err.Error())
+	}
+
+	read := Fish{}
+	if missing, err := d.ReadInto("Fish", map[string]interface{}{"BoB": &read}); err != nil || len(missing) != 0 || read != redfish {
+		t.Error("Expected to read BoB into red fish, got: ", read, missing, err)
+	}
+
+	if found, ok, err := FindFirst(d, "FISH", func(f Fish) bool { return f.Type == "red" }); err != nil || !ok || found != redfish {
+		t.Error("Expected to find red fish, got: ", found, ok, err)
+	}
+
+	if seeded, err := d.SeedOnce("FiSh", map[string]interface{}{"al": redfish}); err != nil || seeded {
+		t.Error("Expected the collection to be seeded already, got: ", seeded, err)
+	}
+
+	if _, err := d.Counter("Counters", "Hits").Inc(2); err != nil {
+		t.Fatal("Failed to increment: ", err.Error())
+	}
+	if n, err := d.Counter("counters", "HITS").Get(); err != nil || n != 2 {
+		t.Error("Expected 2 hits, got: ", n, err)
+	}
+
+	if deleted, err := d.ApplyRetention("FISH", RetentionPolicy{MaxRecords: 1}); err != nil || deleted != 0 {
+		t.Error("Expected nothing to delete, got: ", deleted, err)
+	}
+}
+
+func TestCaseInsensitiveLockCollections(t *testing.T) {
+	d := newTestDB(t, &Options{CaseInsensitiveKeys: true})
+
+	if err := d.Write("fish", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// both names are the same collection, whose mutex is only locked once
+	done := make(chan error, 1)
+	go func() {
+		unlock, err := d.lockCollections("Fish", "fish")
+		if err != nil {
+			done <- err
+			return
+		}
+		unlock()
+
+		_, _, _, err = d.DiffCollections("Fish", "fish")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("Expected the collections locked once, got: ", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Locking the same collection under two cases deadlocked")
+	}
+}
+
+func TestCollectionEncoder(t *testing.T) {
+	d := newTestDB(t, &Options{CollectionEncoder: PercentEncodeKey, CollectionDecoder: PercentDecodeKey})
+
+	// the slash is part of the name rather than nesting a collection
+	name := "tanks/big one"
+	if err := d.Write(name, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(d.dir, "tanks%2Fbig%20one", "red")); err != nil {
+		t.Error("Expected the encoded directory, got: ", err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(name, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	collections, err := d.allCollections()
+	if err != nil || len(collections) != 1 || collections[0] != name {
+		t.Error("Expected the decoded collection, got: ", collections, err)
+	}
+
+	stats, err := d.DatabaseStats()
+	if err != nil || stats.PerCollection[name] != 1 {
+		t.Error("Expected the record counted under the decoded name, got: ", stats.PerCollection, err)
+	}
+}
@@ -0,0 +1,70 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// keySuffix is appended to the file name of a record to name the file
+// holding its resource name
+const keySuffix = ".key"
+
+// ErrKeyCollision is returned when a write would overwrite a record stored
+// under another resource name that maps to the same file
+var ErrKeyCollision = errors.New("key collision - another resource is stored in the same file")
+
+// keyPath returns the path of the file holding the resource name of
+// [resource]
+func (d *Driver) keyPath(collection, resource string) string {
+	return d.recordPath(collection, resource) + keySuffix
+}
+
+// checkKey returns an ErrKeyCollision if the file of [resource] holds
+// another resource, if collisions are detected or the file is named by a
+// hash; records stored without their name can't be told apart and pass
+func (d *Driver) checkKey(collection, resource string) error {
+	if !d.detectKeyCollisions && !d.isHashed(resource) {
+		return nil
+	}
+
+	b, err := os.ReadFile(d.keyPath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if stored := string(b); stored != resource {
+		return fmt.Errorf("%w: %s and %s are both stored as %s", ErrKeyCollision, stored, resource, d.fileName(resource))
+	}
+
+	return nil
+}
+
+// updateKey stores the resource name of [resource] next to it, or removes it
+// along with the record, if collisions are detected or the file is named by
+// a hash, which can't be listed without it; the caller holds the collection
+// lock
+func (d *Driver) updateKey(collection, resource string) error {
+	if !d.detectKeyCollisions && !d.isHashed(resource) {
+		return nil
+	}
+
+	path := d.keyPath(collection, resource)
+
+	if _, err := os.Lstat(d.recordPath(collection, resource)); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	if b, err := os.ReadFile(path); err == nil && string(b) == resource {
+		return nil
+	}
+
+	return writeFileAtomic(path, []byte(resource))
+}
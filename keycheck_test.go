@@ -0,0 +1,56 @@
+package jsondb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDetectKeyCollisions(t *testing.T) {
+	// a lossy encoder mapping every name to its first three letters
+	truncate := func(name string) string {
+		if len(name) > 3 {
+			return name[:3]
+		}
+		return name
+	}
+
+	d := newTestDB(t, &Options{KeyEncoder: truncate, DetectKeyCollisions: true})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// writing the same record again is no collision
+	if err := d.Write(collection, "redfish", Fish{Type: "crimson"}); err != nil {
+		t.Error("Expected the record to be rewritten, got: ", err)
+	}
+
+	if err := d.Write(collection, "redtail", Fish{Type: "tail"}); !errors.Is(err, ErrKeyCollision) {
+		t.Error("Expected ErrKeyCollision, got: ", err)
+	}
+
+	if err := d.WriteFrom(collection, "redtail", strings.NewReader(`{"type":"tail"}`)); !errors.Is(err, ErrKeyCollision) {
+		t.Error("Expected ErrKeyCollision when streaming, got: ", err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "redfish", &fish); err != nil || fish.Type != "crimson" {
+		t.Error("Expected the record to be kept, got: ", fish.Type, err)
+	}
+
+	// the key files aren't records
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 1 {
+		t.Error("Expected 1 record, got: ", len(records), err)
+	}
+
+	// once the record is deleted its file is free again
+	if err := d.Delete(collection, "redfish"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	if err := d.Write(collection, "redtail", Fish{Type: "tail"}); err != nil {
+		t.Error("Expected the freed file to be written, got: ", err)
+	}
+}
@@ -0,0 +1,37 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Keys returns the resource names of the records of [collection] in the
+// driver's order, without reading any record, so callers can decide what to
+// read. Temp files of writes in progress are left out and records a
+// BufferedWriter hasn't stored yet are included; a collection that doesn't
+// exist has no keys.
+func (d *Driver) Keys(collection string) (keys []string, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "keys", collection, "")
+
+	// ensure there is a collection to list
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if pending := d.overlay.merge(collection, names); len(pending) != len(names) {
+		names = pending
+		d.sortNames(names)
+	}
+
+	if names == nil {
+		names = []string{}
+	}
+	return names, nil
+}
@@ -0,0 +1,42 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// a missing collection has no keys
+	keys, err := d.Keys(collection)
+	if err != nil || keys == nil || len(keys) != 0 {
+		t.Error("Expected no keys, got: ", keys, err)
+	}
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+	if err := os.WriteFile(d.recordPath(collection, "green")+tmpSuffix, []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = d.Keys(collection)
+	if err != nil {
+		t.Fatal("Keys failed: ", err.Error())
+	}
+	if want := []string{"blue", "red"}; !reflect.DeepEqual(keys, want) {
+		t.Error("Expected ", want, ", got: ", keys)
+	}
+
+	if _, err := d.Keys("../outside"); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+	if _, err := d.Exists(collection, "../../red"); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+}
@@ -0,0 +1,71 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// ReadLatest returns the [n] most recently modified records of a collection
+// keyed by resource, or all of them when it has fewer. Only those records are
+// read; the others are merely looked up.
+func (d *Driver) ReadLatest(collection string, n int) (records map[string][]byte, err error) {
+	defer wrapOpError(&err, "readlatest", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	type record struct {
+		name    string
+		modTime time.Time
+	}
+
+	latest := make([]record, 0, len(names))
+	for _, name := range names {
+		info, err := d.statRecord(collection, name)
+		if errors.Is(err, ErrNotFound) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "stat", Collection: collection, Resource: name, Err: err}
+		}
+
+		latest = append(latest, record{name: name, modTime: info.ModTime()})
+	}
+
+	// newest first; names break ties so the result is deterministic
+	sort.Slice(latest, func(i, j int) bool {
+		if !latest[i].modTime.Equal(latest[j].modTime) {
+			return latest[i].modTime.After(latest[j].modTime)
+		}
+		return latest[i].name < latest[j].name
+	})
+
+	records = make(map[string][]byte)
+	for _, r := range latest {
+		if len(records) >= n {
+			break
+		}
+
+		b, err := d.read(collection, r.name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: r.name, Err: err}
+		}
+
+		records[r.name] = d.own(b)
+	}
+
+	return records, nil
+}
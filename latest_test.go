@@ -0,0 +1,40 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadLatest(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	now := time.Now()
+	ages := map[string]time.Duration{"old": 3 * time.Hour, "new": time.Minute, "middle": time.Hour}
+	for name, age := range ages {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		modTime := now.Add(-age)
+		if err := os.Chtimes(d.recordPath(collection, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := d.ReadLatest(collection, 2)
+	if err != nil || len(records) != 2 {
+		t.Fatal("Expected 2 records, got: ", records, err)
+	}
+	if _, ok := records["old"]; ok {
+		t.Error("Expected the oldest record to be left out, got: ", records)
+	}
+
+	if records, err := d.ReadLatest(collection, 10); err != nil || len(records) != 3 {
+		t.Error("Expected all 3 records, got: ", records, err)
+	}
+
+	if records, err := d.ReadLatest("missing", 10); err != nil || len(records) != 0 {
+		t.Error("Expected no records from a missing collection, got: ", records, err)
+	}
+}
@@ -0,0 +1,53 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNotDatabase is returned by New when Options.ExpectEmptyOrDatabase is set
+// and the directory doesn't look like a jsondb database
+var ErrNotDatabase = errors.New("not a jsondb database")
+
+// checkLayout makes sure the database directory holds nothing but
+// collection directories and the hidden directories jsondb creates itself
+func (d *Driver) checkLayout() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		// probes interrupted by a crash are left behind by VerifyWritable
+		if strings.HasPrefix(name, ".jsondb-probe-") {
+			continue
+		}
+
+		if !entry.IsDir() {
+			return fmt.Errorf("%w: '%s' holds the file %s", ErrNotDatabase, d.dir, name)
+		}
+
+		if strings.HasPrefix(name, ".") {
+			if !isHiddenDir(name) {
+				return fmt.Errorf("%w: '%s' holds the directory %s", ErrNotDatabase, d.dir, name)
+			}
+			continue
+		}
+
+		if _, ok := d.collectionName(name); !ok {
+			return fmt.Errorf("%w: '%s' holds the directory %s, which isn't a collection", ErrNotDatabase, d.dir, name)
+		}
+	}
+
+	return nil
+}
+
+// isHiddenDir reports whether [name] is a hidden directory jsondb creates at
+// the top of a database
+func isHiddenDir(name string) bool {
+	return name == objectsDir || strings.HasPrefix(name, snapshotPrefix)
+}
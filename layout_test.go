@@ -0,0 +1,47 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpectEmptyOrDatabase(t *testing.T) {
+	d := newTestDB(t, &Options{ContentAddressed: true})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	opts := &Options{ExpectEmptyOrDatabase: true, Debug: t.Logf}
+
+	// collections and the objects directory are what a database holds
+	if _, err := New(d.dir, opts); err != nil {
+		t.Fatal("Expected the database to be opened, got: ", err.Error())
+	}
+
+	if _, err := New(filepath.Join(t.TempDir(), "new"), opts); err != nil {
+		t.Fatal("Expected a new database to be created, got: ", err.Error())
+	}
+
+	// a stray file means the directory is something else
+	if err := os.WriteFile(filepath.Join(d.dir, ".bashrc"), []byte("# shell\n"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(d.dir, opts); !errors.Is(err, ErrNotDatabase) {
+		t.Error("Expected ErrNotDatabase, got: ", err)
+	}
+}
+
+func TestExpectEmptyOrDatabaseHiddenDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".cache"), dirMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(dir, &Options{ExpectEmptyOrDatabase: true, Debug: t.Logf}); !errors.Is(err, ErrNotDatabase) {
+		t.Error("Expected ErrNotDatabase, got: ", err)
+	}
+}
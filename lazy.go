@@ -0,0 +1,26 @@
+package jsondb
+
+// Lazy returns one decode function per record in the collection, in sorted
+// resource order. Nothing is read until a function is called, at which point
+// that record is read and unmarshaled into [out] just like Read would.
+func (d *Driver) Lazy(collection string) ([]func(out interface{}) error, error) {
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	decoders := make([]func(out interface{}) error, 0, len(names))
+	for _, name := range names {
+		name := name
+		decoders = append(decoders, func(out interface{}) error {
+			return d.Read(collection, name, out)
+		})
+	}
+
+	return decoders, nil
+}
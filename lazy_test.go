@@ -0,0 +1,40 @@
+package jsondb
+
+import (
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "blue"}} {
+		if err := d.Write(collection, f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	decoders, err := d.Lazy(collection)
+	if err != nil {
+		t.Fatal("Failed to list: ", err.Error())
+	}
+
+	if len(decoders) != 2 {
+		t.Fatal("Expected 2 decoders, got: ", len(decoders))
+	}
+
+	// resources are in sorted order, so blue comes first
+	for i, want := range []string{"blue", "red"} {
+		fish := Fish{}
+		if err := decoders[i](&fish); err != nil {
+			t.Fatal("Failed to decode: ", err.Error())
+		}
+
+		if fish.Type != want {
+			t.Errorf("Expected %s fish, got: %s", want, fish.Type)
+		}
+	}
+
+	if _, err := d.Lazy(""); err != ErrMissingCollection {
+		t.Error("Expected ErrMissingCollection, got: ", err)
+	}
+}
@@ -0,0 +1,153 @@
+package jsondb
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrLockTimeout is returned when a lock isn't acquired within
+// Options.LockTimeout
+var ErrLockTimeout = errors.New("lock timeout - the collection is busy")
+
+// keyedMutex hands out a mutex per key, dropping each one again once nobody
+// holds or waits for it so the map doesn't grow without bound
+type keyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*refMutex
+}
+
+type refMutex struct {
+	sync.Mutex
+	refs int
+}
+
+func (k *keyedMutex) lock(key string) {
+	k.mutex.Lock()
+	m, ok := k.locks[key]
+	if !ok {
+		m = &refMutex{}
+		k.locks[key] = m
+	}
+	m.refs++
+	k.mutex.Unlock()
+
+	m.Lock()
+}
+
+func (k *keyedMutex) unlock(key string) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	m := k.locks[key]
+	m.Unlock()
+
+	if m.refs--; m.refs == 0 {
+		delete(k.locks, key)
+	}
+}
+
+// lockResource locks [resource] for writing and returns a function unlocking
+// it. Unless resource level locking is on, or [resource] is empty, this locks
+// the whole collection; otherwise the collection is only held shared, so
+// collection wide operations still wait for it. With a lock timeout it gives
+// up with ErrLockTimeout once the timeout passes.
+func (d *Driver) lockResource(collection, resource string) (unlock func(), err error) {
+	return d.lockResourceContext(context.Background(), collection, resource)
+}
+
+// lockResourceContext is lockResource giving up with the context's error as
+// soon as [ctx] is done
+func (d *Driver) lockResourceContext(ctx context.Context, collection, resource string) (unlock func(), err error) {
+	// every write and delete locks what it changes, so names are checked here
+	if err := d.checkNames(collection, resource); err != nil {
+		return nil, err
+	}
+
+	lockCtx := ctx
+	if d.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, d.lockTimeout)
+		defer cancel()
+	}
+
+	// the caller's context ending takes precedence over the lock timeout
+	failed := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return ErrLockTimeout
+	}
+
+	m := d.getOrCreateMutex(collection)
+	if !d.resourceLevelLocking || resource == "" {
+		if !lockWithin(lockCtx, m.TryLock, m.Lock, m.Unlock) {
+			return nil, failed()
+		}
+		return m.Unlock, nil
+	}
+
+	if !lockWithin(lockCtx, m.TryRLock, m.RLock, m.RUnlock) {
+		return nil, failed()
+	}
+
+	key := d.normalize(collection) + "/" + d.normalize(resource)
+	keyLock := func() { d.resourceLocks.lock(key) }
+	keyUnlock := func() { d.resourceLocks.unlock(key) }
+	if !lockWithin(lockCtx, nil, keyLock, keyUnlock) {
+		m.RUnlock()
+		return nil, failed()
+	}
+
+	return func() {
+		keyUnlock()
+		m.RUnlock()
+	}, nil
+}
+
+// rlockContext read locks [collection], giving up with the context's error
+// as soon as [ctx] is done
+func (d *Driver) rlockContext(ctx context.Context, collection string) (unlock func(), err error) {
+	if err := d.checkNames(collection, ""); err != nil {
+		return nil, err
+	}
+
+	m := d.getOrCreateMutex(collection)
+	if !lockWithin(ctx, m.TryRLock, m.RLock, m.RUnlock) {
+		return nil, ctx.Err()
+	}
+
+	return m.RUnlock, nil
+}
+
+// lockWithin acquires a lock with [lock], waiting until [ctx] is done at
+// most, and reports whether it did. A lock acquired only after giving up is
+// released again with [unlock].
+func lockWithin(ctx context.Context, tryLock func() bool, lock, unlock func()) bool {
+	if ctx.Done() == nil {
+		lock()
+		return true
+	}
+
+	// spare the goroutine when the lock is free
+	if tryLock != nil && tryLock() {
+		return true
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return true
+	case <-ctx.Done():
+		go func() {
+			<-acquired
+			unlock()
+		}()
+		return false
+	}
+}
@@ -0,0 +1,238 @@
+package jsondb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResourceLevelLocking(t *testing.T) {
+	d := newTestDB(t, &Options{ResourceLevelLocking: true, Manifest: true})
+
+	// a held resource doesn't block writes to other resources
+	unlock, err := d.lockResource(collection, "busy")
+	if err != nil {
+		t.Fatal("Lock failed: ", err.Error())
+	}
+
+	done := make(chan error)
+	go func() { done <- d.Write(collection, "other", redfish) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("Create fish failed: ", err.Error())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write of another resource blocked")
+	}
+
+	// but it does block writes to itself
+	go func() { done <- d.Write(collection, "busy", redfish) }()
+
+	select {
+	case <-done:
+		t.Error("Write of a locked resource didn't wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	if err := <-done; err != nil {
+		t.Error("Create fish failed: ", err.Error())
+	}
+
+	// concurrent writers keep the manifest consistent
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := d.Write(collection, fmt.Sprint(i), redfish); err != nil {
+				t.Error("Create fish failed: ", err.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if names, err := d.list(collection); err != nil || len(names) != 22 {
+		t.Error("Expected 22 records listed, got: ", len(names), err)
+	}
+
+	// the lock map doesn't keep unused locks
+	if n := len(d.resourceLocks.locks); n != 0 {
+		t.Error("Expected no resource locks left, got: ", n)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	for _, resourceLevel := range []bool{false, true} {
+		d := newTestDB(t, &Options{LockTimeout: 20 * time.Millisecond, ResourceLevelLocking: resourceLevel})
+
+		unlock, err := d.lockResource(collection, "red")
+		if err != nil {
+			t.Fatal("Lock failed: ", err.Error())
+		}
+
+		if err := d.Write(collection, "red", redfish); !errors.Is(err, ErrLockTimeout) {
+			t.Error("Expected ErrLockTimeout, got: ", err)
+		}
+		if err := d.Delete(collection, "red"); !errors.Is(err, ErrLockTimeout) {
+			t.Error("Expected ErrLockTimeout, got: ", err)
+		}
+
+		unlock()
+
+		// whatever locks the whole collection gives up as well
+		unlock, err = d.lockResource(collection, "")
+		if err != nil {
+			t.Fatal("Lock failed: ", err.Error())
+		}
+
+		locked := map[string]func() error{
+			"Swap":     func() error { return d.Swap(collection, "red", "blue") },
+			"WriteAll": func() error { return d.WriteAll(collection, map[string]interface{}{"red": redfish}) },
+			"WriteBatchContext": func() error {
+				_, err := d.WriteBatchContext(context.Background(), collection, map[string]interface{}{"red": redfish})
+				return err
+			},
+			"Migrate":             func() error { _, err := d.Migrate(collection, nil); return err },
+			"ConfigureCollection": func() error { return d.ConfigureCollection(collection, CollectionConfig{}) },
+			"RebuildIndex":        func() error { return d.RebuildIndex(collection, "type") },
+			"DropIndex":           func() error { return d.DropIndex(collection, "type") },
+			"AddUniqueConstraint": func() error { return d.AddUniqueConstraint(collection, "type") },
+			"SeedOnce":            func() error { _, err := d.SeedOnce(collection, map[string]interface{}{"red": redfish}); return err },
+		}
+		for name, fn := range locked {
+			if err := fn(); !errors.Is(err, ErrLockTimeout) {
+				t.Errorf("Expected ErrLockTimeout from %s, got: %v", name, err)
+			}
+		}
+
+		// the context ending first is what WriteBatchContext reports
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		if _, err := d.WriteBatchContext(ctx, collection, map[string]interface{}{"red": redfish}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Error("Expected context.DeadlineExceeded, got: ", err)
+		}
+		cancel()
+
+		unlock()
+
+		// locks acquired after giving up are released again
+		done := make(chan error)
+		go func() { done <- d.Write(collection, "red", redfish) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error("Create fish failed: ", err.Error())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Write blocked after the lock was released")
+		}
+	}
+}
+
+func TestConcurrentCollections(t *testing.T) {
+	for _, opts := range []*Options{{}, {ResourceLevelLocking: true, Manifest: true}} {
+		d := newTestDB(t, opts)
+
+		collections := []string{"fish/a", "fish/b", "fish/c", "fish/d"}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < 20; j++ {
+					c := collections[(i+j)%len(collections)]
+					other := collections[(i+j+1)%len(collections)]
+					name := fmt.Sprintf("fish%d", j%5)
+
+					// errors are expected from records deleted in between; the
+					// race detector and the deadline are what this checks
+					switch (i + j) % 6 {
+					case 0:
+						d.Write(c, name, redfish)
+					case 1:
+						d.Read(c, name, &Fish{})
+					case 2:
+						d.ReadAll(c)
+					case 3:
+						d.Delete(c, name)
+					case 4:
+						d.ConfigureCollection(c, CollectionConfig{Indent: "  "})
+					case 5:
+						d.DiffCollections(c, other)
+					}
+				}
+			}()
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(30 * time.Second):
+			t.Fatal("Concurrent operations deadlocked")
+		}
+	}
+}
+
+func TestReadersShareLock(t *testing.T) {
+	d := newTestDB(t, &Options{Tombstones: true})
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.RebuildIndex(collection, "type"); err != nil {
+		t.Fatal("Failed to build index: ", err.Error())
+	}
+	if _, err := d.Counter(collection, "count").Inc(1); err != nil {
+		t.Fatal("Failed to count: ", err.Error())
+	}
+
+	// none of these wait for another reader
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		t.Fatal("Failed to lock: ", err.Error())
+	}
+	defer unlock()
+
+	done := make(chan error, 4)
+	go func() {
+		_, err := d.ReadInto(collection, map[string]interface{}{"red": &Fish{}})
+		done <- err
+	}()
+	go func() {
+		_, err := d.LookupIndex(collection, "type", "red")
+		done <- err
+	}()
+	go func() {
+		_, err := d.ListTombstones(collection)
+		done <- err
+	}()
+	go func() {
+		_, err := d.Counter(collection, "count").Get()
+		done <- err
+	}()
+
+	for i := 0; i < 4; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error("Read failed: ", err.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the reads to share the lock")
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package jsondb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxNameLength is the longest file name a record is stored under as it is;
+// file systems commonly allow 255 bytes, and the rest is left for the
+// suffixes of temp files and sidecars
+const maxNameLength = 240
+
+// hashedPrefix starts the file name of a record stored under the hash of
+// its name
+const hashedPrefix = "~sha256-"
+
+// storedName returns the name [resource] is stored under: its encoded name,
+// or the hash of it when that's too long for a file name. Names that would
+// look like a hash are hashed too, so a hashed file name always has a key
+// file holding the resource it stores.
+func storedName(encoded string) string {
+	if len(encoded) <= maxNameLength && !strings.HasPrefix(encoded, hashedPrefix) {
+		return encoded
+	}
+
+	sum := sha256.Sum256([]byte(encoded))
+	return hashedPrefix + hex.EncodeToString(sum[:])
+}
+
+// isHashed reports whether [resource] is stored under a hashed file name
+func (d *Driver) isHashed(resource string) bool {
+	return strings.HasPrefix(d.fileName(resource), hashedPrefix)
+}
+
+// hashedResource returns the resource stored in the hashed file [file] of
+// the collection directory [dir], read from its key file
+func hashedResource(dir, file string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, file+keySuffix))
+	if err != nil {
+		return "", false
+	}
+
+	return string(b), true
+}
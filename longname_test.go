@@ -0,0 +1,83 @@
+package jsondb
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLongNames(t *testing.T) {
+	d := newTestDB(t, &Options{Extension: ".json"})
+
+	long := strings.Repeat("fish", 100)
+	if err := d.Write(collection, long, redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the record is stored under a name the file system accepts
+	if name := d.fileName(long); len(name) > 255 || !strings.HasPrefix(name, hashedPrefix) {
+		t.Error("Expected a hashed file name, got: ", name)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, long, &fish); err != nil || fish != redfish {
+		t.Error("Expected redfish, got: ", fish, err)
+	}
+
+	names, err := d.ListPage(collection, 0, 10)
+	if err != nil || len(names) != 1 || names[0] != long {
+		t.Error("Expected the long name to be listed, got: ", names, err)
+	}
+
+	// names looking like hashes are hashed too, so they list as themselves
+	lookalike := hashedPrefix + "fish"
+	if err := d.Write(collection, lookalike, redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	names, err = d.ListPage(collection, 0, 10)
+	if err != nil || len(names) != 2 {
+		t.Error("Expected 2 names, got: ", names, err)
+	}
+
+	for _, name := range []string{long, lookalike} {
+		if err := d.Delete(collection, name); err != nil {
+			t.Fatal("Failed to delete: ", err.Error())
+		}
+	}
+
+	// the key files go along with the records
+	entries, err := os.ReadDir(d.collectionDir(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), keySuffix) {
+			t.Error("Expected the key file to be removed, got: ", entry.Name())
+		}
+	}
+}
+
+func TestLongNamesZip(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	long := strings.Repeat("fish", 100)
+	if err := d.Write(collection, long, redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	var buf strings.Builder
+	if err := d.ExportZip(&buf); err != nil {
+		t.Fatal("Failed to export: ", err.Error())
+	}
+
+	restored := newTestDB(t, nil)
+	if err := restored.ImportZip(strings.NewReader(buf.String())); err != nil {
+		t.Fatal("Failed to import: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := restored.Read(collection, long, &fish); err != nil || fish != redfish {
+		t.Error("Expected the long named fish to be restored, got: ", fish, err)
+	}
+}
@@ -0,0 +1,76 @@
+package jsondb
+
+import (
+	"sync"
+	"time"
+)
+
+// maintenanceReport sums up what a maintenance pass did
+type maintenanceReport struct {
+	tempFiles  int // abandoned temp files removed
+	tombstones int // old tombstones purged
+	cached     int // expired cache entries dropped
+}
+
+// StartMaintenance runs a housekeeping pass in the background every
+// [interval] until the returned stop is called: it removes the temp files
+// CleanTempFiles would, purges tombstones older than Options.TombstoneAge
+// when that's set, and drops cached records past their CacheTTL. Every pass
+// takes the same locks the operations it stands in for do, so it's safe
+// alongside normal use, and logs a summary, along with any error, through
+// Debug. Calling stop waits for a pass in progress to finish; calling it
+// again does nothing.
+func (d *Driver) StartMaintenance(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				report, err := d.maintain()
+				if err != nil {
+					d.log("Maintenance failed: %v\n", err)
+				}
+				d.log("Maintenance removed %d temp files, purged %d tombstones and dropped %d cached records\n",
+					report.tempFiles, report.tombstones, report.cached)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// maintain runs one maintenance pass, carrying on past a failed step so one
+// problem doesn't hold up the rest of the housekeeping
+func (d *Driver) maintain() (report maintenanceReport, err error) {
+	removed, cerr := d.CleanTempFiles()
+	report.tempFiles = len(removed)
+	if cerr != nil {
+		err = cerr
+	}
+
+	if d.tombstoneAge > 0 {
+		purged, perr := d.PurgeTombstones(time.Now().Add(-d.tombstoneAge))
+		report.tombstones = purged
+		if perr != nil && err == nil {
+			err = perr
+		}
+	}
+
+	report.cached = d.cache.sweep()
+	return report, err
+}
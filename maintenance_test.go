@@ -0,0 +1,86 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaintain(t *testing.T) {
+	d := newTestDB(t, &Options{Tombstones: true, TombstoneAge: time.Millisecond, CacheTTL: time.Millisecond})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Read(collection, "redfish", &Fish{}); err != nil {
+		t.Fatal("Read fish failed: ", err.Error())
+	}
+
+	if err := d.Delete(collection, "redfish"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	// a temp file an interrupted write left behind long ago
+	tmp := d.recordPath(collection, "bluefish") + tmpSuffix
+	if err := os.WriteFile(tmp, []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(tmp, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// put an entry in the cache that will have expired by the pass
+	d.cache.put(collection, "greenfish", []byte(`{}`))
+
+	time.Sleep(5 * time.Millisecond)
+
+	report, err := d.maintain()
+	if err != nil {
+		t.Fatal("Maintenance failed: ", err.Error())
+	}
+
+	if report.tempFiles != 1 || report.tombstones != 1 || report.cached != 1 {
+		t.Error("Unexpected maintenance report: ", report)
+	}
+
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Error("Expected the temp file to be removed, got: ", err)
+	}
+}
+
+func TestStartMaintenance(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	tmp := d.recordPath(collection, "bluefish") + tmpSuffix
+	if err := os.MkdirAll(d.collectionDir(collection), dirMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(tmp, []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(tmp, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := d.StartMaintenance(time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(tmp); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected maintenance to remove the temp file")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+	stop()
+}
@@ -0,0 +1,133 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// manifestName is the file in each collection holding its manifest
+const manifestName = "_manifest.json"
+
+// manifest maps the resource names of a collection to their file info
+type manifest map[string]manifestEntry
+
+type manifestEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// names returns the sorted resource names in the manifest
+func (m manifest) names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// RebuildManifest locks the collection and regenerates its manifest from the
+// records actually on disk, picking up changes made outside of jsondb
+func (d *Driver) RebuildManifest(collection string) error {
+	// ensure there is a collection to index
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m, err := d.scanManifest(collection)
+	if err != nil {
+		return err
+	}
+
+	return writeManifest(d.collectionDir(collection), m)
+}
+
+// updateManifest records the current state of [resource] in the collection's
+// manifest, if manifests are maintained; the caller holds the collection lock
+func (d *Driver) updateManifest(collection, resource string) error {
+	if !d.manifest {
+		return nil
+	}
+
+	dir := d.collectionDir(collection)
+
+	m, err := readManifest(dir)
+	switch {
+	// start from what's on disk so existing records aren't forgotten
+	case errors.Is(err, fs.ErrNotExist):
+		if m, err = d.scanManifest(collection); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+
+	info, err := os.Stat(d.recordPath(collection, resource))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		delete(m, resource)
+	case err != nil:
+		return err
+	default:
+		m[resource] = manifestEntry{Size: info.Size(), ModTime: info.ModTime()}
+	}
+
+	return writeManifest(dir, m)
+}
+
+// scanManifest builds a manifest from the records in the collection directory
+func (d *Driver) scanManifest(collection string) (manifest, error) {
+	files, err := os.ReadDir(d.collectionDir(collection))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(manifest)
+	for _, file := range files {
+		name, ok := d.recordName(d.collectionDir(collection), file)
+		if !ok {
+			continue
+		}
+
+		// stat through content addressed links to the records themselves
+		info, err := os.Stat(d.recordPath(collection, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m[name] = manifestEntry{Size: info.Size(), ModTime: info.ModTime()}
+	}
+
+	return m, nil
+}
+
+func readManifest(dir string) (manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(manifest)
+	return m, json.Unmarshal(b, &m)
+}
+
+func writeManifest(dir string, m manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(dir, manifestName), b)
+}
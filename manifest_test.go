@@ -0,0 +1,56 @@
+package jsondb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	d := newTestDB(t, &Options{Manifest: true})
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "blue"}} {
+		if err := d.Write(collection, f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	m, err := readManifest(filepath.Join(d.dir, collection))
+	if err != nil {
+		t.Fatal("Failed to read manifest: ", err.Error())
+	}
+
+	if fmt.Sprint(m.names()) != "[blue red]" || m["red"].Size == 0 {
+		t.Error("Expected blue and red in manifest, got: ", m)
+	}
+
+	if err := d.Delete(collection, "blue"); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+
+	// records added behind the driver's back aren't listed
+	path := filepath.Join(d.dir, collection, "green")
+	if err := os.WriteFile(path, []byte(`{"type":"green"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if names, err := d.list(collection); err != nil || fmt.Sprint(names) != "[red]" {
+		t.Error("Expected only red listed from manifest, got: ", names, err)
+	}
+
+	// until the manifest is rebuilt
+	if err := d.RebuildManifest(collection); err != nil {
+		t.Fatal("Failed to rebuild manifest: ", err.Error())
+	}
+
+	if names, err := d.list(collection); err != nil || fmt.Sprint(names) != "[green red]" {
+		t.Error("Expected green and red listed, got: ", names, err)
+	}
+
+	// the manifest itself is never a record
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 2 {
+		t.Error("Expected 2 records, got: ", len(records), err)
+	}
+}
@@ -0,0 +1,111 @@
+package jsondb
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// ReadMapped returns the raw JSON of a record memory mapped rather than
+// copied onto the heap, along with a function that unmaps it again; the
+// bytes must not be used, and are read only, once it was called. Writes
+// rename a new file into place, so a mapping keeps seeing the record as it
+// was when it was mapped. Records that have to be decoded, like compressed
+// ones, seed records and those on platforms without mmap are returned as a
+// copy with an unmap function that does nothing. A missing record returns
+// ErrNotFound.
+func (d *Driver) ReadMapped(collection, resource string) (data []byte, unmap func() error, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "read", collection, resource)
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a place to read record from
+	if collection == "" {
+		return nil, nil, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to read
+	if resource == "" {
+		return nil, nil, ErrMissingResource
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	f, err := d.openRecord(collection, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if stat.Size() == 0 {
+		if d.emptyAsNotFound {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, ErrEmptyRecord
+	}
+
+	// seed records aren't files of their own
+	file, ok := f.(*os.File)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		return d.decodeCopy(collection, b)
+	}
+
+	b, unmap, err := mapFile(file, stat.Size())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if isGzip(b) {
+		defer unmap()
+		return d.decodeCopy(collection, b)
+	}
+
+	return b, unmap, nil
+}
+
+// decodeCopy returns the decoded [b] as ReadMapped does for records it can't
+// hand out mapped
+func (d *Driver) decodeCopy(collection string, b []byte) ([]byte, func() error, error) {
+	b, err := d.decode(collection, b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, func() error { return nil }, nil
+}
+
+// openRecord opens the file of a record, falling back to the seed records,
+// and returns ErrNotFound if it exists in neither place
+func (d *Driver) openRecord(collection, resource string) (fs.File, error) {
+	f, err := os.Open(d.recordPath(collection, resource))
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if d.source != nil && !d.buried(collection, resource) {
+		if f, serr := d.source.Open(path.Join(d.collectionPath(collection), d.fileName(resource))); serr == nil {
+			return f, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package jsondb
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads [f] onto the heap where mmap isn't available
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(f, b); err != nil {
+		return nil, nil, err
+	}
+
+	return b, func() error { return nil }, nil
+}
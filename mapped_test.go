@@ -0,0 +1,69 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestReadMapped(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, unmap, err := d.ReadMapped(collection, "redfish")
+	if err != nil {
+		t.Fatal("Failed to map fish: ", err.Error())
+	}
+
+	// a write renames a new file into place, leaving the mapping alone
+	if err := d.Write(collection, "redfish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Update fish failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := json.Unmarshal(b, &fish); err != nil {
+		t.Fatal("Failed to decode mapped fish: ", err.Error())
+	}
+
+	if fish != redfish {
+		t.Error("Expected the mapped redfish, got: ", fish)
+	}
+
+	if err := unmap(); err != nil {
+		t.Error("Failed to unmap fish: ", err.Error())
+	}
+
+	if err := unmap(); err != nil {
+		t.Error("Expected unmapping twice to be harmless, got: ", err.Error())
+	}
+
+	if _, _, err := d.ReadMapped(collection, "nofish"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+}
+
+func TestReadMappedCompressed(t *testing.T) {
+	d := newTestDB(t, &Options{Compress: true})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, unmap, err := d.ReadMapped(collection, "redfish")
+	if err != nil {
+		t.Fatal("Failed to map fish: ", err.Error())
+	}
+	defer unmap()
+
+	fish := Fish{}
+	if err := json.Unmarshal(b, &fish); err != nil {
+		t.Fatal("Failed to decode mapped fish: ", err.Error())
+	}
+
+	if fish != redfish {
+		t.Error("Expected redfish, got: ", fish)
+	}
+}
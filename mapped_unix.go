@@ -0,0 +1,27 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package jsondb
+
+import (
+	"os"
+	"sync"
+	"syscall"
+)
+
+// mapFile maps the first [size] bytes of [f] read only; the mapping outlives
+// the file being closed or replaced
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// unmapping twice could release memory mapped since
+	var once sync.Once
+	unmap := func() (err error) {
+		once.Do(func() { err = syscall.Munmap(b) })
+		return err
+	}
+
+	return b, unmap, nil
+}
@@ -0,0 +1,82 @@
+package jsondb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// Migrate locks the collection and passes the raw bytes of every record
+// through [migrate], atomically rewriting the records whose bytes changed. A
+// rewritten record is checked and stored like a Write, so it must pass the
+// collection's validator and schema, and its revision moves on. It returns
+// how many records were rewritten; records migrated before an error stay
+// migrated.
+func (d *Driver) Migrate(collection string, migrate func(raw []byte) ([]byte, error)) (migrated int, err error) {
+	return d.mapRecords("migrate", collection, func(_ string, raw []byte) ([]byte, error) {
+		return migrate(raw)
+	})
+}
+
+// MapCollection locks the collection and passes every record with its
+// resource name through [fn], atomically rewriting the records whose bytes
+// changed, checked like those of Migrate. It returns how many records were
+// rewritten and stops at the first error [fn] returns; records rewritten
+// before it stay rewritten.
+func (d *Driver) MapCollection(collection string, fn func(resource string, raw []byte) ([]byte, error)) (changed int, err error) {
+	return d.mapRecords("map", collection, fn)
+}
+
+// mapRecords is Migrate and MapCollection, naming [op] in its errors
+func (d *Driver) mapRecords(op, collection string, fn func(resource string, raw []byte) ([]byte, error)) (changed int, err error) {
+	// ensure there is a collection to map
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if err != nil {
+		// nothing to map in a collection that doesn't exist
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	for _, name := range names {
+		b, err := d.readFile(collection, name)
+		if err != nil {
+			return changed, fmt.Errorf("%s %s/%s: %w", op, collection, name, err)
+		}
+
+		out, err := fn(name, b)
+		if err != nil {
+			return changed, fmt.Errorf("%s %s/%s: %w", op, collection, name, err)
+		}
+
+		// leave records [fn] didn't touch alone
+		if bytes.Equal(b, out) {
+			continue
+		}
+
+		// a migrated record is stored like any other write
+		if err := d.checkRecord(collection, out); err != nil {
+			return changed, fmt.Errorf("%s %s/%s: %w", op, collection, name, err)
+		}
+
+		err = d.writeResolved(collection, name, out)
+		d.cache.invalidate(collection, name)
+		if err != nil {
+			return changed, fmt.Errorf("%s %s/%s: %w", op, collection, name, err)
+		}
+		changed++
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,119 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "blue"}} {
+		if err := d.Write(collection, f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// rename the "type" field to "kind" on red fish only
+	migrated, err := d.Migrate(collection, func(raw []byte) ([]byte, error) {
+		if !bytes.Contains(raw, []byte(`"red"`)) {
+			return raw, nil
+		}
+		return bytes.Replace(raw, []byte(`"type"`), []byte(`"kind"`), 1), nil
+	})
+	if err != nil {
+		t.Fatal("Failed to migrate: ", err.Error())
+	}
+
+	if migrated != 1 {
+		t.Error("Expected 1 migrated fish, got: ", migrated)
+	}
+
+	var m map[string]string
+	if err := d.Read(collection, "red", &m); err != nil || m["kind"] != "red" {
+		t.Error("Expected migrated red fish, got: ", m, err)
+	}
+
+	// errors name the offending record
+	_, err = d.Migrate(collection, func(raw []byte) ([]byte, error) {
+		return nil, errors.New("boom")
+	})
+	if err == nil || !strings.Contains(err.Error(), collection+"/blue") {
+		t.Error("Expected error naming the record, got: ", err)
+	}
+}
+
+func TestMapCollection(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "blue"}} {
+		if err := d.Write(collection, f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// add a field named after the resource to the red fish only
+	changed, err := d.MapCollection(collection, func(resource string, raw []byte) ([]byte, error) {
+		if resource != "red" {
+			return raw, nil
+		}
+		return []byte(`{"type":"red","name":"` + resource + `"}`), nil
+	})
+	if err != nil || changed != 1 {
+		t.Fatal("Expected 1 record changed, got: ", changed, err)
+	}
+
+	var got map[string]string
+	if err := d.Read(collection, "red", &got); err != nil || got["name"] != "red" {
+		t.Error("Expected the mapped fish, got: ", got, err)
+	}
+
+	// an error stops the map
+	failed := errors.New("failed")
+	changed, err = d.MapCollection(collection, func(resource string, raw []byte) ([]byte, error) {
+		return nil, failed
+	})
+	if !errors.Is(err, failed) || changed != 0 {
+		t.Error("Expected the map to fail, got: ", changed, err)
+	}
+}
+
+func TestMigrateValidates(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if _, err := d.WriteWithRev(collection, "red", redfish, 0); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	d.SetValidator(collection, func(raw []byte) error {
+		if bytes.Contains(raw, []byte(`"green"`)) {
+			return errors.New("no green fish")
+		}
+		return nil
+	})
+
+	// a migrated record failing the validator isn't stored
+	_, err := d.Migrate(collection, func(raw []byte) ([]byte, error) {
+		return bytes.Replace(raw, []byte(`"red"`), []byte(`"green"`), 1), nil
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Error("Expected ErrValidation, got: ", err)
+	}
+
+	// one passing it moves the revision on
+	if _, err := d.Migrate(collection, func(raw []byte) ([]byte, error) {
+		return bytes.Replace(raw, []byte(`"red"`), []byte(`"blue"`), 1), nil
+	}); err != nil {
+		t.Fatal("Failed to migrate: ", err.Error())
+	}
+
+	var fish struct {
+		Type string `json:"type"`
+		Rev  int    `json:"_rev"`
+	}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "blue" || fish.Rev != 2 {
+		t.Error("Expected blue fish at revision 2, got: ", fish, err)
+	}
+}
@@ -0,0 +1,28 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// readMirrored reads a record from the next of the read mirrors, or from the
+// database when there are none or the mirror can't provide it
+func (d *Driver) readMirrored(collection, resource string) ([]byte, error) {
+	if err := d.checkNames(collection, resource); err != nil {
+		return nil, err
+	}
+
+	if len(d.mirrors) == 0 {
+		return d.readFile(collection, resource)
+	}
+
+	i := atomic.AddUint32(&d.nextMirror, 1) % uint32(len(d.mirrors))
+	b, err := os.ReadFile(filepath.Join(d.mirrors[i], d.collectionPath(collection), d.fileName(resource)))
+	if err != nil {
+		// a mirror lagging behind, or broken, mustn't fail the read
+		return d.readFile(collection, resource)
+	}
+
+	return d.decode(collection, b)
+}
@@ -0,0 +1,44 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMirrors(t *testing.T) {
+	mirror := t.TempDir()
+	d := newTestDB(t, &Options{ReadMirrors: []string{mirror}})
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// the mirror holds a copy of the red fish only, changed to tell them apart
+	if err := os.MkdirAll(filepath.Join(mirror, collection), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mirror, collection, "red"), []byte(`{"type":"mirrored"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "mirrored" {
+		t.Error("Expected the mirrored fish, got: ", fish, err)
+	}
+	if err := d.Read(collection, "blue", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected the fish from the database, got: ", fish, err)
+	}
+
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 2 || string(records[1]) != `{"type":"mirrored"}` {
+		t.Error("Expected ReadAll from the mirror, got: ", records, err)
+	}
+
+	// the database itself is left alone
+	if b, err := os.ReadFile(d.recordPath(collection, "red")); err != nil || string(b) != `{"type":"red"}` {
+		t.Error("Expected the database untouched by the mirror, got: ", string(b), err)
+	}
+}
@@ -0,0 +1,71 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+)
+
+// MirrorTo copies every record of the database into [dst], keeping the
+// collection and resource names, one collection at a time so the records
+// are re-encoded with the options of [dst] and only one record is held in
+// memory at once. Records already in [dst] are overwritten and others left
+// alone. Each collection is read locked while it's copied, and its number
+// of records logged once it's done.
+func (d *Driver) MirrorTo(dst *Driver) (err error) {
+	defer wrapOpError(&err, "mirrorto", "", "")
+
+	if dst == d {
+		return errors.New("cannot mirror a database into itself")
+	}
+
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range collections {
+		n, err := d.mirrorCollection(dst, collection)
+		if err != nil {
+			return err
+		}
+
+		d.log("Mirrored %d records of '%s'\n", n, collection)
+	}
+
+	return nil
+}
+
+// mirrorCollection copies the records of [collection] into [dst] and
+// returns how many it copied
+func (d *Driver) mirrorCollection(dst *Driver, collection string) (int, error) {
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if err != nil {
+		return 0, &OpError{Op: "mirrorto", Collection: collection, Err: err}
+	}
+
+	n := 0
+	for _, name := range names {
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(b) == 0) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+		if err != nil {
+			return n, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		if err := dst.Write(collection, name, json.RawMessage(b)); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
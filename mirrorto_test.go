@@ -0,0 +1,47 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMirrorTo(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write("ocean/"+collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	dst := newTestDB(t, nil)
+	if err := dst.ConfigureCollection(collection, CollectionConfig{Compress: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	if err := d.MirrorTo(dst); err != nil {
+		t.Fatal("Failed to mirror: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := dst.Read(collection, "redfish", &fish); err != nil || fish != redfish {
+		t.Error("Expected the mirrored redfish, got: ", fish, err)
+	}
+	if err := dst.Read("ocean/"+collection, "bluefish", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected the mirrored bluefish, got: ", fish, err)
+	}
+
+	// the copy is stored the way the target stores records
+	b, err := os.ReadFile(dst.recordPath(collection, "redfish"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGzip(b) {
+		t.Error("Expected the mirrored record to be compressed")
+	}
+
+	if err := d.MirrorTo(d); err == nil {
+		t.Error("Expected an error mirroring into itself")
+	}
+}
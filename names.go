@@ -0,0 +1,67 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidName is returned for a collection or resource name that would
+// be stored outside its place in the database directory
+var ErrInvalidName = errors.New("invalid name - the name would escape its place in the database")
+
+// checkNames makes sure [collection] and [resource], as they're stored on
+// disk, stay inside the database directory, and that the record's file
+// isn't named like a blob or another file kept beside records; empty names
+// are left to the caller to reject
+func (d *Driver) checkNames(collection, resource string) error {
+	if collection != "" {
+		if err := safeName(d.collectionPath(collection), true); err != nil {
+			return fmt.Errorf("%w: collection %q", err, collection)
+		}
+	}
+
+	if resource != "" {
+		if err := safeName(d.encodedName(resource), false); err != nil {
+			return fmt.Errorf("%w: resource %q", err, resource)
+		}
+
+		// a record stored as a blob, checksum or temp file would be
+		// overwritten by one and never listed
+		if !d.isRecordName(d.fileName(resource)) {
+			return fmt.Errorf("%w: resource %q is stored like a file jsondb keeps beside records", ErrInvalidName, resource)
+		}
+	}
+
+	return nil
+}
+
+// safeName returns ErrInvalidName unless [name] is a plain file name, or a
+// relative path of them when [nested] allows collections within collections.
+// Names starting with a dot are refused as well: they're jsondb's own files,
+// like a collection's settings, and "." and ".." would leave the directory.
+func safeName(name string, nested bool) error {
+	if strings.ContainsRune(name, 0) || (filepath.Separator != '/' && strings.ContainsRune(name, filepath.Separator)) {
+		return ErrInvalidName
+	}
+
+	parts := []string{name}
+	if nested {
+		parts = strings.Split(name, "/")
+	}
+
+	for _, part := range parts {
+		if part == "" || strings.HasPrefix(part, ".") || strings.Contains(part, "/") {
+			return ErrInvalidName
+		}
+	}
+
+	// whatever the parts, the cleaned path must not climb out
+	if clean := filepath.Clean(filepath.FromSlash(name)); filepath.IsAbs(clean) || clean == ".." ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return ErrInvalidName
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSafeName(t *testing.T) {
+	for _, name := range []string{"red", "red fish", "bob@example.com", "a..b"} {
+		if err := safeName(name, false); err != nil {
+			t.Errorf("Expected %q to be safe, got: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"..", ".", ".config.json", "../x", "a/b", "a\x00b"} {
+		if err := safeName(name, false); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Expected %q to be refused, got: %v", name, err)
+		}
+	}
+
+	// collections may be nested, but only downwards
+	if err := safeName("ocean/school", true); err != nil {
+		t.Error("Expected a nested collection to be safe, got: ", err)
+	}
+	for _, name := range []string{"ocean/../..", "/etc", "ocean//school", "ocean/", "ocean/.blobs"} {
+		if err := safeName(name, true); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Expected %q to be refused, got: %v", name, err)
+		}
+	}
+}
+
+func TestPathTraversal(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write("users", "../../../tmp/x", redfish); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+
+	if err := d.Write("../outside", "red", redfish); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(d.dir), "outside")); !os.IsNotExist(err) {
+		t.Error("Expected nothing written outside the database, got: ", err)
+	}
+
+	// a file next to the database can't be read or deleted through it
+	outside := filepath.Join(filepath.Dir(d.dir), "secret.json")
+	if err := os.WriteFile(outside, []byte(`{"type":"secret"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Read(collection, "../../secret", &Fish{}); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+	if err := d.Delete("..", "secret"); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+	if _, err := d.ReadAll(".."); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName, got: ", err)
+	}
+
+	// every way of writing or deleting checks names, not just Write and Delete
+	victim := filepath.Join(filepath.Dir(d.dir), "victim.json")
+	if err := os.WriteFile(victim, []byte(`{"type":"victim"}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.WriteBlob(collection, "../../blob", []byte("x")); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from WriteBlob, got: ", err)
+	}
+	if _, err := d.ReadBlob(collection, "../../blob"); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from ReadBlob, got: ", err)
+	}
+	if _, err := d.Counter(collection, "../../counter").Inc(1); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from Counter, got: ", err)
+	}
+	if _, err := d.DeleteMany(collection, []string{"../../victim"}); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from DeleteMany, got: ", err)
+	}
+	if err := d.WriteAll("../../wa", map[string]interface{}{"red": redfish}); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from WriteAll, got: ", err)
+	}
+	if err := d.WriteAll(collection, map[string]interface{}{"../../wa": redfish}); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from WriteAll, got: ", err)
+	}
+	if err := d.SetField(collection, "../../victim", "type", "changed"); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from SetField, got: ", err)
+	}
+	if _, err := d.ApplyRetention("..", RetentionPolicy{MaxRecords: 1}); !errors.Is(err, ErrInvalidName) {
+		t.Error("Expected ErrInvalidName from ApplyRetention, got: ", err)
+	}
+
+	parent := filepath.Dir(d.dir)
+	for _, name := range []string{d.fileName("blob") + blobSuffix, d.fileName("counter"), filepath.Join("..", "wa")} {
+		if _, err := os.Stat(filepath.Join(parent, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing written outside the database as %s, got: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{outside, victim} {
+		if b, err := os.ReadFile(name); err != nil || bytes.Contains(b, []byte("changed")) {
+			t.Error("Expected the file outside the database to be left alone, got: ", string(b), err)
+		}
+	}
+
+	// names only the key encoder makes safe are fine
+	d = newTestDB(t, &Options{KeyEncoder: PercentEncodeKey, KeyDecoder: PercentDecodeKey})
+	if err := d.Write(collection, "../red", redfish); err != nil {
+		t.Error("Expected an encoded name to be safe, got: ", err)
+	}
+}
+
+func TestPathTraversalCollections(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// a directory next to the database, laid out like a collection
+	outside := filepath.Join(filepath.Dir(d.dir), "outside")
+	if err := os.MkdirAll(filepath.Join(outside, indexDir), dirMode); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"red" + d.ext, filepath.Join(indexDir, "type.json")} {
+		if err := os.WriteFile(filepath.Join(outside, name), []byte(`{"type":"red"}`), fileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, call := range map[string]func() error{
+		"Rotate":              func() error { return d.Rotate("../outside", "../arch") },
+		"Rotate archive":      func() error { return d.Rotate(collection, "../arch") },
+		"RebuildIndex":        func() error { return d.RebuildIndex("../outside", "type") },
+		"DropIndex":           func() error { return d.DropIndex("../outside", "type") },
+		"ConfigureCollection": func() error { return d.ConfigureCollection("../outside", CollectionConfig{}) },
+		"RebuildManifest":     func() error { return d.RebuildManifest("../outside") },
+		"AddUniqueConstraint": func() error { return d.AddUniqueConstraint("../outside", "type") },
+		"ListWithSizes":       func() error { _, err := d.ListWithSizes("../outside"); return err },
+		"Reconcile":           func() error { _, err := d.Reconcile("../outside"); return err },
+		"ListBlobs":           func() error { _, err := d.ListBlobs("../outside"); return err },
+		"VerifyChecksums":     func() error { _, err := d.VerifyChecksums("../outside"); return err },
+		"CompressCollection":  func() error { _, err := d.CompressCollection("../outside"); return err },
+		"ReadWithInfo":        func() error { _, err := d.ReadWithInfo("../outside", "red", &Fish{}); return err },
+		"RecentlyDeleted":     func() error { _, err := d.RecentlyDeleted("../outside", time.Time{}); return err },
+		"DiffCollections":     func() error { _, _, _, err := d.DiffCollections(collection, "../outside"); return err },
+		"MoveWhere": func() error {
+			_, err := d.MoveWhere("../outside", collection, func(string, []byte) (bool, error) { return true, nil })
+			return err
+		},
+	} {
+		if err := call(); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Expected ErrInvalidName from %s, got: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"red" + d.ext, filepath.Join(indexDir, "type.json")} {
+		if _, err := os.Stat(filepath.Join(outside, name)); err != nil {
+			t.Errorf("Expected %s left alone outside the database, got: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, configName)); !os.IsNotExist(err) {
+		t.Error("Expected no settings written outside the database, got: ", err)
+	}
+}
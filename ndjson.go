@@ -0,0 +1,82 @@
+package jsondb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// flusher is implemented by writers such as http.ResponseWriter that can
+// push buffered data to the client
+type flusher interface {
+	Flush()
+}
+
+// ndjsonLine is a line written by StreamNDJSON
+type ndjsonLine struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// StreamNDJSON writes every record of a collection to [w] in sorted resource
+// order, one {"key":...,"value":...} object per line, flushing after each line
+// when [w] can be flushed. A record that isn't valid JSON is written as
+// {"key":...,"error":...} instead, or left out with Options.SkipCorruptRecords.
+func (d *Driver) StreamNDJSON(collection string, w io.Writer) (err error) {
+	defer wrapOpError(&err, "stream", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil {
+		return err
+	}
+
+	f, _ := w.(flusher)
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for _, name := range names {
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		line := ndjsonLine{Key: name}
+
+		// records may be indented, but each must fit on its line
+		var value bytes.Buffer
+		if err := json.Compact(&value, b); err != nil {
+			if d.skipCorruptRecords {
+				continue
+			}
+			line.Error = err.Error()
+		} else {
+			line.Value = value.Bytes()
+		}
+
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+
+		if f != nil {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			f.Flush()
+		}
+	}
+
+	return bw.Flush()
+}
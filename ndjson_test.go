@@ -0,0 +1,50 @@
+package jsondb
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// flushRecorder counts how often it's flushed
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes++
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		d := newTestDB(t, &Options{Indent: "  ", SkipCorruptRecords: skip})
+
+		if err := d.Write(collection, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+		if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+		if err := os.WriteFile(d.recordPath(collection, "bad"), []byte("{oops"), fileMode); err != nil {
+			t.Fatal(err)
+		}
+
+		var w flushRecorder
+		if err := d.StreamNDJSON(collection, &w); err != nil {
+			t.Fatal("StreamNDJSON failed: ", err.Error())
+		}
+
+		want := `{"key":"blue","value":{"type":"blue"}}` + "\n" + `{"key":"red","value":{"type":"red"}}` + "\n"
+		if !skip {
+			want = `{"key":"bad","error":"invalid character 'o' looking for beginning of object key string"}` + "\n" + want
+		}
+		if w.String() != want {
+			t.Error("Expected ", want, ", got: ", w.String())
+		}
+
+		if lines := bytes.Count(w.Bytes(), []byte("\n")); w.flushes != lines {
+			t.Error("Expected a flush per line, got: ", w.flushes)
+		}
+	}
+}
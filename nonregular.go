@@ -0,0 +1,50 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// NonRegularPolicy decides what listing a collection does with entries
+// named like records that aren't regular files, such as symlinks, sockets
+// and devices
+type NonRegularPolicy int
+
+const (
+	// SkipNonRegular leaves them out of listings
+	SkipNonRegular NonRegularPolicy = iota
+	// RejectNonRegular fails the listing with ErrNonRegularFile
+	RejectNonRegular
+	// FollowSymlinks lists symlinks to regular files as records and leaves
+	// the other entries out
+	FollowSymlinks
+)
+
+// ErrNonRegularFile is returned when listing a collection finds an entry
+// that isn't a regular file under Options.NonRegularFiles RejectNonRegular
+var ErrNonRegularFile = errors.New("non-regular file - a collection holds an entry that is not a regular file")
+
+// followed reports whether [entry] of the collection directory [dir] is a
+// symlink to a regular file that is listed as a record
+func (d *Driver) followed(dir string, entry fs.DirEntry) bool {
+	if d.nonRegular != FollowSymlinks || entry.Type()&os.ModeSymlink == 0 || !d.isRecordName(entry.Name()) {
+		return false
+	}
+
+	info, err := os.Stat(filepath.Join(dir, entry.Name()))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// checkNonRegular returns an ErrNonRegularFile for [entry] of the collection
+// directory [dir] when it's named like a record without being one, if such
+// entries are rejected
+func (d *Driver) checkNonRegular(dir string, entry fs.DirEntry) error {
+	if d.nonRegular != RejectNonRegular || entry.IsDir() || !d.isRecordName(entry.Name()) || d.isRecord(entry) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrNonRegularFile, filepath.Join(dir, entry.Name()))
+}
@@ -0,0 +1,49 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNonRegularFiles(t *testing.T) {
+	for _, policy := range []NonRegularPolicy{SkipNonRegular, RejectNonRegular, FollowSymlinks} {
+		d := newTestDB(t, &Options{NonRegularFiles: policy})
+
+		if err := d.Write(collection, "redfish", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		// a record file linked in from elsewhere
+		outside := filepath.Join(t.TempDir(), "bluefish")
+		if err := os.WriteFile(outside, []byte(`{"type":"blue"}`), fileMode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(outside, filepath.Join(d.collectionDir(collection), "bluefish")); err != nil {
+			t.Skip("symlinks aren't supported: ", err)
+		}
+
+		records, err := d.ReadAll(collection)
+		iterated := 0
+		iterErr := d.IterateAll(func(collection, resource string, raw []byte) error {
+			iterated++
+			return nil
+		})
+
+		switch policy {
+		case SkipNonRegular:
+			if err != nil || len(records) != 1 || iterErr != nil || iterated != 1 {
+				t.Error("Expected the symlink to be skipped, got: ", len(records), err, iterated, iterErr)
+			}
+		case RejectNonRegular:
+			if !errors.Is(err, ErrNonRegularFile) || !errors.Is(iterErr, ErrNonRegularFile) {
+				t.Error("Expected ErrNonRegularFile, got: ", err, iterErr)
+			}
+		case FollowSymlinks:
+			if err != nil || len(records) != 2 || iterErr != nil || iterated != 2 {
+				t.Error("Expected the symlink to be followed, got: ", len(records), err, iterated, iterErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package jsondb
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// overlay holds the records a BufferedWriter has accepted but not stored
+// yet, so reads through the driver see them ahead of what is on disk. An
+// entry is dropped as soon as its record is changed on disk, by the flush
+// storing it or by any other write or delete.
+type overlay struct {
+	mutex  sync.Mutex
+	fold   bool                         // names are case insensitive, as with CaseInsensitiveKeys
+	byName map[string]map[string][]byte // marshaled records by collection and resource
+}
+
+// put makes [b] the pending record [resource]
+func (o *overlay) put(collection, resource string, b []byte) {
+	collection, resource = foldName(o.fold, collection), foldName(o.fold, resource)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	records, ok := o.byName[collection]
+	if !ok {
+		records = make(map[string][]byte)
+		o.byName[collection] = records
+	}
+	records[resource] = b
+}
+
+// get returns a copy of the pending record [resource], if there is one
+func (o *overlay) get(collection, resource string) ([]byte, bool) {
+	collection, resource = foldName(o.fold, collection), foldName(o.fold, resource)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	b, ok := o.byName[collection][resource]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]byte(nil), b...), true
+}
+
+// drop forgets the pending record [resource], or the whole collection
+// (including any nested collections) when [resource] is empty
+func (o *overlay) drop(collection, resource string) {
+	collection, resource = foldName(o.fold, collection), foldName(o.fold, resource)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if resource != "" {
+		if records, ok := o.byName[collection]; ok {
+			delete(records, resource)
+			if len(records) == 0 {
+				delete(o.byName, collection)
+			}
+		}
+		return
+	}
+
+	for name := range o.byName {
+		if name == collection || strings.HasPrefix(name, collection+"/") {
+			delete(o.byName, name)
+		}
+	}
+}
+
+// merge adds the resources pending in [collection] to the listed [names],
+// returning them sorted lexically
+func (o *overlay) merge(collection string, names []string) []string {
+	collection = foldName(o.fold, collection)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	records := o.byName[collection]
+	if len(records) == 0 {
+		return names
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+
+	for name := range records {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,119 @@
+package jsondb
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// ReadPage returns up to [limit] records of a collection starting at [offset]
+// in sorted resource order, reading only the records on the page. An offset
+// past the end yields an empty page rather than an error.
+func (d *Driver) ReadPage(collection string, offset, limit int) ([][]byte, error) {
+	names, err := d.ListPage(collection, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	_, records, err := d.readAll(collection, names)
+	if records == nil && err == nil {
+		records = [][]byte{}
+	}
+
+	return records, err
+}
+
+// ListPage returns up to [limit] resource names of a collection starting at
+// [offset] in sorted order
+func (d *Driver) ListPage(collection string, offset, limit int) ([]string, error) {
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	return page(names, offset, limit), nil
+}
+
+// page slices [offset:offset+limit] out of [names], clamped to its bounds
+func page(names []string, offset, limit int) []string {
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset >= len(names) || limit <= 0 {
+		return []string{}
+	}
+
+	end := offset + limit
+	if end > len(names) {
+		end = len(names)
+	}
+
+	return names[offset:end]
+}
+
+// Scan returns up to [limit] records of a collection keyed by resource,
+// continuing in sorted order after the position [cursor] marks; an empty
+// cursor starts at the beginning. The returned cursor resumes the scan where
+// this page ended and is empty once the collection is exhausted. Records
+// written or deleted between calls are seen or skipped depending on where
+// they sort relative to the cursor. Records may come from the cache, so
+// unless Options.CopyOnRead is set they must not be modified.
+func (d *Driver) Scan(collection, cursor string, limit int) (records map[string][]byte, nextCursor string, err error) {
+	defer wrapOpError(&err, "scan", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, "", ErrMissingCollection
+	}
+
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("invalid scan limit %d", limit)
+	}
+
+	after, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid scan cursor %q", cursor)
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", err
+	}
+
+	// skip everything up to and including the last name returned
+	i := 0
+	if cursor != "" {
+		i = sort.Search(len(names), func(i int) bool { return !d.less(names[i], string(after)) })
+		if i < len(names) && names[i] == string(after) {
+			i++
+		}
+	}
+
+	records = make(map[string][]byte)
+	for ; i < len(names) && len(records) < limit; i++ {
+		b, err := d.read(collection, names[i])
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, "", &OpError{Op: "read", Collection: collection, Resource: names[i], Err: err}
+		}
+
+		records[names[i]] = d.own(b)
+	}
+
+	if i < len(names) {
+		nextCursor = base64.RawURLEncoding.EncodeToString([]byte(names[i-1]))
+	}
+
+	return records, nextCursor, nil
+}
@@ -0,0 +1,141 @@
+package jsondb
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestReadPage(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for i := 0; i < 5; i++ {
+		if err := d.Write(collection, fmt.Sprint(i), Fish{Type: fmt.Sprint(i)}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	names, err := d.ListPage(collection, 1, 2)
+	if err != nil || fmt.Sprint(names) != "[1 2]" {
+		t.Error("Expected [1 2], got: ", names, err)
+	}
+
+	// the last page may be short
+	records, err := d.ReadPage(collection, 3, 10)
+	if err != nil || len(records) != 2 {
+		t.Error("Expected 2 records, got: ", len(records), err)
+	}
+
+	// out of range is empty, not an error
+	records, err = d.ReadPage(collection, 10, 2)
+	if err != nil || records == nil || len(records) != 0 {
+		t.Error("Expected empty page, got: ", records, err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("Scan didn't terminate")
+		}
+
+		records, next, err := d.Scan(collection, cursor, 2)
+		if err != nil {
+			t.Fatal("Scan failed: ", err.Error())
+		}
+
+		page := make([]string, 0, len(records))
+		for name := range records {
+			page = append(page, name)
+		}
+		sort.Strings(page)
+		seen = append(seen, page...)
+
+		// records written behind the cursor aren't returned again
+		if pages == 0 {
+			if err := d.Write(collection, "aa", redfish); err != nil {
+				t.Fatal("Create fish failed: ", err.Error())
+			}
+			if err := d.Delete(collection, "c"); err != nil {
+				t.Fatal("Delete fish failed: ", err.Error())
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if want := []string{"a", "b", "d", "e"}; !reflect.DeepEqual(seen, want) {
+		t.Error("Expected to scan ", want, ", got: ", seen)
+	}
+
+	if records, next, err := d.Scan("missing", "", 10); err != nil || len(records) != 0 || next != "" {
+		t.Error("Expected an empty scan of a missing collection, got: ", records, next, err)
+	}
+
+	if _, _, err := d.Scan(collection, "!", 10); err == nil {
+		t.Error("Expected an error for an invalid cursor")
+	}
+	if _, _, err := d.Scan(collection, "", 0); err == nil {
+		t.Error("Expected an error for a zero limit")
+	}
+}
+
+func TestKeyLess(t *testing.T) {
+	numeric := func(a, b string) bool {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return x < y
+	}
+	d := newTestDB(t, &Options{KeyLess: numeric})
+
+	for _, name := range []string{"100", "2", "10", "1"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	want := []string{"1", "2", "10", "100"}
+
+	names, err := d.ListPage(collection, 0, 10)
+	if err != nil || !reflect.DeepEqual(names, want) {
+		t.Error("Expected ", want, ", got: ", names, err)
+	}
+
+	records, err := d.ReadAll(collection)
+	if err != nil {
+		t.Fatal("ReadAll failed: ", err.Error())
+	}
+	for i, b := range records {
+		if fish := fmt.Sprintf(`{"type":"%s"}`, want[i]); string(b) != fish {
+			t.Error("Expected ", fish, ", got: ", string(b))
+		}
+	}
+
+	// scanning resumes in the same order
+	_, cursor, err := d.Scan(collection, "", 2)
+	if err != nil {
+		t.Fatal("Scan failed: ", err.Error())
+	}
+	rest, _, err := d.Scan(collection, cursor, 10)
+	if err != nil {
+		t.Fatal("Scan failed: ", err.Error())
+	}
+	if _, ok := rest["10"]; !ok || len(rest) != 2 {
+		t.Error("Expected the records after 2, got: ", rest)
+	}
+}
@@ -0,0 +1,409 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrPatchTestFailed is returned when a "test" operation of a JSON patch
+// doesn't match the record
+var ErrPatchTestFailed = errors.New("patch test failed")
+
+// patchOp is a single operation of an RFC 6902 JSON patch
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch locks [resource] and applies the RFC 6902 JSON [patch] to
+// it. The patch is all or nothing: if any operation fails, including a
+// "test" that doesn't match (ErrPatchTestFailed), the record is left as is.
+func (d *Driver) ApplyJSONPatch(collection, resource string, patch []byte) (err error) {
+	defer wrapOpError(&err, "patch", collection, resource)
+
+	// ensure there is a place to patch
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource to patch
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	var ops []patchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("invalid patch: %w", err)
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	b, err := d.readExisting(collection, resource)
+	if err != nil {
+		return err
+	}
+
+	doc, err := decodeValue(b)
+	if err != nil {
+		return err
+	}
+
+	for i, op := range ops {
+		if doc, err = applyPatchOp(doc, op); err != nil {
+			return fmt.Errorf("patch operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+
+	return d.write(collection, resource, doc)
+}
+
+// applyPatchOp applies a single operation to [doc], returning the new document
+func applyPatchOp(doc interface{}, op patchOp) (interface{}, error) {
+	if op.Path == nil {
+		return nil, errors.New(`missing "path"`)
+	}
+
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New(`missing "value"`)
+		}
+
+		value, err := decodeValue(op.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			return replaceValue(doc, path, value)
+		}
+
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, *op.Path)
+		}
+		return doc, nil
+
+	case "remove":
+		doc, _, err := removeValue(doc, path)
+		return doc, err
+
+	case "move", "copy":
+		if op.From == nil {
+			return nil, errors.New(`missing "from"`)
+		}
+
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			value, err := getValue(doc, from)
+			if err != nil {
+				return nil, err
+			}
+
+			// the copy mustn't share maps or slices with the original
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = decodeValue(b); err != nil {
+				return nil, err
+			}
+
+			return addValue(doc, path, value)
+		}
+
+		// a value can't be moved into one of its own children
+		if *op.Path != *op.From && strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into itself", *op.From)
+		}
+
+		doc, value, err := removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return addValue(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid path %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex parses [token] as an index into an array of length [n]; [end]
+// allows the index one past the last element, and "-" for it
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if end && token == "-" {
+		return n, nil
+	}
+
+	// indexes are plain decimal numbers without leading zeros
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || strconv.Itoa(i) != token {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	if i > n || (i == n && !end) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+
+	return i, nil
+}
+
+// applyAt walks [path] through [node] and calls [fn] with the parent of the
+// last token, storing whatever parent it returns back into the tree
+func applyAt(node interface{}, path []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("path member %q not found", path[0])
+		}
+
+		child, err := applyAt(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = child
+		return n, nil
+
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := applyAt(n[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("cannot traverse into %q of a non container value", path[0])
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			doc = v
+
+		case []interface{}:
+			i, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = n[i]
+
+		default:
+			return nil, fmt.Errorf("cannot traverse into %q of a non container value", token)
+		}
+	}
+
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	// adding at the root replaces the whole document
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return applyAt(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+
+		return nil, fmt.Errorf("cannot add %q to a non container value", key)
+	})
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+
+	var removed interface{}
+	doc, err := applyAt(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			v, ok := p[key]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", key)
+			}
+
+			removed = v
+			delete(p, key)
+			return p, nil
+
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+
+			removed = p[i]
+			return append(p[:i], p[i+1:]...), nil
+		}
+
+		return nil, fmt.Errorf("cannot remove %q from a non container value", key)
+	})
+
+	return doc, removed, err
+}
+
+func replaceValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return applyAt(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("path member %q not found", key)
+			}
+
+			p[key] = value
+			return p, nil
+
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+
+			p[i] = value
+			return p, nil
+		}
+
+		return nil, fmt.Errorf("cannot replace %q of a non container value", key)
+	})
+}
+
+// decodeValue decodes [b] into generic JSON values, keeping numbers exact
+func decodeValue(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing
+// numbers by value rather than by how they were written
+func jsonEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		fa, erra := a.Float64()
+		fb, errb := b.Float64()
+		if erra != nil || errb != nil {
+			return a == b
+		}
+		return fa == fb
+
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,115 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	record := map[string]interface{}{
+		"type": "red",
+		"fins": []int{1, 2, 3},
+		"tank": map[string]interface{}{"size": 10, "a/b": "slash"},
+	}
+	if err := d.Write(collection, "red", record); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	patch := `[
+		{"op": "test", "path": "/type", "value": "red"},
+		{"op": "test", "path": "/tank/size", "value": 10.0},
+		{"op": "replace", "path": "/type", "value": "blue"},
+		{"op": "add", "path": "/fins/1", "value": 9},
+		{"op": "add", "path": "/fins/-", "value": 4},
+		{"op": "remove", "path": "/fins/0"},
+		{"op": "copy", "from": "/tank", "path": "/spare"},
+		{"op": "move", "from": "/tank/a~1b", "path": "/note"},
+		{"op": "add", "path": "/gone", "value": null}
+	]`
+	if err := d.ApplyJSONPatch(collection, "red", []byte(patch)); err != nil {
+		t.Fatal("ApplyJSONPatch failed: ", err.Error())
+	}
+
+	var got map[string]interface{}
+	if err := d.Read(collection, "red", &got); err != nil {
+		t.Fatal("Read fish failed: ", err.Error())
+	}
+
+	want := map[string]interface{}{
+		"type":  "blue",
+		"fins":  []interface{}{9.0, 2.0, 3.0, 4.0},
+		"tank":  map[string]interface{}{"size": 10.0},
+		"spare": map[string]interface{}{"size": 10.0, "a/b": "slash"},
+		"note":  "slash",
+		"gone":  nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("Expected patched record ", want, ", got: ", got)
+	}
+}
+
+func TestApplyJSONPatchFailures(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a failing test leaves the record untouched, even after earlier ops
+	patch := `[{"op": "replace", "path": "/type", "value": "blue"}, {"op": "test", "path": "/type", "value": "red"}]`
+	if err := d.ApplyJSONPatch(collection, "red", []byte(patch)); !errors.Is(err, ErrPatchTestFailed) {
+		t.Error("Expected ErrPatchTestFailed, got: ", err)
+	}
+
+	var fish Fish
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected the record untouched, got: ", fish, err)
+	}
+
+	for _, patch := range []string{
+		`[{"op": "remove", "path": "/missing"}]`,
+		`[{"op": "replace", "path": "type", "value": 1}]`,
+		`[{"op": "add", "path": "/type/x", "value": 1}]`,
+		`[{"op": "add", "path": "/a/b", "value": 1}]`,
+		`[{"op": "add", "path": "/type"}]`,
+		`[{"op": "move", "path": "/x"}]`,
+		`[{"op": "frobnicate", "path": "/type"}]`,
+		`{"op": "remove"}`,
+	} {
+		if err := d.ApplyJSONPatch(collection, "red", []byte(patch)); err == nil {
+			t.Error("Expected an error for patch ", patch)
+		}
+	}
+
+	if err := d.ApplyJSONPatch(collection, "missing", []byte(`[]`)); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+}
+
+func TestPatchArrays(t *testing.T) {
+	var doc interface{} = []interface{}{json.Number("1"), json.Number("2")}
+
+	bad := [][]string{{"01"}, {"-1"}, {"3"}, {"x"}}
+	for _, path := range bad {
+		if _, err := addValue(doc, path, "v"); err == nil {
+			t.Error("Expected an error adding at ", path)
+		}
+	}
+
+	doc, err := addValue(doc, []string{"2"}, "end")
+	if err != nil || len(doc.([]interface{})) != 3 {
+		t.Error("Expected to append at the array length, got: ", doc, err)
+	}
+
+	if _, err := replaceValue(doc, []string{"-"}, "v"); err == nil {
+		t.Error("Expected an error replacing at -")
+	}
+
+	if _, _, err := removeValue(doc, nil); err == nil {
+		t.Error("Expected an error removing the whole document")
+	}
+}
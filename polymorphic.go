@@ -0,0 +1,89 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownType is returned by ReadPolymorphic when a record's type isn't
+// in the registry
+var ErrUnknownType = errors.New("unknown record type")
+
+// ReadPolymorphic reads a record of a collection holding several record
+// shapes: it looks up the string in the record's top-level [typeField] in
+// [registry] and decodes the record into the value the constructor found
+// there returns, which is then returned; constructors should return
+// pointers. A record without the field, or with a type the registry doesn't
+// have, returns ErrUnknownType.
+func (d *Driver) ReadPolymorphic(collection, resource string, typeField string,
+	registry map[string]func() interface{}) (v interface{}, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "read", collection, resource)
+	defer d.countOp(collection, opRead, &err)
+
+	// ensure there is a place to read record from
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to read
+	if resource == "" {
+		return nil, ErrMissingResource
+	}
+
+	b, err := d.read(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// bring records stored by older versions of the program up to date
+	if b, err = d.migrateRecord(collection, resource, b, d.rewriteMigrated); err != nil {
+		return nil, err
+	}
+
+	kind, err := recordType(b, typeField)
+	if err != nil {
+		return nil, err
+	}
+
+	newValue, ok := registry[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %q", ErrUnknownType, typeField, kind)
+	}
+
+	v = newValue()
+	if err := d.unmarshal(b, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// recordType returns the string in the top-level [field] of the record [b]
+func recordType(b []byte, field string) (string, error) {
+	obj, ok, err := parseObject(b)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: the record isn't an object", ErrUnknownType)
+	}
+
+	for _, m := range obj {
+		if m.name != field {
+			continue
+		}
+
+		raw, _ := m.value.(json.RawMessage)
+
+		var kind string
+		if err := json.Unmarshal(raw, &kind); err != nil {
+			return "", fmt.Errorf("%w: %s is %s, not a string", ErrUnknownType, field, raw)
+		}
+		return kind, nil
+	}
+
+	return "", fmt.Errorf("%w: the record has no %s", ErrUnknownType, field)
+}
@@ -0,0 +1,56 @@
+package jsondb
+
+import (
+	"errors"
+	"testing"
+)
+
+type Shark struct {
+	Type  string `json:"type"`
+	Teeth int    `json:"teeth"`
+}
+
+func TestReadPolymorphic(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Write(collection, "shark", Shark{Type: "shark", Teeth: 300}); err != nil {
+		t.Fatal("Create shark failed: ", err.Error())
+	}
+
+	registry := map[string]func() interface{}{
+		"red":   func() interface{} { return &Fish{} },
+		"shark": func() interface{} { return &Shark{} },
+	}
+
+	v, err := d.ReadPolymorphic(collection, "shark", "type", registry)
+	if err != nil {
+		t.Fatal("Failed to read shark: ", err.Error())
+	}
+
+	if shark, ok := v.(*Shark); !ok || shark.Teeth != 300 {
+		t.Error("Expected a shark with 300 teeth, got: ", v)
+	}
+
+	v, err = d.ReadPolymorphic(collection, "redfish", "type", registry)
+	if err != nil {
+		t.Fatal("Failed to read fish: ", err.Error())
+	}
+
+	if fish, ok := v.(*Fish); !ok || *fish != redfish {
+		t.Error("Expected redfish, got: ", v)
+	}
+
+	// only registered types are decoded
+	delete(registry, "red")
+	if _, err := d.ReadPolymorphic(collection, "redfish", "type", registry); !errors.Is(err, ErrUnknownType) {
+		t.Error("Expected ErrUnknownType, got: ", err)
+	}
+
+	if _, err := d.ReadPolymorphic(collection, "shark", "kind", registry); !errors.Is(err, ErrUnknownType) {
+		t.Error("Expected ErrUnknownType for a missing field, got: ", err)
+	}
+}
@@ -0,0 +1,73 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// ReadInto read locks the collection and reads each resource named by a key of
+// [dest] into the pointer stored under that key. Resources that don't exist
+// leave their target untouched and are returned in sorted order as [missing].
+func (d *Driver) ReadInto(collection string, dest map[string]interface{}) (missing []string, err error) {
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names := make([]string, 0, len(dest))
+	for name := range dest {
+		// ensure there is a resource (name) to read
+		if name == "" {
+			return nil, ErrMissingResource
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	records, missing, err := d.readNames(collection, names)
+	if err != nil {
+		return missing, err
+	}
+
+	// decoded once the lock is released, as migrating may write
+	for _, name := range names {
+		b, ok := records[name]
+		if !ok {
+			continue
+		}
+
+		if err := d.decodeRecord(collection, name, b, dest[name]); err != nil {
+			return missing, fmt.Errorf("read %s/%s: %w", collection, name, err)
+		}
+	}
+
+	return missing, nil
+}
+
+// readNames read locks [collection] while reading the records [names], returning
+// them by name along with the names that don't exist
+func (d *Driver) readNames(collection string, names []string) (records map[string][]byte, missing []string, err error) {
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	records = make(map[string][]byte, len(names))
+	for _, name := range names {
+		b, err := d.read(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, missing, err
+		}
+
+		records[name] = b
+	}
+
+	return records, missing, nil
+}
@@ -0,0 +1,33 @@
+package jsondb
+
+import (
+	"testing"
+)
+
+func TestReadInto(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "blue"}} {
+		if err := d.Write(collection, f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	red, blue, green := Fish{}, Fish{}, Fish{Type: "unchanged"}
+	missing, err := d.ReadInto(collection, map[string]interface{}{
+		"red":   &red,
+		"blue":  &blue,
+		"green": &green,
+	})
+	if err != nil {
+		t.Fatal("Failed to read: ", err.Error())
+	}
+
+	if red.Type != "red" || blue.Type != "blue" {
+		t.Error("Expected red and blue fish, got: ", red.Type, blue.Type)
+	}
+
+	if len(missing) != 1 || missing[0] != "green" || green.Type != "unchanged" {
+		t.Error("Expected green fish reported missing and untouched, got: ", missing, green.Type)
+	}
+}
@@ -0,0 +1,137 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ReconcilePolicy decides how Reconcile resolves a conflict
+type ReconcilePolicy int
+
+const (
+	// ReconcileReport only reports conflicts, leaving the files alone
+	ReconcileReport ReconcilePolicy = iota
+	// ReconcilePreferCommitted keeps the committed record and removes the temp file
+	ReconcilePreferCommitted
+	// ReconcilePreferNewest keeps whichever of the two was modified last
+	ReconcilePreferNewest
+)
+
+// Conflict is a record whose leftover temp file holds different contents
+type Conflict struct {
+	Resource  string
+	Committed time.Time // when the committed record was last modified
+	Temp      time.Time // when the temp file was last modified
+	Kept      string    // "committed" or "temp" once resolved, empty otherwise
+}
+
+// Reconcile locks the collection and reports every record whose temp file,
+// left behind by a crash or a failed rename, differs from the committed
+// record. Conflicts are resolved according to Options.ReconcilePolicy; temp
+// files matching their record, or without one, are left to CleanTempFiles.
+func (d *Driver) Reconcile(collection string) (conflicts []Conflict, err error) {
+	defer wrapOpError(&err, "reconcile", collection, "")
+
+	// ensure there is a collection to reconcile
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	files, err := os.ReadDir(d.collectionDir(collection))
+	if err != nil {
+		// nothing to reconcile in a collection that doesn't exist
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, name := range d.recordNames(d.collectionDir(collection), files) {
+		c, ok, err := d.reconcile(collection, name)
+		if err != nil {
+			return conflicts, &OpError{Op: "reconcile", Collection: collection, Resource: name, Err: err}
+		}
+
+		if ok {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// reconcile compares [resource] with its temp file and resolves a conflict
+// between them according to the policy
+func (d *Driver) reconcile(collection, resource string) (Conflict, bool, error) {
+	fnlPath := d.recordPath(collection, resource)
+	tmpPath := fnlPath + tmpSuffix
+
+	tmpInfo, err := os.Stat(tmpPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Conflict{}, false, nil
+	}
+	if err != nil {
+		return Conflict{}, false, err
+	}
+
+	fnlInfo, err := os.Stat(fnlPath)
+	if err != nil {
+		return Conflict{}, false, err
+	}
+
+	committed, err := d.readConflictFile(collection, fnlPath)
+	if err != nil {
+		return Conflict{}, false, err
+	}
+
+	temp, err := d.readConflictFile(collection, tmpPath)
+	if err != nil {
+		return Conflict{}, false, err
+	}
+
+	if bytes.Equal(committed, temp) {
+		return Conflict{}, false, nil
+	}
+
+	c := Conflict{Resource: resource, Committed: fnlInfo.ModTime(), Temp: tmpInfo.ModTime()}
+
+	switch {
+	case d.reconcilePolicy == ReconcileReport:
+		return c, true, nil
+
+	case d.reconcilePolicy == ReconcilePreferNewest && c.Temp.After(c.Committed):
+		if err := os.Rename(tmpPath, fnlPath); err != nil {
+			return c, true, err
+		}
+		d.cache.invalidate(collection, resource)
+		c.Kept = "temp"
+
+		return c, true, d.recordChanged(collection, resource)
+	}
+
+	if err := os.Remove(tmpPath); err != nil {
+		return c, true, err
+	}
+	c.Kept = "committed"
+
+	return c, true, nil
+}
+
+// readConflictFile reads and decodes a record or temp file at [path]
+func (d *Driver) readConflictFile(collection, path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.decode(collection, b)
+}
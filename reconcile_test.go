@@ -0,0 +1,69 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// leaveTemp writes a temp file next to [resource] as an interrupted write would
+func leaveTemp(t *testing.T, d *Driver, resource, contents string, modTime time.Time) {
+	t.Helper()
+
+	path := d.recordPath(collection, resource) + tmpSuffix
+	if err := os.WriteFile(path, []byte(contents), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	for _, tt := range []struct {
+		policy ReconcilePolicy
+		kept   map[string]string
+		types  map[string]string
+	}{
+		{ReconcileReport, map[string]string{"old": "", "new": ""}, map[string]string{"old": "red", "new": "red"}},
+		{ReconcilePreferCommitted, map[string]string{"old": "committed", "new": "committed"}, map[string]string{"old": "red", "new": "red"}},
+		{ReconcilePreferNewest, map[string]string{"old": "committed", "new": "temp"}, map[string]string{"old": "red", "new": "blue"}},
+	} {
+		d := newTestDB(t, &Options{ReconcilePolicy: tt.policy})
+
+		for _, name := range []string{"old", "new", "same"} {
+			if err := d.Write(collection, name, redfish); err != nil {
+				t.Fatal("Create fish failed: ", err.Error())
+			}
+		}
+
+		leaveTemp(t, d, "old", `{"type":"blue"}`, time.Now().Add(-time.Hour))
+		leaveTemp(t, d, "new", `{"type":"blue"}`, time.Now().Add(time.Hour))
+		leaveTemp(t, d, "same", `{"type":"red"}`, time.Now())
+
+		conflicts, err := d.Reconcile(collection)
+		if err != nil {
+			t.Fatal("Reconcile failed: ", err.Error())
+		}
+
+		if len(conflicts) != 2 {
+			t.Fatal("Expected 2 conflicts, got: ", conflicts)
+		}
+
+		for _, c := range conflicts {
+			if c.Kept != tt.kept[c.Resource] {
+				t.Error("Expected ", c.Resource, " to keep ", tt.kept[c.Resource], ", got: ", c.Kept)
+			}
+
+			fish := Fish{}
+			if err := d.Read(collection, c.Resource, &fish); err != nil || fish.Type != tt.types[c.Resource] {
+				t.Error("Expected ", c.Resource, " to be ", tt.types[c.Resource], ", got: ", fish.Type, err)
+			}
+
+			_, err := os.Stat(d.recordPath(collection, c.Resource) + tmpSuffix)
+			if resolved := c.Kept != ""; resolved != os.IsNotExist(err) {
+				t.Error("Expected the temp file of ", c.Resource, " to be removed only when resolved, got: ", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// RenameSafe locks both collections and moves the record [resource] of
+// [collection] to [newResource] of [newCollection], which may be the same
+// collection. The record is first linked under its new name, or, when the
+// collections are on different file systems (EXDEV), copied there by way of
+// a temp file, and only then removed under the old one, so a crash leaves it
+// under both names at worst and never loses it. A missing record returns
+// ErrNotFound and an existing destination ErrAlreadyExists.
+func (d *Driver) RenameSafe(collection, resource, newCollection, newResource string) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+	newCollection, newResource = d.normalize(newCollection), d.normalize(newResource)
+
+	defer wrapOpError(&err, "rename", collection, resource)
+
+	// ensure there are places to move the record between
+	if collection == "" || newCollection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there are resources (names) to move the record between
+	if resource == "" || newResource == "" {
+		return ErrMissingResource
+	}
+
+	unlock, err := d.lockCollections(collection, newCollection)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+	defer d.cache.invalidate(newCollection, newResource)
+
+	return d.move(collection, resource, newCollection, newResource)
+}
+
+// move relocates a record the way RenameSafe does; the caller holds both
+// collection locks
+func (d *Driver) move(collection, resource, newCollection, newResource string) (err error) {
+	src, dst := d.recordPath(collection, resource), d.recordPath(newCollection, newResource)
+
+	if _, err := os.Lstat(src); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	// renaming a record to itself changes nothing
+	if src == dst {
+		return nil
+	}
+
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%w: %s/%s", ErrAlreadyExists, newCollection, newResource)
+	}
+
+	if err := d.ensureDir(newCollection); err != nil {
+		return err
+	}
+
+	// content addressed records are relative symlinks, which only stay valid
+	// when they're created anew next to their new name
+	if d.contentAddressed {
+		err = d.copyRecord(collection, resource, newCollection, newResource)
+	} else if err = os.Link(src, dst); errors.Is(err, syscall.EXDEV) {
+		err = d.copyRecord(collection, resource, newCollection, newResource)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the new name only survives a crash once its directory is flushed
+	if d.durable {
+		if err := syncFile(filepath.Dir(dst)); err != nil {
+			return err
+		}
+	}
+
+	if err := d.unbury(newCollection, newResource); err != nil {
+		return err
+	}
+
+	if err := d.recordChanged(newCollection, newResource); err != nil {
+		return err
+	}
+
+	return d.remove(collection, resource)
+}
+
+// copyRecord atomically stores the record [resource] of [collection] as
+// [newResource] of [newCollection], encoded the way the new collection stores
+// records; the caller holds both collection locks
+func (d *Driver) copyRecord(collection, resource, newCollection, newResource string) error {
+	b, err := os.ReadFile(d.recordPath(collection, resource))
+	if err != nil {
+		return err
+	}
+
+	if b, err = d.decode(collection, b); err != nil {
+		return err
+	}
+
+	if b, err = d.encode(newCollection, b); err != nil {
+		return err
+	}
+
+	dst := d.recordPath(newCollection, newResource)
+	return d.writeBytes(dst+tmpSuffix, dst, b)
+}
+
+// MoveWhere locks both collections and moves every record of [srcCollection]
+// that [pred] accepts, given its resource name and raw JSON, to the same
+// name in [dstCollection], the way RenameSafe moves records, returning how
+// many were moved. The first error from [pred] or from moving a record, like
+// ErrAlreadyExists for a name the destination already holds, stops the move,
+// leaving the records moved so far in the destination.
+func (d *Driver) MoveWhere(srcCollection, dstCollection string, pred func(resource string, raw []byte) (bool, error)) (moved int, err error) {
+	srcCollection, dstCollection = d.normalize(srcCollection), d.normalize(dstCollection)
+
+	defer wrapOpError(&err, "movewhere", srcCollection, "")
+
+	// ensure there are places to move records between
+	if srcCollection == "" || dstCollection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	// moving records onto themselves changes nothing
+	if srcCollection == dstCollection {
+		return 0, nil
+	}
+
+	unlock, err := d.lockCollections(srcCollection, dstCollection)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	defer d.cache.invalidate(srcCollection, "")
+	defer d.cache.invalidate(dstCollection, "")
+
+	// seed records only exist in the source fs and can't be moved
+	names, err := d.listDisk(srcCollection)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, name := range names {
+		b, err := d.readFile(srcCollection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return moved, &OpError{Op: "read", Collection: srcCollection, Resource: name, Err: err}
+		}
+
+		ok, err := pred(name, b)
+		if err != nil {
+			return moved, err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := d.move(srcCollection, name, dstCollection, name); err != nil {
+			return moved, &OpError{Op: "move", Collection: srcCollection, Resource: name, Err: err}
+		}
+		moved++
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,117 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenameSafe(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.RenameSafe(collection, "red", "tank", "nemo"); err != nil {
+		t.Fatal("Failed to rename fish: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read("tank", "nemo", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish under its new name, got: ", fish.Type, err)
+	}
+
+	if err := d.Read(collection, "red", &Fish{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected red fish to be gone under its old name, got: ", err)
+	}
+
+	// an existing destination is never overwritten
+	if err := d.RenameSafe(collection, "blue", "tank", "nemo"); !errors.Is(err, ErrAlreadyExists) {
+		t.Error("Expected ErrAlreadyExists, got: ", err)
+	}
+
+	if err := d.Read(collection, "blue", &Fish{}); err != nil {
+		t.Error("Expected blue fish to stay, got: ", err)
+	}
+
+	if err := d.RenameSafe(collection, "red", "tank", "dory"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+}
+
+func TestRenameSafeCopy(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection("tank", CollectionConfig{Compress: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the copy used across file systems stores the record the new way
+	if err := d.copyRecord(collection, "red", "tank", "nemo"); err != nil {
+		t.Fatal("Failed to copy fish: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read("tank", "nemo", &fish); err != nil || fish != redfish {
+		t.Error("Expected red fish in the tank, got: ", fish, err)
+	}
+
+	if b, err := os.ReadFile(d.recordPath("tank", "nemo")); err != nil || !isGzip(b) {
+		t.Error("Expected the copy to be compressed, got: ", err)
+	}
+}
+
+func TestMoveWhere(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"red", "blue", "darkred"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	reddish := func(resource string, raw []byte) (bool, error) {
+		fish := Fish{}
+		if err := json.Unmarshal(raw, &fish); err != nil {
+			return false, err
+		}
+		return strings.HasSuffix(fish.Type, "red"), nil
+	}
+
+	moved, err := d.MoveWhere(collection, "archive", reddish)
+	if err != nil || moved != 2 {
+		t.Fatal("Expected 2 fish moved, got: ", moved, err)
+	}
+
+	for _, name := range []string{"red", "darkred"} {
+		if err := d.Read("archive", name, &Fish{}); err != nil {
+			t.Error("Expected ", name, " fish in the archive, got: ", err)
+		}
+		if err := d.Read(collection, name, &Fish{}); !errors.Is(err, fs.ErrNotExist) {
+			t.Error("Expected ", name, " fish to leave the collection, got: ", err)
+		}
+	}
+
+	if err := d.Read(collection, "blue", &Fish{}); err != nil {
+		t.Error("Expected blue fish to stay, got: ", err)
+	}
+
+	// names the destination already holds stop the move
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if _, err := d.MoveWhere(collection, "archive", reddish); !errors.Is(err, ErrAlreadyExists) {
+		t.Error("Expected ErrAlreadyExists, got: ", err)
+	}
+}
@@ -0,0 +1,96 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// replicaQueueSize bounds how many changes wait to be copied to the replica
+const replicaQueueSize = 1024
+
+// replicaOp asks for the current state of a record, or with an empty
+// resource the removal of a whole collection, to be copied to the replica;
+// an op with a done channel only marks a point in the queue
+type replicaOp struct {
+	collection string
+	resource   string
+	done       chan struct{}
+}
+
+// replicator copies changes to Options.ReplicaDir in the background
+type replicator struct {
+	dir   string
+	queue chan replicaOp
+}
+
+// replicate queues the change of [resource] for the replica, if there is
+// one; with the queue full the change is dropped and logged, since the
+// replica is best effort and mustn't hold up writes
+func (d *Driver) replicate(collection, resource string) {
+	if d.replica == nil {
+		return
+	}
+
+	select {
+	case d.replica.queue <- replicaOp{collection: collection, resource: resource}:
+	default:
+		d.log("Replica queue full, dropping '%s/%s'\n", collection, resource)
+	}
+}
+
+// waitReplica blocks until the changes queued so far reached the replica
+func (d *Driver) waitReplica() {
+	if d.replica == nil {
+		return
+	}
+
+	done := make(chan struct{})
+	d.replica.queue <- replicaOp{done: done}
+	<-done
+}
+
+// runReplica copies the queued changes to the replica until the queue is
+// closed, logging those that fail
+func (d *Driver) runReplica() {
+	for op := range d.replica.queue {
+		if op.done != nil {
+			close(op.done)
+			continue
+		}
+
+		if err := d.applyReplica(op.collection, op.resource); err != nil {
+			d.log("Unable to replicate '%s/%s': %v\n", op.collection, op.resource, err)
+		}
+	}
+}
+
+// applyReplica brings the replica's copy of [resource] in line with the
+// database, copying the file as it's stored or removing it when it's gone
+func (d *Driver) applyReplica(collection, resource string) error {
+	dir := filepath.Join(d.replica.dir, d.collectionPath(collection))
+	if resource == "" {
+		return os.RemoveAll(dir)
+	}
+
+	dst := filepath.Join(dir, d.fileName(resource))
+
+	// the record is read as it's now, so a later change is never undone
+	b, err := os.ReadFile(d.recordPath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dst, b)
+}
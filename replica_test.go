@@ -0,0 +1,55 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplicaDir(t *testing.T) {
+	replica := filepath.Join(t.TempDir(), "replica")
+	d := newTestDB(t, &Options{ReplicaDir: replica})
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.Delete(collection, "blue"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	d.waitReplica()
+
+	b, err := os.ReadFile(filepath.Join(replica, collection, "red"))
+	if err != nil || string(b) != `{"type":"red"}` {
+		t.Error("Expected red fish in the replica, got: ", string(b), err)
+	}
+
+	if _, err := os.Stat(filepath.Join(replica, collection, "blue")); !os.IsNotExist(err) {
+		t.Error("Expected blue fish to be removed from the replica, got: ", err)
+	}
+
+	// the replica reads like the database
+	mirrored, err := New(t.TempDir(), &Options{ReadMirrors: []string{replica}, Debug: t.Logf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fish := Fish{}
+	if err := mirrored.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish through the replica, got: ", fish.Type, err)
+	}
+
+	// dropping the collection drops it from the replica too
+	if err := d.Delete(collection, ""); err != nil {
+		t.Fatal("Delete collection failed: ", err.Error())
+	}
+
+	d.waitReplica()
+
+	if _, err := os.Stat(filepath.Join(replica, collection)); !os.IsNotExist(err) {
+		t.Error("Expected the collection to be removed from the replica, got: ", err)
+	}
+}
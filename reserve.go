@@ -0,0 +1,79 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Reserve claims the name [resource] by creating an empty placeholder for
+// it, failing with ErrAlreadyExists if a record or another reservation has it
+// already. Writing the resource replaces the placeholder; calling [release]
+// before that gives the name up again, and does nothing after. Placeholders
+// are left out of ReadAll, and Read reports them as ErrEmptyRecord.
+func (d *Driver) Reserve(collection, resource string) (release func(), err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "reserve", collection, resource)
+
+	// ensure there is a place to reserve the name in
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to reserve
+	if resource == "" {
+		return nil, ErrMissingResource
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// seed records are taken even though there's no file for them
+	if _, err := d.statRecord(collection, resource); !errors.Is(err, ErrNotFound) {
+		if err == nil {
+			err = ErrAlreadyExists
+		}
+		return nil, err
+	}
+
+	if err := d.ensureDir(collection); err != nil {
+		return nil, err
+	}
+
+	path := d.recordPath(collection, resource)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileMode)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { d.release(collection, resource) })
+	}, nil
+}
+
+// release removes the placeholder of [resource] unless it has been written
+func (d *Driver) release(collection, resource string) {
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	path := d.recordPath(collection, resource)
+	if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() && info.Size() == 0 {
+		os.Remove(path)
+	}
+}
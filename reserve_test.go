@@ -0,0 +1,58 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReserve(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	release, err := d.Reserve(collection, "blue")
+	if err != nil {
+		t.Fatal("Reserve failed: ", err.Error())
+	}
+
+	// a name can only be reserved once, and not when it's a record
+	if _, err := d.Reserve(collection, "blue"); !errors.Is(err, ErrAlreadyExists) {
+		t.Error("Expected ErrAlreadyExists, got: ", err)
+	}
+	if _, err := d.Reserve(collection, "red"); !errors.Is(err, ErrAlreadyExists) {
+		t.Error("Expected ErrAlreadyExists, got: ", err)
+	}
+
+	// placeholders aren't records
+	if records, err := d.ReadAll(collection); err != nil || len(records) != 1 {
+		t.Error("Expected only the red fish, got: ", records, err)
+	}
+
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// releasing after the write keeps the record
+	release()
+	fish := Fish{}
+	if err := d.Read(collection, "blue", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected the blue fish, got: ", fish, err)
+	}
+
+	// a release before any write frees the name
+	release, err = d.Reserve(collection, "green")
+	if err != nil {
+		t.Fatal("Reserve failed: ", err.Error())
+	}
+	release()
+
+	if _, err := os.Stat(d.recordPath(collection, "green")); !os.IsNotExist(err) {
+		t.Error("Expected the placeholder removed, got: ", err)
+	}
+	if _, err := d.Reserve(collection, "green"); err != nil {
+		t.Error("Expected the name free again, got: ", err)
+	}
+}
@@ -0,0 +1,86 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// RecordResult is the outcome of reading a single record into a T
+type RecordResult[T any] struct {
+	Name  string
+	Value T
+	Err   error // why the record couldn't be read or decoded, if it couldn't
+}
+
+// ReadAllResults reads every record of [collection] in sorted resource order
+// into a T, reporting a record that can't be read or decoded in its result
+// rather than failing the whole read. Only failing to list the collection
+// returns an error. Collection.All is the all-or-nothing counterpart.
+func ReadAllResults[T any](d *Driver, collection string) (results []RecordResult[T], err error) {
+	defer wrapOpError(&err, "readall", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]RecordResult[T], len(names))
+	for i, name := range names {
+		r := &results[i]
+		r.Name = name
+
+		b, err := d.read(collection, name)
+		if err == nil {
+			err = d.decodeRecord(collection, name, b, &r.Value)
+		}
+		if err != nil {
+			r.Err = &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+	}
+
+	return results, nil
+}
+
+// ReadAllLenient is ReadAll keeping going past records that can't be read or
+// aren't valid JSON: it returns every good record, in sorted resource order,
+// along with an OpError naming each record that failed. Failing to list the
+// collection yields no records and that one problem. [problems] is nil when
+// every record was read.
+func (d *Driver) ReadAllLenient(collection string) (records [][]byte, problems []error) {
+	collection = d.normalize(collection)
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, []error{&OpError{Op: "readall", Collection: collection, Err: ErrMissingCollection}}
+	}
+
+	names, err := d.list(collection)
+	if err != nil {
+		return nil, []error{&OpError{Op: "readall", Collection: collection, Err: err}}
+	}
+
+	for _, name := range names {
+		b, err := d.readMirrored(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(b) == 0) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+		if err == nil {
+			var v interface{}
+			err = d.unmarshal(b, &v)
+		}
+		if err != nil {
+			problems = append(problems, &OpError{Op: "read", Collection: collection, Resource: name, Err: err})
+			continue
+		}
+
+		records = append(records, b)
+	}
+
+	return records, problems
+}
@@ -0,0 +1,74 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReadAllResults(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// one corrupt record doesn't hide the others
+	if err := os.WriteFile(d.recordPath(collection, "b"), []byte("{not json"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ReadAllResults[Fish](d, collection)
+	if err != nil || len(results) != 3 {
+		t.Fatal("Expected 3 results, got: ", results, err)
+	}
+
+	for _, r := range results {
+		switch {
+		case r.Name == "b" && r.Err == nil:
+			t.Error("Expected a decode error for b")
+		case r.Name != "b" && (r.Err != nil || r.Value.Type != r.Name):
+			t.Error("Expected fish ", r.Name, ", got: ", r.Value, r.Err)
+		}
+	}
+
+	if _, err := ReadAllResults[Fish](d, "missing"); err == nil {
+		t.Error("Expected an error listing a missing collection")
+	}
+}
+
+func TestReadAllLenient(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	records, problems := d.ReadAllLenient(collection)
+	if len(records) != 3 || problems != nil {
+		t.Fatal("Expected 3 records and no problems, got: ", len(records), problems)
+	}
+
+	// one corrupt record doesn't hide the others
+	if err := os.WriteFile(d.recordPath(collection, "b"), []byte("{not json"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	records, problems = d.ReadAllLenient(collection)
+	if len(records) != 2 || len(problems) != 1 {
+		t.Fatal("Expected 2 records and 1 problem, got: ", len(records), problems)
+	}
+
+	var oe *OpError
+	if !errors.As(problems[0], &oe) || oe.Resource != "b" {
+		t.Error("Expected the problem to name b, got: ", problems[0])
+	}
+
+	if _, problems := d.ReadAllLenient("missing"); len(problems) != 1 {
+		t.Error("Expected a problem listing a missing collection, got: ", problems)
+	}
+}
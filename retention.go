@@ -0,0 +1,82 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy describes which records of a collection are kept by
+// ApplyRetention. A zero value field disables that rule.
+type RetentionPolicy struct {
+	MaxRecords int           // keep at most this many records, newest first
+	MaxAge     time.Duration // remove records last modified longer ago than this
+}
+
+// ApplyRetention locks the collection and removes every record that falls
+// outside the [policy], returning how many records were deleted. The records
+// of a single file collection share the file's modification time, so MaxAge
+// removes them together and MaxRecords keeps the first in resource order.
+func (d *Driver) ApplyRetention(collection string, policy RetentionPolicy) (deleted int, err error) {
+	// ensure there is a collection to trim
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		// nothing to trim in a collection that doesn't exist
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	type record struct {
+		name    string
+		modTime time.Time
+	}
+
+	records := make([]record, 0, len(names))
+	for _, name := range names {
+		info, err := d.statRecord(collection, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, &OpError{Op: "stat", Collection: collection, Resource: name, Err: err}
+		}
+
+		records = append(records, record{name: name, modTime: info.ModTime()})
+	}
+
+	// newest records first so the ones beyond MaxRecords are the oldest
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].modTime.After(records[j].modTime)
+	})
+
+	cutoff := time.Now().Add(-policy.MaxAge)
+	for i, r := range records {
+		tooMany := policy.MaxRecords > 0 && i >= policy.MaxRecords
+		tooOld := policy.MaxAge > 0 && r.modTime.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		err := d.remove(collection, r.name)
+		d.cache.invalidate(collection, r.name)
+		if err != nil {
+			return deleted, &OpError{Op: "delete", Collection: collection, Resource: r.name, Err: err}
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,93 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplyRetention(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// write three fish with increasingly recent modification times
+	now := time.Now()
+	for i, name := range []string{"old", "middle", "new"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		mtime := now.Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(filepath.Join(d.dir, collection, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// keep only the two newest
+	deleted, err := d.ApplyRetention(collection, RetentionPolicy{MaxRecords: 2})
+	if err != nil {
+		t.Fatal("Failed to apply retention: ", err.Error())
+	}
+
+	if deleted != 1 {
+		t.Error("Expected 1 deleted fish, got: ", deleted)
+	}
+
+	if err := d.Read(collection, "old", &Fish{}); err == nil {
+		t.Error("Expected old fish to be removed")
+	}
+
+	// drop anything older than 90 minutes
+	deleted, err = d.ApplyRetention(collection, RetentionPolicy{MaxAge: 90 * time.Minute})
+	if err != nil {
+		t.Fatal("Failed to apply retention: ", err.Error())
+	}
+
+	if deleted != 1 {
+		t.Error("Expected 1 deleted fish, got: ", deleted)
+	}
+
+	if err := d.Read(collection, "new", &Fish{}); err != nil {
+		t.Error("Expected new fish to be kept: ", err.Error())
+	}
+
+	// a missing collection has nothing to trim
+	if deleted, err := d.ApplyRetention("missing", RetentionPolicy{MaxRecords: 1}); err != nil || deleted != 0 {
+		t.Error("Expected nothing deleted from missing collection, got: ", deleted, err)
+	}
+}
+
+func TestApplyRetentionSingleFile(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{SingleFile: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+	for _, name := range []string{"red", "blue", "green"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// the records share a modification time, so the first ones are kept
+	deleted, err := d.ApplyRetention(collection, RetentionPolicy{MaxRecords: 2})
+	if err != nil || deleted != 1 {
+		t.Fatal("Expected 1 deleted fish, got: ", deleted, err)
+	}
+	if names, err := d.Keys(collection); err != nil || len(names) != 2 || names[0] != "blue" || names[1] != "green" {
+		t.Error("Expected blue and green to be kept, got: ", names, err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(d.singlePath(collection), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err = d.ApplyRetention(collection, RetentionPolicy{MaxAge: time.Hour})
+	if err != nil || deleted != 2 {
+		t.Error("Expected 2 deleted fish, got: ", deleted, err)
+	}
+	if n, err := d.Count(collection); err != nil || n != 0 {
+		t.Error("Expected no fish left, got: ", n, err)
+	}
+}
@@ -0,0 +1,82 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// revField is the top-level member holding the revision of a record written
+// by WriteWithRev
+const revField = "_rev"
+
+// ErrRevConflict is returned by WriteWithRev when the stored record isn't at
+// the expected revision
+var ErrRevConflict = errors.New("revision conflict - the record was changed since it was read")
+
+// WriteWithRev locks [resource] and stores [v] as the record only if the
+// stored one is at revision [expectedRev], returning ErrRevConflict
+// otherwise, and the revision it stored the record at on success. The
+// revision is kept in the "_rev" member of the record, which the driver sets
+// to one past the stored revision whatever [v] holds; a record that doesn't
+// exist, or was only ever written without WriteWithRev, is at revision 0.
+// Once a record has a revision every write moves it on, be it by Write,
+// WriteAll, WriteBatch, SetField or Upsert, so a writer holding a stale
+// revision always conflicts. Read the revision back with a field tagged
+// `json:"_rev"`. [v] must marshal to a JSON object, and the conflict
+// resolver isn't consulted, the revision check being the conflict
+// detection.
+func (d *Driver) WriteWithRev(collection, resource string, v interface{}, expectedRev int) (newRev int, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "write", collection, resource)
+	defer d.countOp(collection, opWrite, &err)
+
+	// ensure there is a place to save record
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save record as
+	if resource == "" {
+		return 0, ErrMissingResource
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	rev := 0
+	b, err := d.readFile(collection, resource)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return 0, err
+	case len(b) > 0:
+		if rev, err = intMember(b, revField, "record revision"); err != nil {
+			return 0, err
+		}
+	}
+
+	if rev != expectedRev {
+		return 0, fmt.Errorf("%w: %s is at revision %d, not %d", ErrRevConflict, resource, rev, expectedRev)
+	}
+
+	if b, err = d.marshalStamped(collection, resource, v, stamps{rev: rev + 1}); err != nil {
+		return 0, err
+	}
+
+	// only an object has a member to keep the revision in
+	if _, ok, err := parseObject(b); err != nil || !ok {
+		return 0, ErrInvalidRecordShape
+	}
+
+	if err := d.writeRaw(collection, resource, b); err != nil {
+		return 0, err
+	}
+
+	return rev + 1, nil
+}
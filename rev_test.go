@@ -0,0 +1,128 @@
+package jsondb
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type revFish struct {
+	Type string `json:"type"`
+	Rev  int    `json:"_rev"`
+}
+
+func TestWriteWithRev(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	rev, err := d.WriteWithRev(collection, "red", revFish{Type: "red"}, 0)
+	if err != nil || rev != 1 {
+		t.Fatal("Expected revision 1, got: ", rev, err)
+	}
+
+	fish := revFish{}
+	if err := d.Read(collection, "red", &fish); err != nil {
+		t.Fatal("Failed to read fish: ", err.Error())
+	}
+	if fish.Rev != 1 {
+		t.Error("Expected the stored revision 1, got: ", fish.Rev)
+	}
+
+	// the revision the value holds doesn't matter, only the expected one
+	fish.Type = "crimson"
+	fish.Rev = 7
+	if rev, err = d.WriteWithRev(collection, "red", fish, 1); err != nil || rev != 2 {
+		t.Fatal("Expected revision 2, got: ", rev, err)
+	}
+
+	if _, err := d.WriteWithRev(collection, "red", revFish{Type: "stale"}, 1); !errors.Is(err, ErrRevConflict) {
+		t.Error("Expected ErrRevConflict, got: ", err)
+	}
+
+	fish = revFish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish != (revFish{Type: "crimson", Rev: 2}) {
+		t.Error("Expected the conflicting write to change nothing, got: ", fish, err)
+	}
+
+	if _, err := d.WriteWithRev(collection, "list", []string{"red"}, 0); !errors.Is(err, ErrInvalidRecordShape) {
+		t.Error("Expected ErrInvalidRecordShape, got: ", err)
+	}
+}
+
+func TestWriteWithRevConcurrent(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// of the writers expecting the same revision exactly one wins
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	won := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.WriteWithRev(collection, "red", revFish{Type: "red"}, 0)
+			if err == nil {
+				mutex.Lock()
+				won++
+				mutex.Unlock()
+			} else if !errors.Is(err, ErrRevConflict) {
+				t.Error("Expected ErrRevConflict, got: ", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if won != 1 {
+		t.Error("Expected one winning write, got: ", won)
+	}
+}
+
+func TestRevCarriedByEveryWrite(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if _, err := d.WriteWithRev(collection, "red", revFish{Type: "red"}, 0); err != nil {
+		t.Fatal("Failed to write fish: ", err.Error())
+	}
+
+	// a plain write moves the revision on, whatever the value holds
+	if err := d.Write(collection, "red", revFish{Type: "red"}); err != nil {
+		t.Fatal("Failed to write fish: ", err.Error())
+	}
+	if _, err := d.WriteWithRev(collection, "red", revFish{Type: "red"}, 1); !errors.Is(err, ErrRevConflict) {
+		t.Error("Expected ErrRevConflict after a plain write, got: ", err)
+	}
+
+	writes := []func() error{
+		func() error {
+			_, err := d.WriteWithRev(collection, "red", revFish{Type: "red"}, 2)
+			return err
+		},
+		func() error { return d.SetField(collection, "red", "type", "crimson") },
+		func() error { return d.WriteAll(collection, map[string]interface{}{"red": revFish{Type: "red"}}) },
+		func() error { return d.WriteBatch(collection, map[string]interface{}{"red": revFish{Type: "red"}}) },
+		func() error { return WriteBatchUpsert(d, collection, map[string]revFish{"red": {Type: "red"}}, nil) },
+	}
+	for i, write := range writes {
+		if err := write(); err != nil {
+			t.Fatal("Failed to write fish: ", err.Error())
+		}
+
+		fish := revFish{}
+		if err := d.Read(collection, "red", &fish); err != nil {
+			t.Fatal("Failed to read fish: ", err.Error())
+		}
+		if fish.Rev != i+3 {
+			t.Errorf("Expected revision %d after write %d, got: %d", i+3, i, fish.Rev)
+		}
+	}
+
+	// a record never written with a revision doesn't get one
+	if err := d.Write(collection, "blue", revFish{Type: "blue"}); err != nil {
+		t.Fatal("Failed to write fish: ", err.Error())
+	}
+	if err := d.Write(collection, "blue", revFish{Type: "blue"}); err != nil {
+		t.Fatal("Failed to write fish: ", err.Error())
+	}
+	if rev, err := d.WriteWithRev(collection, "blue", revFish{Type: "blue"}, 0); err != nil || rev != 1 {
+		t.Error("Expected revision 1, got: ", rev, err)
+	}
+}
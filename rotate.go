@@ -0,0 +1,109 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// rotateLayout is the timestamp appended to the archive prefix by Rotate;
+// archives of the same prefix sort by when they were rotated
+const rotateLayout = "20060102T150405.000000000Z"
+
+// Rotate locks the collection and renames it to [archivePrefix] followed by
+// the current UTC time, then starts a fresh empty collection in its place, so
+// writes from then on go to the fresh collection while the archive stays
+// readable as a collection of its own. The fresh collection keeps the
+// settings of ConfigureCollection, the schema of InitSchema and the next
+// offset of a Log, so its offsets keep growing; indexes, the manifest and
+// nested collections move to the archive. Rotating a collection that doesn't
+// exist does nothing.
+func (d *Driver) Rotate(collection string, archivePrefix string) (err error) {
+	collection, archivePrefix = d.normalize(collection), d.normalize(archivePrefix)
+
+	defer wrapOpError(&err, "rotate", collection, "")
+
+	// ensure there is a collection to rotate
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a name to archive it as
+	if archivePrefix == "" {
+		return ErrMissingCollection
+	}
+
+	archive := archivePrefix + time.Now().UTC().Format(rotateLayout)
+
+	unlock, err := d.lockCollections(collection, archive)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dir := d.collectionDir(collection)
+	archiveDir := d.collectionDir(archive)
+
+	if _, err := os.Stat(dir); err != nil {
+		// nothing to rotate in a collection that doesn't exist
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := os.Lstat(archiveDir); err == nil {
+		return fmt.Errorf("%w: collection %s", ErrAlreadyExists, archive)
+	}
+
+	// the settings, schema and log offset are carried over to the fresh
+	// collection below
+	kept := make(map[string][]byte)
+	for _, name := range []string{configName, schemaName, logOffsetName} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		kept[name] = b
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archiveDir), dirMode); err != nil {
+		return err
+	}
+
+	if err := os.Rename(dir, archiveDir); err != nil {
+		return err
+	}
+
+	d.cache.invalidate(collection, "")
+	d.forgetDirs(collection)
+	d.blooms.forget(collection)
+
+	if err := d.ensureDir(collection); err != nil {
+		return err
+	}
+
+	for name, b := range kept {
+		if err := writeFileAtomic(filepath.Join(dir, name), b); err != nil {
+			return err
+		}
+	}
+
+	// the renamed directory only survives a crash once its parents are flushed
+	if d.durable {
+		if err := syncFile(filepath.Dir(dir)); err != nil {
+			return err
+		}
+		if err := syncFile(filepath.Dir(archiveDir)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
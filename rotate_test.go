@@ -0,0 +1,89 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{Compress: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Rotate(collection, "fish-"); err != nil {
+		t.Fatal("Failed to rotate collection: ", err.Error())
+	}
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "fish-") {
+			archives = append(archives, entry.Name())
+		}
+	}
+
+	if len(archives) != 1 {
+		t.Fatal("Expected one archive, got: ", archives)
+	}
+
+	// the archive still holds the old record
+	fish := Fish{}
+	if err := d.Read(archives[0], "redfish", &fish); err != nil {
+		t.Fatal("Failed to read archived fish: ", err.Error())
+	}
+
+	if fish != redfish {
+		t.Error("Expected archived redfish, got: ", fish)
+	}
+
+	// the fresh collection is empty but keeps its settings
+	records, err := d.ReadAll(collection)
+	if err != nil {
+		t.Fatal("Failed to read fresh collection: ", err.Error())
+	}
+
+	if len(records) != 0 {
+		t.Error("Expected an empty collection, got: ", len(records))
+	}
+
+	if err := d.Write(collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "bluefish"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isGzip(b) {
+		t.Error("Expected the fresh collection to keep compressing")
+	}
+
+	if err := d.Read(archives[0], "bluefish", &Fish{}); err == nil {
+		t.Error("Expected new writes to skip the archive")
+	}
+}
+
+func TestRotateMissing(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Rotate(collection, "fish-"); err != nil {
+		t.Fatal("Expected rotating a missing collection to do nothing, got: ", err.Error())
+	}
+
+	if err := d.Rotate(collection, ""); !errors.Is(err, ErrMissingCollection) {
+		t.Error("Expected ErrMissingCollection, got: ", err)
+	}
+}
@@ -0,0 +1,284 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// schemaName is the file in each collection holding its JSON Schema
+const schemaName = ".schema.json"
+
+// schemas caches the JSON Schemas of collections; a nil schema means the
+// collection has none
+type schemas struct {
+	mutex  sync.Mutex
+	byName map[string]*jsonSchema
+}
+
+// forget drops the cached schemas of a collection and its nested collections
+func (s *schemas) forget(collection string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for name := range s.byName {
+		if name == collection || strings.HasPrefix(name, collection+"/") {
+			delete(s.byName, name)
+		}
+	}
+}
+
+// InitSchema creates each collection of [schemas] that doesn't exist yet and
+// stores its JSON Schema with it, so startup code can declare the whole data
+// model in one call. Every schema is parsed before anything is written, and
+// running it again with the same schemas changes nothing, while a changed
+// schema replaces the stored one. Writes to a collection with a schema fail
+// with ErrValidation when the record doesn't match it; of JSON Schema only
+// the "type", "properties", "required" and "items" keywords, the ones
+// InferSchema produces, are checked.
+func (d *Driver) InitSchema(schemas map[string][]byte) (err error) {
+	defer wrapOpError(&err, "initschema", "", "")
+
+	raw := make(map[string][]byte, len(schemas))
+	parsed := make(map[string]*jsonSchema, len(schemas))
+	names := make([]string, 0, len(schemas))
+	for name, b := range schemas {
+		collection := d.normalize(name)
+
+		// ensure there is a collection to describe
+		if collection == "" {
+			return ErrMissingCollection
+		}
+
+		s := &jsonSchema{}
+		if err := json.Unmarshal(b, s); err != nil {
+			return fmt.Errorf("schema of %s: %w", collection, err)
+		}
+		if err := s.check(); err != nil {
+			return fmt.Errorf("schema of %s: %w", collection, err)
+		}
+
+		raw[collection] = b
+		parsed[collection] = s
+		names = append(names, collection)
+	}
+	sort.Strings(names)
+
+	unlock, err := d.lockCollections(names...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, collection := range names {
+		if err := d.ensureDir(collection); err != nil {
+			return &OpError{Op: "initschema", Collection: collection, Err: err}
+		}
+
+		b := raw[collection]
+		path := filepath.Join(d.collectionDir(collection), schemaName)
+
+		// an unchanged schema is left as it is
+		existing, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return &OpError{Op: "initschema", Collection: collection, Err: err}
+		}
+
+		if err != nil || !bytes.Equal(existing, b) {
+			if err := writeFileAtomic(path, b); err != nil {
+				return &OpError{Op: "initschema", Collection: collection, Err: err}
+			}
+		}
+
+		d.schemas.mutex.Lock()
+		d.schemas.byName[collection] = parsed[collection]
+		d.schemas.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// collectionSchema returns the JSON Schema of [collection], or nil if it has
+// none
+func (d *Driver) collectionSchema(collection string) (*jsonSchema, error) {
+	collection = d.normalize(collection)
+
+	d.schemas.mutex.Lock()
+	s, ok := d.schemas.byName[collection]
+	d.schemas.mutex.Unlock()
+
+	if ok {
+		return s, nil
+	}
+
+	b, err := os.ReadFile(filepath.Join(d.collectionDir(collection), schemaName))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		s = nil
+	case err != nil:
+		// a record in place of the collection directory can't be read through
+		if cerr := d.collectionCollision(collection); cerr != nil {
+			return nil, cerr
+		}
+		return nil, err
+	default:
+		s = &jsonSchema{}
+		if err := json.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("schema of %s: %w", collection, err)
+		}
+	}
+
+	d.schemas.mutex.Lock()
+	defer d.schemas.mutex.Unlock()
+
+	// a schema InitSchema stored meanwhile is newer than the file read
+	if cached, ok := d.schemas.byName[collection]; ok {
+		return cached, nil
+	}
+
+	d.schemas.byName[collection] = s
+	return s, nil
+}
+
+// validateSchema checks the record [b] against the schema of [collection]
+func (d *Driver) validateSchema(collection string, b []byte) error {
+	s, err := d.collectionSchema(collection)
+	if err != nil || s == nil {
+		return err
+	}
+
+	v, err := decodeValue(b)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validate(v, "$"); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return nil
+}
+
+// check makes sure the keywords validate understands are well formed
+func (s *jsonSchema) check() error {
+	if _, err := s.types(); err != nil {
+		return err
+	}
+
+	for _, p := range s.Properties {
+		if err := p.check(); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		return s.Items.check()
+	}
+
+	return nil
+}
+
+// types returns the type names the schema allows; none allows any
+func (s *jsonSchema) types() ([]string, error) {
+	switch t := s.Type.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{t}, nil
+	case []string:
+		return t, nil
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, name := range t {
+			name, ok := name.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid type %v", t)
+			}
+			types = append(types, name)
+		}
+		return types, nil
+	}
+
+	return nil, fmt.Errorf("invalid type %v", s.Type)
+}
+
+// validate checks the decoded value [v], found at [path], against the schema
+func (s *jsonSchema) validate(v interface{}, path string) error {
+	types, err := s.types()
+	if err != nil {
+		return err
+	}
+
+	if len(types) > 0 {
+		allowed := false
+		for _, t := range types {
+			if t == jsonType(v) || (t == "number" && jsonType(v) == "integer") {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%s is %s, not %s", path, jsonType(v), strings.Join(types, " or "))
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s is missing %q", path, name)
+			}
+		}
+
+		for name, p := range s.Properties {
+			if value, ok := v[name]; ok {
+				if err := p.validate(value, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+
+	case []interface{}:
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range v {
+			if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonType returns the JSON Schema type name of a value decoded with
+// decodeValue
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,112 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitSchema(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	schema := []byte(`{"type":"object","properties":{"type":{"type":"string"},"fins":{"type":"array","items":{"type":"integer"}}},"required":["type"]}`)
+	if err := d.InitSchema(map[string][]byte{collection: schema, "tanks": []byte(`{}`)}); err != nil {
+		t.Fatal("Failed to init schema: ", err.Error())
+	}
+
+	// the collections exist right away
+	for _, name := range []string{collection, "tanks"} {
+		if _, err := os.Stat(d.collectionDir(name)); err != nil {
+			t.Error("Expected collection ", name, " to be created, got: ", err)
+		}
+	}
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Error("Expected a matching record to be written, got: ", err)
+	}
+
+	for _, v := range []interface{}{
+		map[string]interface{}{"type": 3},
+		map[string]interface{}{"fins": []int{1}},
+		map[string]interface{}{"type": "red", "fins": []interface{}{1.5}},
+		"red",
+	} {
+		if err := d.Write(collection, "badfish", v); !errors.Is(err, ErrValidation) {
+			t.Error("Expected ErrValidation for ", v, ", got: ", err)
+		}
+	}
+
+	// running it again with the same schema leaves the file alone
+	path := filepath.Join(d.collectionDir(collection), schemaName)
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.InitSchema(map[string][]byte{collection: schema}); err != nil {
+		t.Fatal("Failed to init schema again: ", err.Error())
+	}
+
+	if after, err := os.Stat(path); err != nil || !os.SameFile(before, after) {
+		t.Error("Expected the schema to be left as it was, got: ", err)
+	}
+
+	// the schema is stored with the collection
+	reopened, err := New(d.dir, &Options{Debug: t.Logf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reopened.Write(collection, "badfish", map[string]interface{}{"type": 3}); !errors.Is(err, ErrValidation) {
+		t.Error("Expected ErrValidation after reopening, got: ", err)
+	}
+
+	// a changed schema replaces the old one
+	if err := d.InitSchema(map[string][]byte{collection: []byte(`{"type":"object"}`)}); err != nil {
+		t.Fatal("Failed to update schema: ", err.Error())
+	}
+
+	if err := d.Write(collection, "badfish", map[string]interface{}{"type": 3}); err != nil {
+		t.Error("Expected the updated schema to allow the record, got: ", err)
+	}
+}
+
+func TestInitSchemaInvalid(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	err := d.InitSchema(map[string][]byte{collection: []byte(`{}`), "tanks": []byte(`{"type":3}`)})
+	if err == nil {
+		t.Fatal("Expected an invalid schema to be refused")
+	}
+
+	// nothing is created when any schema is invalid
+	if _, err := os.Stat(d.collectionDir(collection)); !os.IsNotExist(err) {
+		t.Error("Expected no collection to be created, got: ", err)
+	}
+}
+
+func TestInitSchemaInferred(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	schema, err := d.InferSchema(collection)
+	if err != nil {
+		t.Fatal("Failed to infer schema: ", err.Error())
+	}
+
+	if err := d.InitSchema(map[string][]byte{collection: schema}); err != nil {
+		t.Fatal("Failed to init inferred schema: ", err.Error())
+	}
+
+	if err := d.Write(collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Error("Expected a record like the others to be written, got: ", err)
+	}
+
+	if err := d.Write(collection, "badfish", map[string]interface{}{"kind": "red"}); !errors.Is(err, ErrValidation) {
+		t.Error("Expected ErrValidation, got: ", err)
+	}
+}
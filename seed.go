@@ -0,0 +1,66 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// SeedOnce locks the collection and writes [records] only if the collection
+// has no records yet, reporting whether it did. Seeding on every start is
+// then harmless: records written since the first run are never clobbered.
+func (d *Driver) SeedOnce(collection string, records map[string]interface{}) (seeded bool, err error) {
+	defer wrapOpError(&err, "seed", collection, "")
+
+	// ensure there is a place to save records
+	if collection == "" {
+		return false, ErrMissingCollection
+	}
+
+	names := make([]string, 0, len(records))
+	for name := range records {
+		// ensure there is a resource (name) to save each record as
+		if name == "" {
+			return false, ErrMissingResource
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	existing, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+
+	ops := make([]walOp, 0, len(names))
+	for _, name := range names {
+		b, err := d.marshal(collection, name, records[name])
+		if err != nil {
+			return false, &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+		ops = append(ops, walOp{Resource: name, Data: b})
+	}
+
+	if err := d.beginWAL(collection, ops); err != nil {
+		return false, err
+	}
+
+	for _, op := range ops {
+		err := d.applyOp(collection, op)
+		d.cache.invalidate(collection, op.Resource)
+		if err != nil {
+			return false, &OpError{Op: "write", Collection: collection, Resource: op.Resource, Err: err}
+		}
+	}
+
+	return true, d.endWAL(collection, ops)
+}
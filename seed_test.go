@@ -0,0 +1,53 @@
+package jsondb
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSeedOnce(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	seeds := map[string]interface{}{"red": redfish, "blue": Fish{Type: "blue"}}
+
+	// concurrent startups seed exactly once
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	seededCount := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			seeded, err := d.SeedOnce(collection, seeds)
+			if err != nil {
+				t.Error("SeedOnce failed: ", err.Error())
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if seeded {
+				seededCount++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if seededCount != 1 {
+		t.Error("Expected exactly one seeding, got: ", seededCount)
+	}
+
+	// changes made since aren't clobbered
+	if err := d.Write(collection, "red", Fish{Type: "crimson"}); err != nil {
+		t.Fatal("Update fish failed: ", err.Error())
+	}
+
+	if seeded, err := d.SeedOnce(collection, seeds); err != nil || seeded {
+		t.Error("Expected no seeding of a populated collection, got: ", seeded, err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "crimson" {
+		t.Error("Expected the update to survive, got: ", fish.Type, err)
+	}
+}
@@ -0,0 +1,49 @@
+package jsondb
+
+import "errors"
+
+// SetField locks [resource] and sets its top-level [field] to [value],
+// adding the field if the record doesn't have it yet. The other fields keep
+// their values and order. The record must exist and hold a JSON object.
+func (d *Driver) SetField(collection, resource, field string, value interface{}) (err error) {
+	defer wrapOpError(&err, "setfield", collection, resource)
+
+	// ensure there is a place to update
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource to update
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	b, err := d.readExisting(collection, resource)
+	if err != nil {
+		return err
+	}
+
+	obj, ok, err := parseObject(b)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("record is not a JSON object")
+	}
+
+	for i := range obj {
+		if obj[i].name == field {
+			obj[i].value = value
+			return d.write(collection, resource, obj)
+		}
+	}
+
+	return d.write(collection, resource, append(obj, taggedMember{name: field, value: value}))
+}
@@ -0,0 +1,42 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestSetField(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", map[string]interface{}{"type": "red", "size": 1}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.SetField(collection, "red", "type", "crimson"); err != nil {
+		t.Fatal("SetField failed: ", err.Error())
+	}
+	if err := d.SetField(collection, "red", "fins", []int{1, 2}); err != nil {
+		t.Fatal("SetField failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil {
+		t.Fatal("Read fish failed: ", err.Error())
+	}
+	if string(b) != `{"size":1,"type":"crimson","fins":[1,2]}` {
+		t.Error("Expected updated fish, got: ", string(b))
+	}
+
+	// records must exist and be objects
+	if err := d.SetField(collection, "missing", "type", "red"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+
+	if err := d.Write(collection, "list", []string{"red"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.SetField(collection, "list", "type", "red"); err == nil {
+		t.Error("Expected an error setting a field of an array")
+	}
+}
@@ -0,0 +1,167 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// singleFileName is the file holding every record of a collection whose
+// CollectionConfig sets SingleFile
+const singleFileName = ".records.json"
+
+// singleFile reports whether [collection] keeps its records in one file
+func (d *Driver) singleFile(collection string) (bool, error) {
+	cfg, err := d.collectionConfig(collection)
+	return cfg.SingleFile, err
+}
+
+// singlePath returns the path of the file holding the records of [collection]
+func (d *Driver) singlePath(collection string) string {
+	return filepath.Join(d.collectionDir(collection), singleFileName)
+}
+
+// readSingleFile returns the records of a single file collection by resource
+func (d *Driver) readSingleFile(collection string) (map[string]json.RawMessage, error) {
+	records := make(map[string]json.RawMessage)
+
+	b, err := os.ReadFile(d.singlePath(collection))
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if b, err = d.decode(collection, b); err != nil {
+		return nil, err
+	}
+
+	return records, json.Unmarshal(b, &records)
+}
+
+// readSingle returns the record [resource] of a single file collection, or
+// an fs.ErrNotExist error if the file doesn't hold it
+func (d *Driver) readSingle(collection, resource string) ([]byte, error) {
+	records, err := d.readSingleFile(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := records[resource]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: d.recordPath(collection, resource), Err: fs.ErrNotExist}
+	}
+
+	return b, nil
+}
+
+// listSingle returns the sorted resource names held by a single file
+// collection
+func (d *Driver) listSingle(collection string) ([]string, error) {
+	if _, err := os.Stat(d.collectionDir(collection)); err != nil {
+		return nil, err
+	}
+
+	records, err := d.readSingleFile(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// updateSingle atomically rewrites the file of a single file collection
+// with [b] as the record [resource], or without it when [b] is nil. The
+// caller holds the collection lock; with resource level locking, writers of
+// the file take turns here too.
+func (d *Driver) updateSingle(collection, resource string, b []byte) error {
+	return d.updateSingleRecords(collection, map[string][]byte{resource: b})
+}
+
+// updateSingleRecords is updateSingle for every record of [changes] at once,
+// in a single rewrite of the file
+func (d *Driver) updateSingleRecords(collection string, changes map[string][]byte) error {
+	if d.resourceLevelLocking {
+		key := collection + "\x00file"
+		d.resourceLocks.lock(key)
+		defer d.resourceLocks.unlock(key)
+	}
+
+	if err := d.ensureDir(collection); err != nil {
+		return err
+	}
+
+	records, err := d.readSingleFile(collection)
+	if err != nil {
+		return err
+	}
+
+	for resource, b := range changes {
+		if b == nil {
+			delete(records, resource)
+		} else {
+			records[resource] = b
+		}
+	}
+
+	raw, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	if raw, err = d.encode(collection, raw); err != nil {
+		return err
+	}
+
+	path := d.singlePath(collection)
+	tmpPath := path + tmpSuffix
+
+	if d.durable {
+		err = writeFileSync(tmpPath, raw)
+	} else {
+		err = os.WriteFile(tmpPath, raw, fileMode)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// the rename only survives a crash once the directory is flushed too
+	if d.durable {
+		return syncFile(filepath.Dir(path))
+	}
+
+	return nil
+}
+
+// deleteSingle removes the record [resource] from a single file collection;
+// the caller holds the collection lock
+func (d *Driver) deleteSingle(collection, resource string) error {
+	if _, err := d.readSingle(collection, resource); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return d.deleteMissing(filepath.Join(collection, resource))
+		}
+		return err
+	}
+
+	if err := d.updateSingle(collection, resource, nil); err != nil {
+		return err
+	}
+
+	return d.recordChanged(collection, resource)
+}
@@ -0,0 +1,174 @@
+package jsondb
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSingleFile(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{SingleFile: true, Compress: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	for _, kind := range []string{"red", "blue", "green"} {
+		if err := d.Write(collection, kind, Fish{Type: kind}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "blue", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected bluefish, got: ", fish, err)
+	}
+
+	// the records share one file
+	entries, err := os.ReadDir(d.collectionDir(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if d.isRecord(entry) {
+			t.Error("Expected no file per record, got: ", entry.Name())
+		}
+	}
+
+	b, err := os.ReadFile(d.singlePath(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGzip(b) {
+		t.Error("Expected the records file to be compressed")
+	}
+
+	if err := d.Delete(collection, "green"); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+	if err := d.Delete(collection, "green"); err != nil {
+		t.Error("Expected deleting a missing record to succeed, got: ", err.Error())
+	}
+
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 2 {
+		t.Error("Expected 2 records, got: ", len(records), err)
+	}
+
+	if n, err := d.Count(collection); err != nil || n != 2 {
+		t.Error("Expected a count of 2, got: ", n, err)
+	}
+
+	// WriteAll removes the records left out
+	if err := d.WriteAll(collection, map[string]interface{}{"red": redfish}); err != nil {
+		t.Fatal("Failed to write all: ", err.Error())
+	}
+
+	names, err := d.ListPage(collection, 0, 10)
+	if err != nil || len(names) != 1 || names[0] != "red" {
+		t.Error("Expected only red, got: ", names, err)
+	}
+
+	// the layout can't change under existing records
+	if err := d.ConfigureCollection(collection, CollectionConfig{}); err == nil {
+		t.Error("Expected an error turning SingleFile off with records")
+	}
+
+	if err := d.Delete(collection, ""); err != nil {
+		t.Fatal("Failed to delete collection: ", err.Error())
+	}
+	if _, err := os.Stat(d.collectionDir(collection)); !os.IsNotExist(err) {
+		t.Error("Expected the collection to be removed")
+	}
+}
+
+func TestSingleFileResourceLevelLocking(t *testing.T) {
+	d := newTestDB(t, &Options{ResourceLevelLocking: true})
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{SingleFile: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	// writers of distinct records mustn't lose each other's writes
+	const n = 20
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			errs <- d.Write(collection, "fish"+string(rune('a'+i)), redfish)
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if count, err := d.Count(collection); err != nil || count != n {
+		t.Error("Expected ", n, " records, got: ", count, err)
+	}
+}
+
+func TestSingleFileSwap(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{SingleFile: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	for _, kind := range []string{"red", "blue"} {
+		if err := d.Write(collection, kind, Fish{Type: kind}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.Swap(collection, "red", "blue"); err != nil {
+		t.Fatal("Failed to swap: ", err.Error())
+	}
+
+	for resource, kind := range map[string]string{"red": "blue", "blue": "red"} {
+		fish := Fish{}
+		if err := d.Read(collection, resource, &fish); err != nil || fish.Type != kind {
+			t.Errorf("Expected %s to hold %s fish, got: %v %v", resource, kind, fish, err)
+		}
+	}
+
+	// the swap rewrote the shared file rather than writing a file per record
+	entries, err := os.ReadDir(d.collectionDir(collection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if d.isRecord(entry) || strings.HasSuffix(entry.Name(), tmpSuffix) {
+			t.Error("Expected no file per record, got: ", entry.Name())
+		}
+	}
+}
+
+func TestSingleFileIterateAndStats(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.ConfigureCollection("s", CollectionConfig{SingleFile: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+
+	for _, w := range []struct{ collection, resource string }{{"s", "a"}, {"s", "b"}, {"plain", "x"}} {
+		if err := d.Write(w.collection, w.resource, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	var seen []string
+	err := d.IterateAll(func(collection, resource string, raw []byte) error {
+		seen = append(seen, collection+"/"+resource)
+		return nil
+	})
+	if err != nil || strings.Join(seen, " ") != "plain/x s/a s/b" {
+		t.Error("Expected every record iterated, got: ", seen, err)
+	}
+
+	stats, err := d.DatabaseStats()
+	if err != nil || stats.Records != 3 || stats.PerCollection["s"] != 2 || stats.PerCollection["plain"] != 1 {
+		t.Error("Expected the single file records counted, got: ", stats, err)
+	}
+}
@@ -0,0 +1,88 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ListWithSizes returns the size in bytes each record of [collection] takes
+// on disk, by resource name, from the directory listing alone, so oversized
+// records can be found without reading any. Compressed records report their
+// compressed size and content addressed ones the size of their object; the
+// records of a SingleFile collection report the size of their JSON in the
+// shared file. Temp files and entries that aren't records are left out, and
+// a collection that doesn't exist has no records.
+func (d *Driver) ListWithSizes(collection string) (sizes map[string]int64, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "list", collection, "")
+
+	// ensure there is a collection to list
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	sizes = make(map[string]int64)
+
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return nil, err
+	}
+	if single {
+		records, err := d.readSingleFile(collection)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for name, b := range records {
+			sizes[name] = int64(len(b))
+		}
+		return sizes, nil
+	}
+
+	dir := d.collectionDir(collection)
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sizes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if err := d.checkNonRegular(dir, file); err != nil {
+			return nil, err
+		}
+
+		name, ok := d.recordName(dir, file)
+		if !ok {
+			continue
+		}
+
+		var info fs.FileInfo
+		if file.Type()&os.ModeSymlink != 0 {
+			// the size of a symlink is that of the path it holds
+			info, err = os.Stat(filepath.Join(dir, file.Name()))
+		} else {
+			info, err = file.Info()
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, &OpError{Op: "list", Collection: collection, Resource: name, Err: err}
+		}
+
+		sizes[name] = info.Size()
+	}
+
+	return sizes, nil
+}
@@ -0,0 +1,50 @@
+package jsondb
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestListWithSizes(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	// a missing collection has no records
+	sizes, err := d.ListWithSizes(collection)
+	if err != nil || len(sizes) != 0 {
+		t.Error("Expected no sizes, got: ", sizes, err)
+	}
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write(collection, "big", Tank{Fish: []string{"red", "blue", "green"}, Count: 3}); err != nil {
+		t.Fatal("Create tank failed: ", err.Error())
+	}
+
+	// neither a leftover temp file nor a symlink is a record
+	if err := os.WriteFile(d.recordPath(collection, "stale")+tmpSuffix, []byte(`{}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(d.recordPath(collection, "red"), d.recordPath(collection, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	sizes, err = d.ListWithSizes(collection)
+	if err != nil {
+		t.Fatal("ListWithSizes failed: ", err.Error())
+	}
+
+	want := map[string]int64{}
+	for _, name := range []string{"red", "big"} {
+		info, err := os.Stat(d.recordPath(collection, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[name] = info.Size()
+	}
+
+	if !reflect.DeepEqual(sizes, want) {
+		t.Error("Expected ", want, ", got: ", sizes)
+	}
+}
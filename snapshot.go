@@ -0,0 +1,148 @@
+package jsondb
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// snapshotPrefix names the hidden directories StreamSnapshot links records into
+const snapshotPrefix = ".snapshot-"
+
+// snapshotEntry is a record linked into a snapshot directory
+type snapshotEntry struct {
+	collection string
+	name       string
+	path       string
+	b          []byte // the record itself, for a single file collection
+}
+
+// StreamSnapshot writes the same archive as ExportZip, but only holds the
+// collection locks while hard linking every record into a hidden snapshot
+// directory. Writers replace records by renaming new files into place, so the
+// links keep the snapshotted contents while the archive is streamed to [w]
+// without blocking them. The snapshot directory is removed afterwards.
+func (d *Driver) StreamSnapshot(w io.Writer) error {
+	snap, err := os.MkdirTemp(d.dir, snapshotPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(snap)
+
+	entries, err := d.linkSnapshot(snap)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	for _, e := range entries {
+		b, err := d.snapshotRecord(e)
+		if err != nil {
+			return &OpError{Op: "snapshot", Collection: e.collection, Resource: e.name, Err: err}
+		}
+
+		if err := d.writeZipEntry(zw, e.collection, e.name, b); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// snapshotRecord returns the record [e] holds as ExportZip would write it
+func (d *Driver) snapshotRecord(e snapshotEntry) ([]byte, error) {
+	if e.b != nil {
+		return e.b, nil
+	}
+
+	b, err := os.ReadFile(e.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.decode(e.collection, b)
+}
+
+// linkSnapshot locks every collection and links each record into [snap],
+// returning the linked records in export order. The records of a single
+// file collection have no file of their own, so they're kept in memory.
+func (d *Driver) linkSnapshot(snap string) ([]snapshotEntry, error) {
+	collections, err := d.allCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := d.lockCollections(collections...)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var entries []snapshotEntry
+	for _, collection := range collections {
+		names, err := d.listDisk(collection)
+		if err != nil {
+			return nil, err
+		}
+
+		single, err := d.singleFile(collection)
+		if err != nil {
+			return nil, err
+		}
+		if single {
+			records, err := d.readSingleFile(collection)
+			if err != nil {
+				return nil, &OpError{Op: "snapshot", Collection: collection, Err: err}
+			}
+			for _, name := range names {
+				entries = append(entries, snapshotEntry{collection: collection, name: name, b: records[name]})
+			}
+			continue
+		}
+
+		for _, name := range names {
+			e := snapshotEntry{
+				collection: collection,
+				name:       name,
+				path:       filepath.Join(snap, strconv.Itoa(len(entries))),
+			}
+
+			if err := d.linkRecord(collection, name, e.path); err != nil {
+				return nil, &OpError{Op: "snapshot", Collection: collection, Resource: name, Err: err}
+			}
+
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// linkRecord hard links the file holding [resource] to [dst], copying it on
+// file systems without hard links
+func (d *Driver) linkRecord(collection, resource, dst string) error {
+	src := d.recordPath(collection, resource)
+
+	// content-addressed records are symlinks; link the object they point at
+	if d.contentAddressed {
+		target, err := filepath.EvalSymlinks(src)
+		if err != nil {
+			return err
+		}
+		src = target
+	}
+
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, b, fileMode)
+}
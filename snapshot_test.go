@@ -0,0 +1,96 @@
+package jsondb
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStreamSnapshot(t *testing.T) {
+	for _, opts := range []*Options{nil, {ContentAddressed: true}, {Compress: true}} {
+		d := newTestDB(t, opts)
+
+		if err := d.Write(collection, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+		if err := d.Write("ocean/"+collection, "blue", Fish{Type: "blue"}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		var want, got bytes.Buffer
+		if err := d.ExportZip(&want); err != nil {
+			t.Fatal("ExportZip failed: ", err.Error())
+		}
+		if err := d.StreamSnapshot(&got); err != nil {
+			t.Fatal("StreamSnapshot failed: ", err.Error())
+		}
+
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Error("Expected the snapshot to match ExportZip")
+		}
+
+		// the snapshot directory is cleaned up
+		entries, err := os.ReadDir(d.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), snapshotPrefix) {
+				t.Error("Expected the snapshot directory to be removed, found: ", e.Name())
+			}
+		}
+	}
+}
+
+func TestStreamSnapshotSingleFile(t *testing.T) {
+	d := newTestDB(t, &Options{Compress: true})
+
+	if err := d.ConfigureCollection(collection, CollectionConfig{SingleFile: true}); err != nil {
+		t.Fatal("Failed to configure collection: ", err.Error())
+	}
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+	if err := d.Write("ocean", "nemo", Fish{Type: "clown"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	var want, got bytes.Buffer
+	if err := d.ExportZip(&want); err != nil {
+		t.Fatal("ExportZip failed: ", err.Error())
+	}
+	if err := d.StreamSnapshot(&got); err != nil {
+		t.Fatal("StreamSnapshot failed: ", err.Error())
+	}
+
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Error("Expected the snapshot to match ExportZip")
+	}
+}
+
+func TestStreamSnapshotIsolation(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	snap := t.TempDir()
+	entries, err := d.linkSnapshot(snap)
+	if err != nil || len(entries) != 1 {
+		t.Fatal("linkSnapshot failed: ", entries, err)
+	}
+
+	// writes after the links are taken don't change the snapshot
+	if err := d.Write(collection, "red", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Update fish failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(entries[0].path)
+	if err != nil || !strings.Contains(string(b), `"red"`) {
+		t.Error("Expected the snapshot to keep the old record, got: ", string(b), err)
+	}
+}
@@ -0,0 +1,137 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// sortKey is the value a record is ordered by in IterateSortedBy
+type sortKey struct {
+	resource string
+	rank     int // sortNumber, sortString or sortOther
+	num      float64
+	str      string
+}
+
+const (
+	sortNumber = iota
+	sortString
+	sortOther // the field is missing or neither a number nor a string
+)
+
+// IterateSortedBy calls [fn] with the raw bytes of each record of
+// [collection], ordered by the value of their top-level [field]: numbers
+// first by value, then strings lexically, reversed when [desc] is set.
+// Records without the field, or where it holds anything else, come last;
+// records with equal values keep the order of their resource names. Only the
+// names and values are held in memory while sorting, each record is read
+// again when it's handed to [fn], and a record deleted meanwhile is skipped.
+// Iteration stops at the first error returned by [fn].
+func (d *Driver) IterateSortedBy(collection, field string, desc bool, fn func(resource string, raw []byte) error) (err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "iterate", collection, "")
+
+	// ensure there is a collection to iterate
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	keys := make([]sortKey, 0, len(names))
+	for _, name := range names {
+		b, err := d.readLive(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(b) == 0) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+		if err != nil {
+			return &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		keys = append(keys, recordSortKey(name, b, field))
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+
+		// records without a value to sort by stay last either way
+		if a.rank == sortOther || b.rank == sortOther {
+			return a.rank < b.rank
+		}
+
+		if desc {
+			a, b = b, a
+		}
+		return a.less(b)
+	})
+
+	for _, key := range keys {
+		b, err := d.readLive(collection, key.resource)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return &OpError{Op: "read", Collection: collection, Resource: key.resource, Err: err}
+		}
+
+		if err := fn(key.resource, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordSortKey returns what the record [b] is sorted by
+func recordSortKey(resource string, b []byte, field string) sortKey {
+	key := sortKey{resource: resource, rank: sortOther}
+
+	obj, ok, err := parseObject(b)
+	if err != nil || !ok {
+		return key
+	}
+
+	for _, m := range obj {
+		if m.name != field {
+			continue
+		}
+
+		raw, _ := m.value.(json.RawMessage)
+
+		v, err := decodeValue(raw)
+		if err != nil {
+			return key
+		}
+
+		switch v := v.(type) {
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				key.rank, key.num = sortNumber, f
+			}
+		case string:
+			key.rank, key.str = sortString, v
+		}
+	}
+
+	return key
+}
+
+// less orders two keys holding a number or a string
+func (k sortKey) less(o sortKey) bool {
+	if k.rank != o.rank {
+		return k.rank < o.rank
+	}
+
+	if k.rank == sortNumber {
+		return k.num < o.num
+	}
+
+	return k.str < o.str
+}
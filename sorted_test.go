@@ -0,0 +1,60 @@
+package jsondb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIterateSortedBy(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	records := map[string]interface{}{
+		"a": map[string]interface{}{"size": 3},
+		"b": map[string]interface{}{"size": 1.5},
+		"c": map[string]interface{}{"size": "large"},
+		"d": map[string]interface{}{"color": "red"},
+		"e": map[string]interface{}{"size": 3},
+		"f": map[string]interface{}{"size": "huge"},
+	}
+	for name, v := range records {
+		if err := d.Write(collection, name, v); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	order := func(desc bool) []string {
+		var names []string
+		err := d.IterateSortedBy(collection, "size", desc, func(resource string, raw []byte) error {
+			if len(raw) == 0 {
+				t.Error("Expected the raw record of ", resource)
+			}
+			names = append(names, resource)
+			return nil
+		})
+		if err != nil {
+			t.Fatal("Failed to iterate fish: ", err.Error())
+		}
+		return names
+	}
+
+	// equal sizes keep resource order, and fish without a size come last
+	if names := order(false); !reflect.DeepEqual(names, []string{"b", "a", "e", "f", "c", "d"}) {
+		t.Error("Unexpected ascending order: ", names)
+	}
+
+	if names := order(true); !reflect.DeepEqual(names, []string{"c", "f", "a", "e", "b", "d"}) {
+		t.Error("Unexpected descending order: ", names)
+	}
+
+	// an error from fn stops the iteration
+	stop := errors.New("stop")
+	calls := 0
+	err := d.IterateSortedBy(collection, "size", false, func(resource string, raw []byte) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Error("Expected iteration to stop after one record, got: ", calls, err)
+	}
+}
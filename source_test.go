@@ -0,0 +1,43 @@
+package jsondb
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestSourceFS(t *testing.T) {
+	seeds := fstest.MapFS{
+		"fish/red":  {Data: []byte(`{"type":"red"}`)},
+		"fish/blue": {Data: []byte(`{"type":"blue"}`)},
+	}
+	d := newTestDB(t, &Options{SourceFS: seeds})
+
+	// seed records are readable before anything is written
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected seeded red fish, got: ", fish.Type, err)
+	}
+
+	// records on disk override their seeds
+	if err := d.Write(collection, "red", Fish{Type: "crimson"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "crimson" {
+		t.Error("Expected crimson fish from disk, got: ", fish.Type, err)
+	}
+
+	if err := d.Write(collection, "green", Fish{Type: "green"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// listings combine disk and seeds without duplicates
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 3 {
+		t.Error("Expected 3 records, got: ", len(records), err)
+	}
+
+	if err := d.Read(collection, "missing", &fish); err == nil {
+		t.Error("Expected nothing, got fish")
+	}
+}
@@ -0,0 +1,128 @@
+package jsondb
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DatabaseStats aggregates the size of a database
+type DatabaseStats struct {
+	Collections   int            // number of collections, nested ones included
+	Records       int            // number of records across all collections
+	Bytes         int64          // total size of all records on disk
+	PerCollection map[string]int // number of records in each collection
+}
+
+// DatabaseStats reports how many collections and records the database
+// holds and how much space the records take, as ListWithSizes reports it for
+// each collection; the records of SingleFile collections are counted like
+// any other. Temp files and hidden entries aren't counted.
+func (d *Driver) DatabaseStats() (DatabaseStats, error) {
+	collections, err := d.allCollections()
+	if err != nil {
+		return DatabaseStats{}, err
+	}
+
+	stats := DatabaseStats{Collections: len(collections), PerCollection: make(map[string]int, len(collections))}
+	for _, collection := range collections {
+		sizes, err := d.ListWithSizes(collection)
+		if err != nil {
+			return DatabaseStats{}, err
+		}
+
+		stats.Records += len(sizes)
+		stats.PerCollection[collection] = len(sizes)
+		for _, size := range sizes {
+			stats.Bytes += size
+		}
+	}
+
+	return stats, nil
+}
+
+// OpCounts counts the operations on a collection since the driver was
+// created or ResetStats was last called. Errors counts the failed ones among
+// them.
+type OpCounts struct {
+	Reads   uint64 // Read and ReadAll calls
+	Writes  uint64 // Write calls
+	Deletes uint64 // Delete calls
+	Errors  uint64
+}
+
+type opKind int
+
+const (
+	opRead opKind = iota
+	opWrite
+	opDelete
+)
+
+// opStats holds the OpCounts of each collection
+type opStats struct {
+	mutex  sync.Mutex
+	byName map[string]*OpCounts
+}
+
+// CollectionStats returns the operation counts of [collection]; a collection
+// nothing was done to yet has all zero counts
+func (d *Driver) CollectionStats(collection string) (OpCounts, error) {
+	collection = d.normalize(collection)
+
+	// ensure there is a collection to report on
+	if collection == "" {
+		return OpCounts{}, ErrMissingCollection
+	}
+
+	d.stats.mutex.Lock()
+	c, ok := d.stats.byName[collection]
+	d.stats.mutex.Unlock()
+
+	if !ok {
+		return OpCounts{}, nil
+	}
+
+	return OpCounts{
+		Reads:   atomic.LoadUint64(&c.Reads),
+		Writes:  atomic.LoadUint64(&c.Writes),
+		Deletes: atomic.LoadUint64(&c.Deletes),
+		Errors:  atomic.LoadUint64(&c.Errors),
+	}, nil
+}
+
+// ResetStats sets the operation counts of every collection back to zero
+func (d *Driver) ResetStats() {
+	d.stats.mutex.Lock()
+	defer d.stats.mutex.Unlock()
+
+	d.stats.byName = make(map[string]*OpCounts)
+}
+
+// countOp counts an operation of [kind] on [collection] that ended with *[err]
+func (d *Driver) countOp(collection string, kind opKind, err *error) {
+	if collection == "" {
+		return
+	}
+	collection = d.normalize(collection)
+
+	d.stats.mutex.Lock()
+	c, ok := d.stats.byName[collection]
+	if !ok {
+		c = &OpCounts{}
+		d.stats.byName[collection] = c
+	}
+	d.stats.mutex.Unlock()
+
+	switch kind {
+	case opRead:
+		atomic.AddUint64(&c.Reads, 1)
+	case opWrite:
+		atomic.AddUint64(&c.Writes, 1)
+	case opDelete:
+		atomic.AddUint64(&c.Deletes, 1)
+	}
+
+	if *err != nil {
+		atomic.AddUint64(&c.Errors, 1)
+	}
+}
@@ -0,0 +1,81 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDatabaseStats(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, f := range []Fish{{Type: "red"}, {Type: "blue"}} {
+		if err := d.Write(collection, f.Type, f); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.Write("deep/sea", "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// temp files don't count
+	if err := os.WriteFile(filepath.Join(d.dir, collection, "green.tmp"), []byte("{}"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := d.DatabaseStats()
+	if err != nil {
+		t.Fatal("Failed to collect stats: ", err.Error())
+	}
+
+	if stats.Collections != 3 {
+		t.Error("Expected 3 collections, got: ", stats.Collections)
+	}
+
+	if stats.Records != 3 {
+		t.Error("Expected 3 records, got: ", stats.Records)
+	}
+
+	if stats.Bytes <= 0 {
+		t.Error("Expected some bytes, got: ", stats.Bytes)
+	}
+
+	if stats.PerCollection[collection] != 2 || stats.PerCollection["deep/sea"] != 1 || stats.PerCollection["deep"] != 0 {
+		t.Error("Unexpected per collection counts: ", stats.PerCollection)
+	}
+}
+
+func TestCollectionStats(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Read(collection, "red", &Fish{}); err != nil {
+		t.Fatal("Read fish failed: ", err.Error())
+	}
+	if _, err := d.ReadAll(collection); err != nil {
+		t.Fatal("Read fish failed: ", err.Error())
+	}
+	if err := d.Read(collection, "missing", &Fish{}); err == nil {
+		t.Fatal("Expected an error reading a missing fish")
+	}
+	if err := d.Delete(collection, "red"); err != nil {
+		t.Fatal("Delete fish failed: ", err.Error())
+	}
+
+	stats, err := d.CollectionStats(collection)
+	if want := (OpCounts{Reads: 3, Writes: 1, Deletes: 1, Errors: 1}); err != nil || stats != want {
+		t.Error("Expected ", want, ", got: ", stats, err)
+	}
+
+	if stats, err := d.CollectionStats("birds"); err != nil || stats != (OpCounts{}) {
+		t.Error("Expected zero counts for an untouched collection, got: ", stats, err)
+	}
+
+	d.ResetStats()
+	if stats, _ := d.CollectionStats(collection); stats != (OpCounts{}) {
+		t.Error("Expected zero counts after ResetStats, got: ", stats)
+	}
+}
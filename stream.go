@@ -0,0 +1,177 @@
+package jsondb
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFrom locks [resource] and atomically stores the JSON read from [r]
+// as the record, streaming it to disk so a large record never has to fit in
+// memory. The bytes are stored as they are: they aren't marshaled, so
+// BeforeWrite, StripFields, validators and the conflict resolver don't see
+// them. Content addressed databases hold the record in memory to hash it.
+func (d *Driver) WriteFrom(collection, resource string, r io.Reader) error {
+	return d.WriteFromWithProgress(collection, resource, r, nil)
+}
+
+// WriteFromWithProgress is WriteFrom calling [progress], unless it's nil,
+// with the number of bytes read from [r] so far every time more arrive
+func (d *Driver) WriteFromWithProgress(collection, resource string, r io.Reader, progress func(written int64)) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "write", collection, resource)
+	defer d.countOp(collection, opWrite, &err)
+
+	// ensure there is a place to save record
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save record as
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	if err := d.checkKey(collection, resource); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		r = &progressReader{r: r, progress: progress}
+	}
+
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return err
+	}
+
+	// objects are named by their hash, which needs all of the bytes first,
+	// and a single file collection stores the record amid the others
+	if d.contentAddressed || single {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if len(b) == 0 {
+			return ErrEmptyRecord
+		}
+		return d.writeRaw(collection, resource, b)
+	}
+
+	if err := d.ensureDir(collection); err != nil {
+		return err
+	}
+
+	fnlPath := d.recordPath(collection, resource)
+	tmpPath := fnlPath + tmpSuffix
+
+	if err := d.streamFile(collection, tmpPath, r); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := d.rename(tmpPath, fnlPath); err != nil {
+		os.Remove(tmpPath)
+
+		// a nested collection may be in the way of the record
+		if info, serr := os.Lstat(fnlPath); serr == nil && info.IsDir() {
+			return fmt.Errorf("%w: %s/%s is a collection", ErrNameCollision, collection, resource)
+		}
+		return err
+	}
+
+	// the rename only survives a crash once the directory is flushed too
+	if d.durable {
+		if err := syncFile(filepath.Dir(fnlPath)); err != nil {
+			return err
+		}
+	}
+
+	if err := d.unbury(collection, resource); err != nil {
+		return err
+	}
+
+	if d.afterWrite == nil {
+		return d.recordChanged(collection, resource)
+	}
+
+	// the streamed bytes were never held, so AfterWrite gets them read back
+	b, err := d.readFile(collection, resource)
+	if err != nil {
+		return err
+	}
+	return d.written(collection, resource, b)
+}
+
+// streamFile copies [r] into a new file at [path], compressing it when the
+// collection's records are
+func (d *Driver) streamFile(collection, path string, r io.Reader) error {
+	cfg, err := d.collectionConfig(collection)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if cfg.Compress {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+
+	// a truncated upload would otherwise be stored as an empty record
+	if n == 0 {
+		return ErrEmptyRecord
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	if d.durable {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+
+	return f.Close()
+}
+
+// progressReader reports how many bytes have been read through it
+type progressReader struct {
+	r        io.Reader
+	read     int64
+	progress func(read int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.progress(p.read)
+	}
+
+	return n, err
+}
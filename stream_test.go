@@ -0,0 +1,63 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestWriteFromWithProgress(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	record := `{"type":"red","note":"` + strings.Repeat("x", 1000) + `"}`
+
+	var reported []int64
+	progress := func(written int64) { reported = append(reported, written) }
+
+	// read a byte at a time so progress is reported more than once
+	r := iotest.OneByteReader(strings.NewReader(record))
+	if err := d.WriteFromWithProgress(collection, "red", r, progress); err != nil {
+		t.Fatal("WriteFromWithProgress failed: ", err.Error())
+	}
+
+	if len(reported) < 2 || reported[len(reported)-1] != int64(len(record)) {
+		t.Error("Expected progress up to ", len(record), ", got: ", reported)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+
+	// a failing reader leaves the old record in place
+	failed := errors.New("failed")
+	if err := d.WriteFrom(collection, "red", iotest.ErrReader(failed)); !errors.Is(err, failed) {
+		t.Error("Expected the reader's error, got: ", err)
+	}
+	if b, err := os.ReadFile(d.recordPath(collection, "red")); err != nil || string(b) != record {
+		t.Error("Expected the old record, got: ", len(b), err)
+	}
+	if _, err := os.Stat(d.recordPath(collection, "red") + tmpSuffix); !os.IsNotExist(err) {
+		t.Error("Expected no temp file left behind, got: ", err)
+	}
+}
+
+func TestWriteFromCompressed(t *testing.T) {
+	d := newTestDB(t, &Options{Compress: true})
+
+	if err := d.WriteFrom(collection, "red", strings.NewReader(`{"type":"red"}`)); err != nil {
+		t.Fatal("WriteFrom failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil || !isGzip(b) {
+		t.Error("Expected compressed record, got: ", b, err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish, got: ", fish.Type, err)
+	}
+}
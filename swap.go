@@ -0,0 +1,121 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Swap locks the collection and exchanges the contents of resources [a] and
+// [b]; both must exist or ErrNotFound is returned. Both new contents are
+// written to temp files before either is renamed into place, so a crash
+// between the two renames leaves the displaced content, a's former record,
+// in b's .tmp file rather than losing it. The files are written and renamed
+// like every record, so Durable and CrossDeviceCopy apply. In a SingleFile
+// collection both records change in one rewrite of the shared file.
+func (d *Driver) Swap(collection, a, b string) error {
+	collection, a, b = d.normalize(collection), d.normalize(a), d.normalize(b)
+
+	// ensure there is a place to swap records
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there are resources to swap
+	if a == "" || b == "" {
+		return ErrMissingResource
+	}
+
+	for _, resource := range []string{a, b} {
+		if err := d.checkNames(collection, resource); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, a)
+	defer d.cache.invalidate(collection, b)
+
+	pathA, pathB := d.recordPath(collection, a), d.recordPath(collection, b)
+
+	rawA, err := d.readExisting(collection, a)
+	if err != nil {
+		return err
+	}
+
+	rawB, err := d.readExisting(collection, b)
+	if err != nil {
+		return err
+	}
+
+	// swapping a record with itself changes nothing
+	if a == b {
+		return nil
+	}
+
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return err
+	}
+	if single {
+		if err := d.updateSingleRecords(collection, map[string][]byte{a: rawB, b: rawA}); err != nil {
+			return err
+		}
+		return d.swapped(collection, a, b)
+	}
+
+	// stage both records as they'd be stored on disk
+	if rawA, err = d.encode(collection, rawA); err != nil {
+		return err
+	}
+
+	if rawB, err = d.encode(collection, rawB); err != nil {
+		return err
+	}
+
+	tmpA, tmpB := pathA+tmpSuffix, pathB+tmpSuffix
+	if err := d.stage(tmpA, pathA, rawB); err != nil {
+		return err
+	}
+
+	if err := d.stage(tmpB, pathB, rawA); err != nil {
+		os.Remove(tmpA)
+		return err
+	}
+
+	if err := d.commit(tmpA, pathA); err != nil {
+		os.Remove(tmpA)
+		os.Remove(tmpB)
+		return err
+	}
+
+	if err := d.commit(tmpB, pathB); err != nil {
+		return err
+	}
+
+	return d.swapped(collection, a, b)
+}
+
+// swapped brings everything kept about the swapped records [a] and [b] up to
+// date; the caller holds the collection lock
+func (d *Driver) swapped(collection, a, b string) error {
+	if err := d.recordChanged(collection, a); err != nil {
+		return err
+	}
+
+	return d.recordChanged(collection, b)
+}
+
+// readExisting reads a record, reporting a missing one as ErrNotFound
+func (d *Driver) readExisting(collection, resource string) ([]byte, error) {
+	b, err := d.readFile(collection, resource)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return b, err
+}
@@ -0,0 +1,46 @@
+package jsondb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	for _, opts := range []*Options{nil, {Durable: true}, {Durable: true, ContentAddressed: true}} {
+		testSwap(t, newTestDB(t, opts))
+	}
+}
+
+func testSwap(t *testing.T, d *Driver) {
+	t.Helper()
+
+	if err := d.Write(collection, "first", Fish{Type: "red"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Write(collection, "second", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.Swap(collection, "first", "second"); err != nil {
+		t.Fatal("Failed to swap: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "first", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected blue fish first, got: ", fish.Type, err)
+	}
+
+	if err := d.Read(collection, "second", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red fish second, got: ", fish.Type, err)
+	}
+
+	// both records must exist
+	if err := d.Swap(collection, "first", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+
+	if err := d.Read(collection, "first", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected failed swap to leave blue fish, got: ", fish.Type, err)
+	}
+}
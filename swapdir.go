@@ -0,0 +1,96 @@
+package jsondb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// swapDirSuffix is appended to the database directory SwapDir moves aside
+const swapDirSuffix = ".swapped-"
+
+// SwapDir replaces the whole database with the one prepared at [newDir],
+// which must be a directory on the same file system. Every collection of the
+// current and the new database is locked, the current directory is renamed
+// aside, [newDir] is renamed into its place, and the old database is removed
+// once the new one is in place; should that second rename fail, the old
+// database is put back. Operations after SwapDir returns see the new
+// database under the same path. Writes creating collections found in
+// neither database aren't held off while swapping.
+func (d *Driver) SwapDir(newDir string) (err error) {
+	defer wrapOpError(&err, "swapdir", "", "")
+
+	info, err := os.Stat(newDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", newDir)
+	}
+
+	src, err := filepath.Abs(newDir)
+	if err != nil {
+		return err
+	}
+	dst, err := filepath.Abs(d.dir)
+	if err != nil {
+		return err
+	}
+
+	// renaming the database aside would take a directory inside it along
+	if src == dst || strings.HasPrefix(src, dst+string(filepath.Separator)) {
+		return fmt.Errorf("%s is inside the database directory %s", newDir, d.dir)
+	}
+
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+
+	incoming, err := d.collectionsIn(src)
+	if err != nil {
+		return err
+	}
+	collections = append(collections, incoming...)
+
+	unlock, err := d.lockCollections(collections...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	old := dst + swapDirSuffix + time.Now().UTC().Format(rotateLayout)
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		if rerr := os.Rename(old, dst); rerr != nil {
+			d.log("Failed to restore '%s' from '%s': %v\n", dst, old, rerr)
+		}
+		return err
+	}
+
+	// nothing cached from the old database holds for the new one
+	for _, collection := range collections {
+		d.cache.invalidate(collection, "")
+		d.forgetDirs(collection)
+		d.configs.forget(collection)
+		d.blooms.forget(collection)
+		d.schemas.forget(collection)
+	}
+
+	// the renamed directories only survive a crash once their parents are flushed
+	if d.durable {
+		if err := syncFile(filepath.Dir(dst)); err != nil {
+			return err
+		}
+		if err := syncFile(filepath.Dir(src)); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(old)
+}
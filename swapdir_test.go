@@ -0,0 +1,77 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSwapDir(t *testing.T) {
+	d := newTestDB(t, &Options{CacheTTL: time.Hour})
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// warm the cache so a stale record would show
+	if err := d.Read(collection, "redfish", &Fish{}); err != nil {
+		t.Fatal("Failed to read fish: ", err.Error())
+	}
+
+	// the new dataset is built off to the side
+	staged := newTestDB(t, nil)
+	if err := staged.Write(collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.SwapDir(staged.dir); err != nil {
+		t.Fatal("Failed to swap directory: ", err.Error())
+	}
+
+	if err := d.Read(collection, "redfish", &Fish{}); err == nil {
+		t.Error("Expected the old record to be gone")
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "bluefish", &fish); err != nil || fish.Type != "blue" {
+		t.Error("Expected bluefish from the new directory, got: ", fish, err)
+	}
+
+	if _, err := os.Stat(staged.dir); !os.IsNotExist(err) {
+		t.Error("Expected the new directory to be moved into place")
+	}
+
+	// nothing but the database is left beside it
+	entries, err := os.ReadDir(filepath.Dir(d.dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Error("Expected the old directory to be removed, got: ", len(entries), " entries")
+	}
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+}
+
+func TestSwapDirInvalid(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.SwapDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error swapping in a missing directory")
+	}
+
+	if err := d.SwapDir(d.collectionDir(collection)); err == nil {
+		t.Error("Expected an error swapping in a directory inside the database")
+	}
+
+	if err := d.Read(collection, "redfish", &Fish{}); err != nil {
+		t.Error("Expected the database to be kept, got: ", err.Error())
+	}
+}
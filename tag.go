@@ -0,0 +1,469 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	marshalerType       = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// taggedField is a struct field as it's named by the driver's tag key
+type taggedField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// tagFields caches the fields of struct types, keyed by type and tag key
+var tagFields sync.Map
+
+type tagFieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// fieldsOf returns the fields of struct type [t] named by [tag], with the
+// fields of embedded structs promoted unless an outer field has their name
+func fieldsOf(t reflect.Type, tag string) []taggedField {
+	key := tagFieldsKey{t, tag}
+	if fields, ok := tagFields.Load(key); ok {
+		return fields.([]taggedField)
+	}
+
+	var fields []taggedField
+	taken := map[string]bool{}
+
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+
+	visited := map[reflect.Type]bool{}
+	for current := []level{{t: t}}; len(current) > 0; {
+		var next []level
+
+		// names at the same depth are collected before they're taken
+		var found []taggedField
+		for _, l := range current {
+			if visited[l.t] {
+				continue
+			}
+			visited[l.t] = true
+
+			for i := 0; i < l.t.NumField(); i++ {
+				sf := l.t.Field(i)
+				index := append(append([]int{}, l.index...), i)
+
+				name, opts, _ := strings.Cut(sf.Tag.Get(tag), ",")
+				if name == "-" && opts == "" {
+					continue
+				}
+
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// untagged embedded structs have their fields promoted
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					// unless they can't be allocated when decoding
+					if sf.Type.Kind() != reflect.Ptr || sf.IsExported() {
+						next = append(next, level{t: ft, index: index})
+					}
+					continue
+				}
+
+				if !sf.IsExported() {
+					continue
+				}
+
+				if name == "" {
+					name = sf.Name
+				}
+
+				found = append(found, taggedField{
+					name:      name,
+					index:     index,
+					omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+				})
+			}
+		}
+
+		for _, f := range found {
+			if !taken[f.name] {
+				taken[f.name] = true
+				fields = append(fields, f)
+			}
+		}
+
+		current = next
+	}
+
+	// keep the order fields are declared in, like encoding/json
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	tagFields.Store(key, fields)
+	return fields
+}
+
+// taggedObject is a JSON object whose members keep their order
+type taggedObject []taggedMember
+
+type taggedMember struct {
+	name  string
+	value interface{}
+}
+
+func (o taggedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// tagged converts [v] into values encoding/json marshals the way [tag] names
+// the struct fields in it; types marshaling themselves are left alone
+func tagged(v reflect.Value, tag string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	t := v.Type()
+	if t.Implements(marshalerType) || t.Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	if v.CanAddr() && (reflect.PtrTo(t).Implements(marshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) {
+		return v.Addr().Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return tagged(v.Elem(), tag)
+
+	case reflect.Struct:
+		obj := taggedObject{}
+		for _, f := range fieldsOf(t, tag) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+				continue
+			}
+			obj = append(obj, taggedMember{name: f.name, value: tagged(fv, tag)})
+		}
+		return obj
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key()
+
+			var name string
+			switch k.Kind() {
+			case reflect.String:
+				name = k.String()
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				name = strconv.FormatInt(k.Int(), 10)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				name = strconv.FormatUint(k.Uint(), 10)
+			default:
+				// keys encoding/json converts itself
+				return v.Interface()
+			}
+
+			m[name] = tagged(iter.Value(), tag)
+		}
+		return m
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+
+		// byte slices stay base64 strings
+		if t.Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+
+	case reflect.Array:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = tagged(v.Index(i), tag)
+		}
+		return s
+	}
+
+	return v.Interface()
+}
+
+// fieldByIndex is reflect's FieldByIndex, reporting false instead of
+// panicking when it runs into a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// fieldByIndexAlloc is reflect's FieldByIndex, allocating nil embedded
+// pointers on the way
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// unmarshalTagged decodes the record [b] into [v], matching object members
+// to struct fields by the tag key
+func (d *Driver) unmarshalTagged(b []byte, v interface{}, opts DecodeOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	// numbers stay exact until they reach their destination
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+
+	// like json.Unmarshal, reject anything after the record
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+
+	return d.assignTagged(rv.Elem(), data, opts)
+}
+
+// assignTagged stores the decoded JSON [data] in [v]; everything but structs
+// and the containers holding them is left to encoding/json
+func (d *Driver) assignTagged(v reflect.Value, data interface{}, opts DecodeOptions) error {
+	t := v.Type()
+	if reflect.PtrTo(t).Implements(unmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return d.assignJSON(v, data, opts)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if data == nil {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return d.assignTagged(v.Elem(), data, opts)
+
+	case reflect.Struct:
+		if data == nil {
+			return nil
+		}
+
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot unmarshal %T into %v", data, t)
+		}
+
+		fields := fieldsOf(t, d.tagKey)
+		for name, value := range obj {
+			f, ok := lookupField(fields, name)
+			if !ok {
+				if opts.DisallowUnknownFields {
+					return fmt.Errorf("unknown field %q", name)
+				}
+				continue
+			}
+
+			if err := d.assignTagged(fieldByIndexAlloc(v, f.index), value, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Slice:
+		s, ok := data.([]interface{})
+		if !ok || t.Elem().Kind() == reflect.Uint8 {
+			return d.assignJSON(v, data, opts)
+		}
+
+		out := reflect.MakeSlice(t, len(s), len(s))
+		for i, value := range s {
+			if err := d.assignTagged(out.Index(i), value, opts); err != nil {
+				return err
+			}
+		}
+		v.Set(out)
+		return nil
+
+	case reflect.Array:
+		s, ok := data.([]interface{})
+		if !ok {
+			return d.assignJSON(v, data, opts)
+		}
+
+		v.Set(reflect.Zero(t))
+		for i := 0; i < v.Len() && i < len(s); i++ {
+			if err := d.assignTagged(v.Index(i), s[i], opts); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return d.assignJSON(v, data, opts)
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(t, len(obj)))
+		}
+
+		for name, value := range obj {
+			k := reflect.New(t.Key()).Elem()
+			switch k.Kind() {
+			case reflect.String:
+				k.SetString(name)
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				n, err := strconv.ParseInt(name, 10, 64)
+				if err != nil || k.OverflowInt(n) {
+					return fmt.Errorf("cannot unmarshal key %q into %v", name, t.Key())
+				}
+				k.SetInt(n)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				n, err := strconv.ParseUint(name, 10, 64)
+				if err != nil || k.OverflowUint(n) {
+					return fmt.Errorf("cannot unmarshal key %q into %v", name, t.Key())
+				}
+				k.SetUint(n)
+			default:
+				// keys encoding/json converts itself
+				return d.assignJSON(v, data, opts)
+			}
+
+			e := reflect.New(t.Elem()).Elem()
+			if err := d.assignTagged(e, value, opts); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, e)
+		}
+		return nil
+	}
+
+	return d.assignJSON(v, data, opts)
+}
+
+// assignJSON stores [data] in [v] with encoding/json
+func (d *Driver) assignJSON(v reflect.Value, data interface{}, opts DecodeOptions) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return d.unmarshalJSON(b, v.Addr().Interface(), opts)
+}
+
+// lookupField finds the field [name] refers to, preferring an exact match
+// but accepting any case like encoding/json does
+func lookupField(fields []taggedField, name string) (taggedField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+
+	return taggedField{}, false
+}
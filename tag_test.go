@@ -0,0 +1,85 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tagInner struct {
+	Depth int `jsondb:"depth"`
+}
+
+type tagFish struct {
+	tagInner
+	Type    string            `json:"type" jsondb:"kind"`
+	Secret  string            `json:"-" jsondb:"secret"`
+	Public  string            `json:"public" jsondb:"-"`
+	Empty   string            `jsondb:"empty,omitempty"`
+	Born    time.Time         `jsondb:"born"`
+	Friends []tagInner        `jsondb:"friends"`
+	Tanks   map[int]*tagInner `jsondb:"tanks"`
+	Extra   interface{}       `jsondb:"extra"`
+}
+
+func TestTagKey(t *testing.T) {
+	d := newTestDB(t, &Options{TagKey: "jsondb", UseNumber: true})
+
+	born := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	in := tagFish{
+		tagInner: tagInner{Depth: 3},
+		Type:     "red",
+		Secret:   "hidden",
+		Public:   "api only",
+		Born:     born,
+		Friends:  []tagInner{{Depth: 1}},
+		Tanks:    map[int]*tagInner{7: {Depth: 2}},
+		Extra:    map[string]interface{}{"n": 1},
+	}
+
+	if err := d.Write(collection, "red", in); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	b, err := os.ReadFile(d.recordPath(collection, "red"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"depth":3,"kind":"red","secret":"hidden","born":"2020-01-02T03:04:05Z",` +
+		`"friends":[{"depth":1}],"tanks":{"7":{"depth":2}},"extra":{"n":1}}`
+	if string(b) != want {
+		t.Error("Expected record ", want, ", got: ", string(b))
+	}
+
+	var out tagFish
+	if err := d.Read(collection, "red", &out); err != nil {
+		t.Fatal("Read fish failed: ", err.Error())
+	}
+
+	in.Public = ""
+	in.Extra = map[string]interface{}{"n": json.Number("1")}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("Expected %+v, got: %+v", in, out)
+	}
+
+	// values other than structs decode as usual
+	var m map[string]interface{}
+	if err := d.Read(collection, "red", &m); err != nil || m["kind"] != "red" {
+		t.Error("Expected a generic map, got: ", m, err)
+	}
+
+	if err := d.Read(collection, "red", out); err == nil {
+		t.Error("Expected an error reading into a non pointer")
+	}
+}
+
+func TestTagKeyJSON(t *testing.T) {
+	d := newTestDB(t, &Options{TagKey: "json"})
+
+	if d.tagKey != "" {
+		t.Error("Expected the json tag key to use encoding/json directly, got: ", d.tagKey)
+	}
+}
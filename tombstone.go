@@ -0,0 +1,220 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// tombstoneDir is the directory in each collection holding its tombstones
+const tombstoneDir = ".tombstones"
+
+// Tombstone marks a record deleted while Options.Tombstones is set
+type Tombstone struct {
+	Resource string    `json:"resource"`
+	Deleted  time.Time `json:"deleted"`
+}
+
+// ListTombstones returns the tombstones of a collection's deleted records in
+// sorted resource order
+func (d *Driver) ListTombstones(collection string) ([]Tombstone, error) {
+	// ensure there is a collection to list
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return d.readTombstones(collection)
+}
+
+// DeletedRecord is a record deleted while Options.Tombstones is set, as
+// returned by RecentlyDeleted
+type DeletedRecord struct {
+	Resource string
+	Deleted  time.Time
+}
+
+// RecentlyDeleted returns the records of a collection deleted after [since],
+// most recently deleted first, from the collection's tombstones. Without
+// Options.Tombstones nothing is kept about deletes, so it returns an empty
+// slice.
+func (d *Driver) RecentlyDeleted(collection string, since time.Time) ([]DeletedRecord, error) {
+	collection = d.normalize(collection)
+
+	// ensure there is a collection to list
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	if err := d.checkNames(collection, ""); err != nil {
+		return nil, err
+	}
+
+	deleted := []DeletedRecord{}
+	if !d.tombstones {
+		return deleted, nil
+	}
+
+	unlock, err := d.rlockCollections(collection)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tombstones, err := d.readTombstones(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tombstones {
+		if t.Deleted.After(since) {
+			deleted = append(deleted, DeletedRecord{Resource: t.Resource, Deleted: t.Deleted})
+		}
+	}
+
+	sort.SliceStable(deleted, func(i, j int) bool {
+		return deleted[i].Deleted.After(deleted[j].Deleted)
+	})
+
+	return deleted, nil
+}
+
+// PurgeTombstones removes every tombstone in the database for a delete made
+// before [before], returning how many were removed. A seed record whose
+// tombstone is purged becomes visible again.
+func (d *Driver) PurgeTombstones(before time.Time) (purged int, err error) {
+	collections, err := d.allCollections()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, collection := range collections {
+		n, err := d.purgeTombstones(collection, before)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+
+	return purged, nil
+}
+
+func (d *Driver) purgeTombstones(collection string, before time.Time) (purged int, err error) {
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	tombstones, err := d.readTombstones(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range tombstones {
+		if !t.Deleted.Before(before) {
+			continue
+		}
+
+		if err := os.Remove(d.tombstonePath(collection, t.Resource)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// bury replaces a record with a tombstone; the caller holds the collection lock
+func (d *Driver) bury(collection, resource string) error {
+	if _, err := d.statRecord(collection, resource); err != nil {
+		return d.deleteMissing(filepath.Join(collection, d.fileName(resource)))
+	}
+
+	b, err := json.Marshal(Tombstone{Resource: resource, Deleted: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(d.collectionDir(collection), tombstoneDir), dirMode); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(d.tombstonePath(collection, resource), b); err != nil {
+		return err
+	}
+
+	if err := os.Remove(d.recordPath(collection, resource)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return d.recordChanged(collection, resource)
+}
+
+// unbury removes the tombstone of a record that was written again
+func (d *Driver) unbury(collection, resource string) error {
+	if !d.tombstones {
+		return nil
+	}
+
+	err := os.Remove(d.tombstonePath(collection, resource))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// buried reports whether [resource] has a tombstone
+func (d *Driver) buried(collection, resource string) bool {
+	if !d.tombstones {
+		return false
+	}
+
+	_, err := os.Stat(d.tombstonePath(collection, resource))
+	return err == nil
+}
+
+func (d *Driver) readTombstones(collection string) ([]Tombstone, error) {
+	files, err := os.ReadDir(filepath.Join(d.collectionDir(collection), tombstoneDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstones []Tombstone
+	for _, file := range files {
+		if !d.isRecord(file) {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(d.collectionDir(collection), tombstoneDir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var t Tombstone
+		if err := json.Unmarshal(b, &t); err != nil {
+			return nil, fmt.Errorf("tombstone %s/%s: %w", collection, file.Name(), err)
+		}
+		tombstones = append(tombstones, t)
+	}
+
+	return tombstones, nil
+}
+
+func (d *Driver) tombstonePath(collection, resource string) string {
+	return filepath.Join(d.collectionDir(collection), tombstoneDir, d.fileName(resource))
+}
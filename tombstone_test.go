@@ -0,0 +1,99 @@
+package jsondb
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTombstones(t *testing.T) {
+	seeds := fstest.MapFS{"fish/blue": {Data: []byte(`{"type":"blue"}`)}}
+	d := newTestDB(t, &Options{Tombstones: true, SourceFS: seeds})
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	before := time.Now().Add(-time.Second)
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Delete(collection, name); err != nil {
+			t.Fatal("Failed to delete: ", err.Error())
+		}
+	}
+
+	// deleted records, seeds included, are gone
+	if records, err := d.ReadAll(collection); err != nil || len(records) != 0 {
+		t.Error("Expected no records, got: ", len(records), err)
+	}
+
+	if err := d.Read(collection, "blue", &Fish{}); err == nil {
+		t.Error("Expected deleted seed to stay hidden")
+	}
+
+	tombstones, err := d.ListTombstones(collection)
+	if err != nil || len(tombstones) != 2 {
+		t.Fatal("Expected 2 tombstones, got: ", tombstones, err)
+	}
+
+	if tombstones[0].Resource != "blue" || tombstones[0].Deleted.Before(before) {
+		t.Error("Unexpected tombstone: ", tombstones[0])
+	}
+
+	// writing a record again lifts its tombstone
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if tombstones, _ := d.ListTombstones(collection); len(tombstones) != 1 {
+		t.Error("Expected 1 tombstone, got: ", tombstones)
+	}
+
+	// only tombstones older than the cutoff are purged
+	if purged, err := d.PurgeTombstones(before); err != nil || purged != 0 {
+		t.Error("Expected nothing purged, got: ", purged, err)
+	}
+
+	if purged, err := d.PurgeTombstones(time.Now().Add(time.Second)); err != nil || purged != 1 {
+		t.Error("Expected 1 purged, got: ", purged, err)
+	}
+}
+
+func TestRecentlyDeleted(t *testing.T) {
+	d := newTestDB(t, &Options{Tombstones: true})
+
+	for _, name := range []string{"old", "red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	if err := d.Delete(collection, "old"); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	since := time.Now()
+
+	for _, name := range []string{"red", "blue"} {
+		time.Sleep(10 * time.Millisecond)
+		if err := d.Delete(collection, name); err != nil {
+			t.Fatal("Failed to delete: ", err.Error())
+		}
+	}
+
+	deleted, err := d.RecentlyDeleted(collection, since)
+	if err != nil {
+		t.Fatal("Failed to list deleted fish: ", err.Error())
+	}
+
+	// the latest delete comes first
+	if len(deleted) != 2 || deleted[0].Resource != "blue" || deleted[1].Resource != "red" {
+		t.Error("Expected blue and red fish, got: ", deleted)
+	}
+
+	// nothing is known about deletes without tombstones
+	d = newTestDB(t, nil)
+	if deleted, err := d.RecentlyDeleted(collection, since); err != nil || deleted == nil || len(deleted) != 0 {
+		t.Error("Expected an empty slice, got: ", deleted, err)
+	}
+}
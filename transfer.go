@@ -0,0 +1,148 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInsufficientBalance is returned by Transfer when the record to take
+// from holds less than the amount
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// ErrWALRequired is returned by Transfer when Options.WAL is off
+var ErrWALRequired = errors.New("write-ahead log required - Options.WAL is off")
+
+// Transfer locks the collection and moves [amount] from the numeric [field]
+// of [fromResource] to the same field of [toResource], leaving both records
+// unchanged when either is missing or not a JSON object, or when
+// [fromResource] holds less than [amount]. A field missing from a record
+// counts as zero. Both records are stored together through the write-ahead
+// log, so a crash applies both or neither; without Options.WAL, which makes
+// New replay the log, Transfer returns ErrWALRequired.
+func (d *Driver) Transfer(collection, fromResource, toResource, field string, amount float64) (err error) {
+	collection = d.normalize(collection)
+	fromResource, toResource = d.normalize(fromResource), d.normalize(toResource)
+
+	defer wrapOpError(&err, "transfer", collection, fromResource)
+	defer d.countOp(collection, opWrite, &err)
+
+	// ensure there is a place to update
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there are resources to update
+	if fromResource == "" || toResource == "" {
+		return ErrMissingResource
+	}
+
+	if fromResource == toResource {
+		return fmt.Errorf("cannot transfer from %s to itself", fromResource)
+	}
+
+	if amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return fmt.Errorf("invalid amount %v", amount)
+	}
+
+	// a crash between the two stores would create or destroy [amount]
+	if !d.wal {
+		return ErrWALRequired
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, "")
+
+	from, fromBalance, err := d.readBalance(collection, fromResource, field)
+	if err != nil {
+		return err
+	}
+
+	to, toBalance, err := d.readBalance(collection, toResource, field)
+	if err != nil {
+		return err
+	}
+
+	if fromBalance < amount {
+		return fmt.Errorf("%w: %s holds %v of %s, %v needed", ErrInsufficientBalance, fromResource, fromBalance, field, amount)
+	}
+
+	ops := make([]walOp, 0, 2)
+	for _, r := range []struct {
+		name    string
+		obj     taggedObject
+		balance float64
+	}{
+		{fromResource, from, fromBalance - amount},
+		{toResource, to, toBalance + amount},
+	} {
+		b, err := d.marshal(collection, r.name, setMember(r.obj, field, r.balance))
+		if err != nil {
+			return &OpError{Op: "transfer", Collection: collection, Resource: r.name, Err: err}
+		}
+		b, err = d.resolve(collection, r.name, b)
+		if err != nil {
+			return &OpError{Op: "transfer", Collection: collection, Resource: r.name, Err: err}
+		}
+		ops = append(ops, walOp{Resource: r.name, Data: b})
+	}
+
+	return d.commitOps("transfer", collection, ops)
+}
+
+// readBalance reads [resource] as an object along with the number its
+// [field] holds; the caller holds the collection lock
+func (d *Driver) readBalance(collection, resource, field string) (taggedObject, float64, error) {
+	b, err := d.readExisting(collection, resource)
+	if err != nil {
+		return nil, 0, &OpError{Op: "read", Collection: collection, Resource: resource, Err: err}
+	}
+
+	obj, ok, err := parseObject(b)
+	if err != nil {
+		return nil, 0, &OpError{Op: "read", Collection: collection, Resource: resource, Err: err}
+	}
+	if !ok {
+		return nil, 0, &OpError{Op: "read", Collection: collection, Resource: resource, Err: ErrInvalidRecordShape}
+	}
+
+	for _, m := range obj {
+		if m.name != field {
+			continue
+		}
+
+		v, err := decodeValue(m.value.(json.RawMessage))
+		if err != nil {
+			return nil, 0, err
+		}
+		n, ok := v.(json.Number)
+		if !ok {
+			return nil, 0, fmt.Errorf("%s: field %s is not a number", resource, field)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, 0, err
+		}
+		return obj, f, nil
+	}
+
+	return obj, 0, nil
+}
+
+// setMember returns [obj] with its member [name] set to [value], appending
+// the member when it's missing
+func setMember(obj taggedObject, name string, value interface{}) taggedObject {
+	for i := range obj {
+		if obj[i].name == name {
+			obj[i].value = value
+			return obj
+		}
+	}
+
+	return append(obj, taggedMember{name: name, value: value})
+}
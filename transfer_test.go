@@ -0,0 +1,102 @@
+package jsondb
+
+import (
+	"errors"
+	"testing"
+)
+
+type account struct {
+	Owner   string  `json:"owner"`
+	Balance float64 `json:"balance"`
+}
+
+func TestTransfer(t *testing.T) {
+	d := newTestDB(t, &Options{WAL: true})
+
+	if err := d.Write("accounts", "alice", account{Owner: "alice", Balance: 100}); err != nil {
+		t.Fatal("Create account failed: ", err.Error())
+	}
+
+	// the field is missing from bob and counts as zero
+	if err := d.Write("accounts", "bob", map[string]string{"owner": "bob"}); err != nil {
+		t.Fatal("Create account failed: ", err.Error())
+	}
+
+	if err := d.Transfer("accounts", "alice", "bob", "balance", 30); err != nil {
+		t.Fatal("Failed to transfer: ", err.Error())
+	}
+
+	alice, bob := account{}, account{}
+	if err := d.Read("accounts", "alice", &alice); err != nil {
+		t.Fatal("Failed to read account: ", err.Error())
+	}
+	if err := d.Read("accounts", "bob", &bob); err != nil {
+		t.Fatal("Failed to read account: ", err.Error())
+	}
+
+	if alice != (account{Owner: "alice", Balance: 70}) || bob != (account{Owner: "bob", Balance: 30}) {
+		t.Error("Expected balances of 70 and 30, got: ", alice, bob)
+	}
+}
+
+func TestTransferRefused(t *testing.T) {
+	d := newTestDB(t, &Options{WAL: true})
+
+	if err := d.Write("accounts", "alice", account{Owner: "alice", Balance: 10}); err != nil {
+		t.Fatal("Create account failed: ", err.Error())
+	}
+	if err := d.Write("accounts", "bob", account{Owner: "bob", Balance: 5}); err != nil {
+		t.Fatal("Create account failed: ", err.Error())
+	}
+	if err := d.Write("accounts", "list", []int{1, 2}); err != nil {
+		t.Fatal("Create record failed: ", err.Error())
+	}
+
+	if err := d.Transfer("accounts", "alice", "bob", "balance", 20); !errors.Is(err, ErrInsufficientBalance) {
+		t.Error("Expected ErrInsufficientBalance, got: ", err)
+	}
+
+	if err := d.Transfer("accounts", "alice", "carol", "balance", 1); !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrNotFound, got: ", err)
+	}
+
+	if err := d.Transfer("accounts", "alice", "list", "balance", 1); !errors.Is(err, ErrInvalidRecordShape) {
+		t.Error("Expected ErrInvalidRecordShape, got: ", err)
+	}
+
+	if err := d.Transfer("accounts", "alice", "alice", "balance", 1); err == nil {
+		t.Error("Expected a transfer to itself to fail")
+	}
+
+	// nothing was changed by the refused transfers
+	alice, bob := account{}, account{}
+	if err := d.Read("accounts", "alice", &alice); err != nil {
+		t.Fatal("Failed to read account: ", err.Error())
+	}
+	if err := d.Read("accounts", "bob", &bob); err != nil {
+		t.Fatal("Failed to read account: ", err.Error())
+	}
+
+	if alice.Balance != 10 || bob.Balance != 5 {
+		t.Error("Expected balances of 10 and 5, got: ", alice, bob)
+	}
+}
+
+func TestTransferRequiresWAL(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, owner := range []string{"alice", "bob"} {
+		if err := d.Write("accounts", owner, account{Owner: owner, Balance: 10}); err != nil {
+			t.Fatal("Create account failed: ", err.Error())
+		}
+	}
+
+	if err := d.Transfer("accounts", "alice", "bob", "balance", 1); !errors.Is(err, ErrWALRequired) {
+		t.Error("Expected ErrWALRequired, got: ", err)
+	}
+
+	alice := account{}
+	if err := d.Read("accounts", "alice", &alice); err != nil || alice.Balance != 10 {
+		t.Error("Expected alice unchanged, got: ", alice, err)
+	}
+}
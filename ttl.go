@@ -0,0 +1,181 @@
+package jsondb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"time"
+)
+
+// expiresField is the top-level member holding the expiry of a record
+// written by WriteTTL, in nanoseconds since the Unix epoch
+const expiresField = "_expires"
+
+// ErrExpired is returned when reading a record past the expiry WriteTTL gave
+// it. It matches fs.ErrNotExist, so an expired record is handled like one
+// that was never written.
+var ErrExpired = fmt.Errorf("%w: record expired", fs.ErrNotExist)
+
+// WriteTTL locks [resource] and stores [v] as the record like Write, except
+// that it expires after [ttl]: from then on it reads as ErrExpired, reading
+// it or calling Purge deletes it, and it no longer holds its unique values.
+// Until it's deleted, what reads records skips it, be it ReadAll, Find,
+// IterateAll, IterateShard, IterateSortedBy, Aggregate, InferSchema,
+// StreamNDJSON, ReadWithInfo, MirrorTo, Backup or RebuildIndex. What only
+// looks at names still sees it, namely Count, Keys, Exists and LookupIndex,
+// and so do DiffCollections, Dump, ExportZip and snapshots, which copy what
+// is stored. The expiry is kept in the "_expires" member of the record, set
+// like "_rev" before the record is formatted, so [v] must marshal to a JSON
+// object; records stored with Write have no expiry and never expire.
+func (d *Driver) WriteTTL(collection, resource string, v interface{}, ttl time.Duration) (err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	defer wrapOpError(&err, "write", collection, resource)
+	defer d.countOp(collection, opWrite, &err)
+
+	// ensure there is a place to save record
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	// ensure there is a resource (name) to save record as
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	if ttl <= 0 {
+		return fmt.Errorf("invalid ttl %v", ttl)
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	b, err := d.marshalStamped(collection, resource, v, stamps{expires: time.Now().Add(ttl).UnixNano()})
+	if err != nil {
+		return err
+	}
+
+	return d.writeResolved(collection, resource, b)
+}
+
+// stampExpiry sets the expiry member of the record [b] to [expires]; only an
+// object has a member to keep it in
+func stampExpiry(b []byte, expires int64) ([]byte, error) {
+	obj, ok, err := parseObject(b)
+	if err != nil || !ok {
+		return nil, ErrInvalidRecordShape
+	}
+
+	return setMember(obj, expiresField, json.RawMessage(strconv.FormatInt(expires, 10))).MarshalJSON()
+}
+
+// Purge locks [collection] and deletes every record in it past the expiry
+// WriteTTL gave it, returning how many it deleted. Expired records are never
+// read, so Purge only reclaims their space; run it as often as that matters.
+func (d *Driver) Purge(collection string) (deleted int, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "purge", collection, "")
+	defer d.countOp(collection, opDelete, &err)
+
+	// ensure there is a collection to purge
+	if collection == "" {
+		return 0, ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	names, err := d.list(collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		// nothing to purge in a collection that doesn't exist
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		b, err := d.readFile(collection, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return deleted, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		if !expired(b, now) {
+			continue
+		}
+
+		err = d.remove(collection, name)
+		d.cache.invalidate(collection, name)
+		if err != nil {
+			return deleted, &OpError{Op: "delete", Collection: collection, Resource: name, Err: err}
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// dropExpired deletes [resource] if it's still expired once locked, a write
+// meanwhile having stored a live record in its place. Failing to delete it
+// is ignored: the record still reads as expired, and Purge tries again.
+func (d *Driver) dropExpired(collection, resource string) {
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	if b, err := d.readFile(collection, resource); err == nil && expired(b, time.Now()) {
+		d.remove(collection, resource)
+	}
+}
+
+// isExpired reports whether the stored record [resource] is past its expiry
+func (d *Driver) isExpired(collection, resource string) bool {
+	_, err := d.readLive(collection, resource)
+	return errors.Is(err, ErrExpired)
+}
+
+// expired reports whether the record [b] has an expiry and it's before
+// [now]; a record without one, or that isn't a JSON object, never expires
+func expired(b []byte, now time.Time) bool {
+	// most records have no expiry, and are told apart without parsing
+	if !bytes.Contains(b, []byte(`"`+expiresField+`"`)) {
+		return false
+	}
+
+	obj, ok, err := parseObject(b)
+	if err != nil || !ok {
+		return false
+	}
+
+	for _, m := range obj {
+		if m.name != expiresField {
+			continue
+		}
+
+		var expires int64
+		if err := json.Unmarshal(m.value.(json.RawMessage), &expires); err != nil {
+			return false
+		}
+		return now.UnixNano() >= expires
+	}
+
+	return false
+}
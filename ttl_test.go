@@ -0,0 +1,119 @@
+package jsondb
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteTTL(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.WriteTTL(collection, "red", redfish, time.Hour); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.WriteTTL(collection, "blue", Fish{Type: "blue"}, time.Millisecond); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish != redfish {
+		t.Error("Expected redfish, got: ", fish, err)
+	}
+
+	// an expired record reads as missing and is deleted
+	err := d.Read(collection, "blue", &fish)
+	if !errors.Is(err, ErrExpired) || !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected ErrExpired, got: ", err)
+	}
+	if _, err := os.Stat(d.recordPath(collection, "blue")); !os.IsNotExist(err) {
+		t.Error("Expected the expired record to be deleted, got: ", err)
+	}
+
+	if err := d.WriteTTL(collection, "list", []int{1}, time.Hour); !errors.Is(err, ErrInvalidRecordShape) {
+		t.Error("Expected ErrInvalidRecordShape, got: ", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.WriteTTL(collection, "blue", Fish{Type: "blue"}, time.Hour); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	for _, name := range []string{"green", "yellow"} {
+		if err := d.WriteTTL(collection, name, Fish{Type: name}, time.Millisecond); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// expired records are skipped before they are purged
+	records, err := d.ReadAll(collection)
+	if err != nil || len(records) != 2 {
+		t.Error("Expected 2 records, got: ", len(records), err)
+	}
+
+	if deleted, err := d.Purge(collection); err != nil || deleted != 2 {
+		t.Error("Expected 2 records purged, got: ", deleted, err)
+	}
+
+	if n, err := d.Count(collection); err != nil || n != 2 {
+		t.Error("Expected 2 records left, got: ", n, err)
+	}
+
+	if deleted, err := d.Purge("missing"); err != nil || deleted != 0 {
+		t.Error("Expected nothing purged from a missing collection, got: ", deleted, err)
+	}
+}
+
+func TestExpiredSkipped(t *testing.T) {
+	d := newTestDB(t, &Options{TrailingNewline: true})
+
+	if err := d.AddUniqueConstraint(collection, "type"); err != nil {
+		t.Fatal("Failed to add constraint: ", err.Error())
+	}
+
+	if err := d.Write(collection, "red", map[string]interface{}{"type": "red", "n": 1}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.WriteTTL(collection, "blue", map[string]interface{}{"type": "blue", "n": 100}, time.Millisecond); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the expiry is set before the record is formatted
+	b, err := os.ReadFile(d.recordPath(collection, "blue"))
+	if err != nil || !bytes.HasSuffix(b, []byte("}\n")) {
+		t.Error("Expected the trailing newline kept, got: ", string(b), err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	iterated := 0
+	if err := d.IterateAll(func(collection, resource string, raw []byte) error {
+		iterated++
+		return nil
+	}); err != nil || iterated != 1 {
+		t.Error("Expected 1 record iterated, got: ", iterated, err)
+	}
+
+	if agg, err := d.Aggregate(collection, "n"); err != nil || agg.Count != 1 || agg.Sum != 1 {
+		t.Error("Expected only red aggregated, got: ", agg, err)
+	}
+
+	var out bytes.Buffer
+	if err := d.StreamNDJSON(collection, &out); err != nil || bytes.Contains(out.Bytes(), []byte("blue")) {
+		t.Error("Expected blue left out of the stream, got: ", out.String(), err)
+	}
+
+	// the expired record doesn't hold on to its unique value
+	if err := d.Write(collection, "other", map[string]interface{}{"type": "blue"}); err != nil {
+		t.Error("Expected the expired value to be free, got: ", err)
+	}
+}
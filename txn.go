@@ -0,0 +1,161 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// CollectionTxn stages the reads and writes of a transaction on a single
+// collection; it's only valid inside the function given to
+// Driver.CollectionTxn
+type CollectionTxn struct {
+	d          *Driver
+	collection string
+	staged     map[string]walOp // pending writes and deletes by resource
+}
+
+// CollectionTxn locks [collection] for the duration of [fn] and hands it a
+// transaction whose writes and deletes are staged in memory. When [fn]
+// returns nil the staged changes are committed together, the same way
+// WriteAll commits, so with Options.WAL they survive a crash as a whole; when
+// it returns an error nothing is changed and the error is returned. [fn] must
+// go through the transaction, since calling the driver for the same
+// collection would wait for the lock it holds.
+func (d *Driver) CollectionTxn(collection string, fn func(txn *CollectionTxn) error) (err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "txn", collection, "")
+
+	// ensure there is a collection to work on
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	txn := &CollectionTxn{d: d, collection: collection, staged: make(map[string]walOp)}
+	if err := fn(txn); err != nil {
+		return err
+	}
+
+	return txn.commit()
+}
+
+// Read reads a record as the transaction sees it, including its own staged
+// writes and deletes
+func (t *CollectionTxn) Read(resource string, v interface{}) error {
+	resource = t.d.normalize(resource)
+
+	// ensure there is a resource (name) to read
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	if op, ok := t.staged[resource]; ok {
+		if op.Delete {
+			return &fs.PathError{Op: "read", Path: t.d.recordPath(t.collection, resource), Err: fs.ErrNotExist}
+		}
+		return t.d.unmarshal(op.Data, v)
+	}
+
+	b, err := t.d.read(t.collection, resource)
+	if err != nil {
+		return err
+	}
+
+	// the collection lock is already held, so migrated records aren't rewritten
+	if b, err = t.d.migrateRecord(t.collection, resource, b, false); err != nil {
+		return err
+	}
+
+	return t.d.unmarshal(b, v)
+}
+
+// Write stages [v] as the record [resource]; it's marshaled and validated
+// right away, but only stored when the transaction commits
+func (t *CollectionTxn) Write(resource string, v interface{}) error {
+	resource = t.d.normalize(resource)
+
+	// ensure there is a resource (name) to save record as
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	b, err := t.d.marshal(t.collection, resource, v)
+	if err != nil {
+		return err
+	}
+
+	t.staged[resource] = walOp{Resource: resource, Data: b}
+	return nil
+}
+
+// Delete stages the removal of the record [resource]; deleting a record that
+// doesn't exist is not an error
+func (t *CollectionTxn) Delete(resource string) error {
+	resource = t.d.normalize(resource)
+
+	// ensure there is a resource (name) to delete
+	if resource == "" {
+		return ErrMissingResource
+	}
+
+	t.staged[resource] = walOp{Resource: resource, Delete: true}
+	return nil
+}
+
+// List returns the sorted resource names of the collection as the
+// transaction sees it
+func (t *CollectionTxn) List() ([]string, error) {
+	names, err := t.d.list(t.collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	listed := []string{}
+	for _, name := range names {
+		if _, ok := t.staged[name]; !ok {
+			listed = append(listed, name)
+		}
+	}
+
+	for name, op := range t.staged {
+		if !op.Delete {
+			listed = append(listed, name)
+		}
+	}
+
+	t.d.sortNames(listed)
+	return listed, nil
+}
+
+// commit stores the staged changes; the caller holds the collection lock
+func (t *CollectionTxn) commit() error {
+	names := make([]string, 0, len(t.staged))
+	for name := range t.staged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defer t.d.cache.invalidate(t.collection, "")
+
+	ops := make([]walOp, 0, len(names))
+	for _, name := range names {
+		op := t.staged[name]
+		if !op.Delete {
+			b, err := t.d.resolve(t.collection, name, op.Data)
+			if err != nil {
+				return &OpError{Op: "write", Collection: t.collection, Resource: name, Err: err}
+			}
+			op.Data = b
+		}
+		ops = append(ops, op)
+	}
+
+	return t.d.commitOps("txn", t.collection, ops)
+}
@@ -0,0 +1,97 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+func TestCollectionTxn(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"red", "blue"} {
+		if err := d.Write(collection, name, Fish{Type: name}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	err := d.CollectionTxn(collection, func(txn *CollectionTxn) error {
+		fish := Fish{}
+		if err := txn.Read("red", &fish); err != nil {
+			return err
+		}
+
+		fish.Type = "crimson"
+		if err := txn.Write("red", fish); err != nil {
+			return err
+		}
+		if err := txn.Write("green", Fish{Type: "green"}); err != nil {
+			return err
+		}
+		if err := txn.Delete("blue"); err != nil {
+			return err
+		}
+
+		// the transaction sees its own changes
+		if err := txn.Read("red", &fish); err != nil || fish.Type != "crimson" {
+			t.Error("Expected the staged fish, got: ", fish, err)
+		}
+		if err := txn.Read("blue", &fish); !errors.Is(err, fs.ErrNotExist) {
+			t.Error("Expected the staged delete, got: ", err)
+		}
+
+		names, err := txn.List()
+		if want := []string{"green", "red"}; err != nil || !reflect.DeepEqual(names, want) {
+			t.Error("Expected ", want, ", got: ", names, err)
+		}
+
+		// nothing is stored before the commit
+		if _, err := d.statRecord(collection, "green"); !errors.Is(err, ErrNotFound) {
+			t.Error("Expected the staged write not to be stored yet, got: ", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal("CollectionTxn failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "crimson" {
+		t.Error("Expected the committed fish, got: ", fish, err)
+	}
+	if err := d.Read(collection, "blue", &fish); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected the committed delete, got: ", err)
+	}
+}
+
+func TestCollectionTxnDiscard(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	failed := errors.New("failed")
+	err := d.CollectionTxn(collection, func(txn *CollectionTxn) error {
+		if err := txn.Write("red", Fish{Type: "crimson"}); err != nil {
+			return err
+		}
+		if err := txn.Write("green", Fish{Type: "green"}); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Error("Expected the transaction's error, got: ", err)
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected the untouched fish, got: ", fish, err)
+	}
+	if err := d.Read(collection, "green", &fish); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("Expected nothing written, got: ", err)
+	}
+}
@@ -0,0 +1,105 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// DecodeOptions are the settings records are decoded with, given as
+// Options.DisallowUnknownFields and Options.UseNumber
+type DecodeOptions struct {
+	DisallowUnknownFields bool // decoding fails on fields the target doesn't have
+	UseNumber             bool // numbers decode into interfaces as json.Number
+}
+
+// ReadAllTyped reads every record of [collection] in sorted resource order
+// into a T, decoding each one like Read does. Non-nil [opts] replace the
+// driver's decoding options for this call only, e.g. to catch fields a
+// struct has lost across a whole collection. The first record that can't be
+// read or decoded fails the call; ReadAllResults reports them one by one.
+func ReadAllTyped[T any](d *Driver, collection string, opts *DecodeOptions) (all []T, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "readall", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	decode := d.decodeOptions()
+	if opts != nil {
+		decode = *opts
+	}
+
+	// the records are read under the collection's read lock and decoded once
+	// it's released, since writing back a migrated record takes the lock
+	names, records, err := d.readAllNamed(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	all = make([]T, 0, len(names))
+	for i, name := range names {
+		b, err := d.migrateRecord(collection, name, records[i], d.rewriteMigrated)
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		var v T
+		if err := d.unmarshalWith(b, &v, decode); err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+		all = append(all, v)
+	}
+
+	return all, nil
+}
+
+// ReadAll is ReadAllTyped with the driver's decoding options, so
+//
+//	users, err := jsondb.ReadAll[User](db, "users")
+//
+// reads every user. Temp files of writes in progress and reserved names are
+// skipped like by Driver.ReadAll, and a record that can't be decoded fails
+// the call with an *OpError naming it.
+func ReadAll[T any](d *Driver, collection string) ([]T, error) {
+	return ReadAllTyped[T](d, collection, nil)
+}
+
+// ReadOrInit reads the record [resource] into a T, or, when it doesn't
+// exist, stores what [init] returns as the record and returns that. [init]
+// is only called for a missing record, with the record locked and checked
+// again, so of concurrent callers only one initializes it and the others
+// read what it stored.
+func ReadOrInit[T any](d *Driver, collection, resource string, init func() T) (v T, err error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	if err := d.Read(collection, resource, &v); !errors.Is(err, fs.ErrNotExist) {
+		return v, err
+	}
+
+	defer wrapOpError(&err, "readorinit", collection, resource)
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return v, err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	// another caller may have stored it meanwhile
+	b, err := d.readDisk(collection, resource)
+	if err == nil {
+		if b, err = d.migrateRecord(collection, resource, b, false); err == nil {
+			err = d.unmarshal(b, &v)
+		}
+		return v, err
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return v, err
+	}
+
+	v = init()
+	return v, d.write(collection, resource, v)
+}
@@ -0,0 +1,116 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadAllTyped(t *testing.T) {
+	d := newTestDB(t, &Options{UseNumber: true})
+
+	if err := d.Write(collection, "red", map[string]interface{}{"type": "red", "size": 9007199254740993}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// the driver's options apply to every record
+	all, err := ReadAllTyped[map[string]interface{}](d, collection, nil)
+	if err != nil {
+		t.Fatal("ReadAllTyped failed: ", err.Error())
+	}
+	if len(all) != 2 || all[1]["size"] != json.Number("9007199254740993") {
+		t.Error("Expected exact numbers, got: ", all)
+	}
+
+	// lenient by default, strict for one call
+	fish, err := ReadAllTyped[Fish](d, collection, nil)
+	if err != nil || len(fish) != 2 || fish[1].Type != "red" {
+		t.Error("Expected 2 fish, got: ", fish, err)
+	}
+
+	if _, err := ReadAllTyped[Fish](d, collection, &DecodeOptions{DisallowUnknownFields: true}); err == nil {
+		t.Error("Expected the unknown size field to fail a strict read")
+	}
+}
+
+func TestReadAllGeneric(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if _, err := d.Reserve(collection, "reserved"); err != nil {
+		t.Fatal("Reserve fish failed: ", err.Error())
+	}
+
+	// a write in progress leaves a temp file behind
+	if err := os.WriteFile(d.recordPath(collection, "blue")+tmpSuffix, []byte(`{"ty`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	fish, err := ReadAll[Fish](d, collection)
+	if err != nil {
+		t.Fatal("ReadAll failed: ", err.Error())
+	}
+	if len(fish) != 1 || fish[0] != redfish {
+		t.Error("Expected only redfish, got: ", fish)
+	}
+
+	// the record that can't be decoded is named
+	if err := os.WriteFile(d.recordPath(collection, "broken"), []byte(`{"type":1}`), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	var opErr *OpError
+	if _, err := ReadAll[Fish](d, collection); !errors.As(err, &opErr) || opErr.Resource != "broken" {
+		t.Error("Expected an error naming the broken record, got: ", err)
+	}
+}
+
+func TestReadOrInit(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	var calls int32
+	init := func() Fish {
+		atomic.AddInt32(&calls, 1)
+		return redfish
+	}
+
+	// concurrent callers initialize the record once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fish, err := ReadOrInit(d, collection, "redfish", init)
+			if err != nil || fish != redfish {
+				t.Error("Expected redfish, got: ", fish, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Error("Expected init to be called once, got: ", calls)
+	}
+
+	// an existing record is read without calling init
+	if err := d.Write(collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	fish, err := ReadOrInit(d, collection, "bluefish", init)
+	if err != nil || fish.Type != "blue" || calls != 1 {
+		t.Error("Expected the stored bluefish, got: ", fish, err, calls)
+	}
+
+	if _, err := ReadOrInit(d, collection, "", init); !errors.Is(err, ErrMissingResource) {
+		t.Error("Expected ErrMissingResource, got: ", err)
+	}
+}
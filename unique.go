@@ -0,0 +1,202 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// uniqueName is the file in a collection holding its unique fields
+const uniqueName = ".unique.json"
+
+// ErrUniqueViolation is returned when a write would give a record the value
+// of a unique field another record already holds
+var ErrUniqueViolation = errors.New("unique violation - another record holds the value")
+
+// AddUniqueConstraint locks the collection and makes the top-level [field]
+// unique in it: from then on a write giving a record the value of [field]
+// another record holds fails with ErrUniqueViolation. Records without the
+// field aren't constrained. The constraint is backed by the index of
+// [field], which is built first, and isn't added when the records already
+// hold duplicates; the ErrUniqueViolation returned then names them. Like
+// validators, the constraint isn't checked by WriteFrom.
+func (d *Driver) AddUniqueConstraint(collection, field string) (err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "adduniqueconstraint", collection, "")
+
+	if err := d.checkIndexArgs(collection, field); err != nil {
+		return err
+	}
+
+	if err := d.RebuildIndex(collection, field); err != nil {
+		return err
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := readIndex(filepath.Join(d.collectionDir(collection), indexDir), field)
+	if err != nil {
+		return err
+	}
+
+	var duplicates []string
+	for key, names := range idx {
+		if len(names) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s held by %s", key, strings.Join(names, ", ")))
+		}
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return fmt.Errorf("%w: %s %s", ErrUniqueViolation, field, strings.Join(duplicates, "; "))
+	}
+
+	fields, err := d.uniqueFields(collection)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if f == field {
+			return nil
+		}
+	}
+
+	b, err := json.Marshal(append(fields, field))
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(d.collectionDir(collection), uniqueName), b)
+}
+
+// uniqueFields returns the unique fields of [collection]
+func (d *Driver) uniqueFields(collection string) ([]string, error) {
+	b, err := os.ReadFile(filepath.Join(d.collectionDir(collection), uniqueName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	return fields, json.Unmarshal(b, &fields)
+}
+
+// checkUniqueBatch is checkUnique for every record [ops] writes at once. A
+// record of [ops] holds the values it's written with rather than those it's
+// indexed under, so two of them sharing a value is a violation too.
+func (d *Driver) checkUniqueBatch(collection string, ops []walOp) (release func(), err error) {
+	release = func() {}
+
+	fields, err := d.uniqueFields(collection)
+	if err != nil || len(fields) == 0 {
+		return release, err
+	}
+
+	if d.resourceLevelLocking {
+		key := collection + "\x00unique"
+		d.resourceLocks.lock(key)
+		release = func() { d.resourceLocks.unlock(key) }
+	}
+
+	changed := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		changed[d.normalize(op.Resource)] = true
+	}
+
+	dir := filepath.Join(d.collectionDir(collection), indexDir)
+	for _, field := range fields {
+		idx, err := readIndex(dir, field)
+		if err != nil {
+			release()
+			return func() {}, err
+		}
+
+		held := make(map[string]string)
+		for _, op := range ops {
+			if op.Delete {
+				continue
+			}
+			key, ok := indexKey(op.Data, field)
+			if !ok {
+				continue
+			}
+
+			holder, taken := held[key]
+			for _, name := range idx[key] {
+				// an expired record gives up its values, and one of [ops]
+				// holds the value it's written with
+				if taken || changed[name] || d.isExpired(collection, name) {
+					continue
+				}
+				holder, taken = name, true
+			}
+
+			if taken && holder != d.normalize(op.Resource) {
+				release()
+				return func() {}, &OpError{Op: "write", Collection: collection, Resource: op.Resource,
+					Err: fmt.Errorf("%w: %s %s is held by %s", ErrUniqueViolation, field, key, holder)}
+			}
+			held[key] = d.normalize(op.Resource)
+		}
+	}
+
+	return release, nil
+}
+
+// checkUnique returns an ErrUniqueViolation if storing [b] as [resource]
+// would duplicate a unique field of another record. With resource level
+// locking, writers of a collection with unique fields take turns until the
+// returned [release] is called, so two of them can't both claim a value.
+func (d *Driver) checkUnique(collection, resource string, b []byte) (release func(), err error) {
+	release = func() {}
+
+	fields, err := d.uniqueFields(collection)
+	if err != nil || len(fields) == 0 {
+		return release, err
+	}
+
+	if d.resourceLevelLocking {
+		key := collection + "\x00unique"
+		d.resourceLocks.lock(key)
+		release = func() { d.resourceLocks.unlock(key) }
+	}
+
+	dir := filepath.Join(d.collectionDir(collection), indexDir)
+	for _, field := range fields {
+		key, ok := indexKey(b, field)
+		if !ok {
+			continue
+		}
+
+		idx, err := readIndex(dir, field)
+		if err != nil {
+			release()
+			return func() {}, err
+		}
+
+		for _, name := range idx[key] {
+			// an expired record gives up its values, though it stays
+			// indexed until it's deleted
+			if name == resource || d.isExpired(collection, name) {
+				continue
+			}
+
+			release()
+			return func() {}, fmt.Errorf("%w: %s %s is held by %s", ErrUniqueViolation, field, key, name)
+		}
+	}
+
+	return release, nil
+}
@@ -0,0 +1,89 @@
+package jsondb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUniqueConstraint(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.AddUniqueConstraint(collection, "type"); err != nil {
+		t.Fatal("Failed to add constraint: ", err.Error())
+	}
+
+	if err := d.Write(collection, "otherfish", redfish); !errors.Is(err, ErrUniqueViolation) {
+		t.Error("Expected ErrUniqueViolation, got: ", err)
+	}
+
+	// a record may keep its own value
+	if err := d.Write(collection, "redfish", redfish); err != nil {
+		t.Error("Failed to rewrite fish: ", err.Error())
+	}
+
+	if err := d.Write(collection, "bluefish", Fish{Type: "blue"}); err != nil {
+		t.Error("Create fish failed: ", err.Error())
+	}
+
+	// deleting a record frees its value
+	if err := d.Delete(collection, "redfish"); err != nil {
+		t.Fatal("Failed to delete: ", err.Error())
+	}
+
+	if err := d.Write(collection, "otherfish", redfish); err != nil {
+		t.Error("Expected the freed value to be writable, got: ", err.Error())
+	}
+
+	if err := d.DropIndex(collection, "type"); err == nil {
+		t.Error("Expected the index of a unique field to stay")
+	}
+}
+
+func TestUniqueConstraintDuplicates(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"a", "b"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	err := d.AddUniqueConstraint(collection, "type")
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Fatal("Expected ErrUniqueViolation, got: ", err)
+	}
+
+	if !strings.Contains(err.Error(), "a, b") {
+		t.Error("Expected the error to name the duplicates, got: ", err.Error())
+	}
+
+	// nothing was constrained
+	if err := d.Write(collection, "c", redfish); err != nil {
+		t.Error("Create fish failed: ", err.Error())
+	}
+}
+
+func TestUniqueConstraintBatch(t *testing.T) {
+	d := newTestDB(t, &Options{ResourceLevelLocking: true})
+
+	if err := d.AddUniqueConstraint(collection, "type"); err != nil {
+		t.Fatal("Failed to add constraint: ", err.Error())
+	}
+
+	err := d.WriteAll(collection, map[string]interface{}{"a": redfish, "b": redfish})
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Error("Expected ErrUniqueViolation, got: ", err)
+	}
+	// a gives up red in the same batch that gives it to b
+	if err := d.Write(collection, "a", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+	if err := d.WriteBatch(collection, map[string]interface{}{"a": Fish{Type: "blue"}, "b": redfish}); err != nil {
+		t.Error("Failed to hand red over: ", err.Error())
+	}
+}
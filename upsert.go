@@ -0,0 +1,98 @@
+package jsondb
+
+import (
+	"errors"
+	"sort"
+)
+
+// WriteBatchUpsert locks the collection once and merges [items] into it: an
+// item whose resource doesn't exist yet is written as it is, while for one
+// that does [onConflict] decides, from the stored and the incoming value,
+// what is written; a nil [onConflict] overwrites. The records are written
+// together the way WriteAll writes, so with Options.WAL the whole batch
+// survives a crash, and a record that can't be read, decoded or marshaled
+// leaves the collection as it was.
+func WriteBatchUpsert[T any](d *Driver, collection string, items map[string]T, onConflict func(existing, incoming T) T) (err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "upsert", collection, "")
+
+	// ensure there is a place to save records
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	incoming := make(map[string]T, len(items))
+	names := make([]string, 0, len(items))
+	for name, v := range items {
+		name = d.normalize(name)
+
+		// ensure there is a resource (name) to save each record as
+		if name == "" {
+			return ErrMissingResource
+		}
+
+		if _, ok := incoming[name]; !ok {
+			names = append(names, name)
+		}
+		incoming[name] = v
+	}
+	sort.Strings(names)
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, "")
+
+	ops := make([]walOp, 0, len(names))
+	for _, name := range names {
+		v, err := upsertValue(d, collection, name, incoming[name], onConflict)
+		if err != nil {
+			return &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+
+		b, err := d.marshal(collection, name, v)
+		if err != nil {
+			return &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+
+		if b, err = d.resolve(collection, name, b); err != nil {
+			return &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+
+		ops = append(ops, walOp{Resource: name, Data: b})
+	}
+
+	return d.commitOps("upsert", collection, ops)
+}
+
+// upsertValue returns what upserting [incoming] as [resource] stores; the
+// caller holds the collection lock
+func upsertValue[T any](d *Driver, collection, resource string, incoming T, onConflict func(existing, incoming T) T) (T, error) {
+	if onConflict == nil {
+		return incoming, nil
+	}
+
+	b, err := d.readExisting(collection, resource)
+	if errors.Is(err, ErrNotFound) || (err == nil && len(b) == 0) {
+		// nothing stored yet, or only reserved
+		return incoming, nil
+	}
+	if err != nil {
+		return incoming, err
+	}
+
+	// the collection lock is already held, so migrated records aren't rewritten
+	if b, err = d.migrateRecord(collection, resource, b, false); err != nil {
+		return incoming, err
+	}
+
+	var existing T
+	if err := d.unmarshal(b, &existing); err != nil {
+		return incoming, err
+	}
+
+	return onConflict(existing, incoming), nil
+}
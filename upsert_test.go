@@ -0,0 +1,69 @@
+package jsondb
+
+import (
+	"testing"
+)
+
+type Tank struct {
+	Fish  []string `json:"fish"`
+	Count int      `json:"count"`
+}
+
+func TestWriteBatchUpsert(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "reef", Tank{Fish: []string{"red"}, Count: 1}); err != nil {
+		t.Fatal("Create tank failed: ", err.Error())
+	}
+
+	merge := func(existing, incoming Tank) Tank {
+		return Tank{Fish: append(existing.Fish, incoming.Fish...), Count: existing.Count + incoming.Count}
+	}
+
+	items := map[string]Tank{
+		"reef":   {Fish: []string{"blue"}, Count: 1},
+		"lagoon": {Fish: []string{"green"}, Count: 1},
+	}
+	if err := WriteBatchUpsert(d, collection, items, merge); err != nil {
+		t.Fatal("Failed to upsert tanks: ", err.Error())
+	}
+
+	tank := Tank{}
+	if err := d.Read(collection, "reef", &tank); err != nil || tank.Count != 2 || len(tank.Fish) != 2 {
+		t.Error("Expected the reef to be merged, got: ", tank, err)
+	}
+
+	tank = Tank{}
+	if err := d.Read(collection, "lagoon", &tank); err != nil || tank.Count != 1 || tank.Fish[0] != "green" {
+		t.Error("Expected the lagoon to be inserted, got: ", tank, err)
+	}
+
+	// without a conflict strategy the incoming value wins
+	if err := WriteBatchUpsert(d, collection, map[string]Tank{"reef": {Count: 7}}, nil); err != nil {
+		t.Fatal("Failed to upsert tanks: ", err.Error())
+	}
+
+	tank = Tank{}
+	if err := d.Read(collection, "reef", &tank); err != nil || tank.Count != 7 || len(tank.Fish) != 0 {
+		t.Error("Expected the reef to be overwritten, got: ", tank, err)
+	}
+}
+
+func TestWriteBatchUpsertUndecodable(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "reef", "not a tank"); err != nil {
+		t.Fatal("Create tank failed: ", err.Error())
+	}
+
+	keep := func(existing, incoming Tank) Tank { return existing }
+	items := map[string]Tank{"lagoon": {Count: 1}, "reef": {Count: 1}}
+	if err := WriteBatchUpsert(d, collection, items, keep); err == nil {
+		t.Fatal("Expected a record that can't be decoded to fail the upsert")
+	}
+
+	// nothing was written
+	if err := d.Read(collection, "lagoon", &Tank{}); err == nil {
+		t.Error("Expected the lagoon not to be written")
+	}
+}
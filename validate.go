@@ -0,0 +1,49 @@
+package jsondb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrValidation is returned when a collection's validator rejects a record
+var ErrValidation = errors.New("validation failed")
+
+// validators holds the validators registered per collection
+type validators struct {
+	mutex  sync.Mutex
+	byName map[string]func(raw []byte) error
+}
+
+// validate runs the validator of [collection], if any, on the record [b]
+func (v *validators) validate(collection string, b []byte) error {
+	v.mutex.Lock()
+	fn := v.byName[collection]
+	v.mutex.Unlock()
+
+	if fn == nil {
+		return nil
+	}
+
+	if err := fn(b); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return nil
+}
+
+// SetValidator makes every write to [collection] pass the marshaled record
+// through [fn] first, rejecting the write with ErrValidation if it returns an
+// error. A nil [fn] removes the collection's validator.
+func (d *Driver) SetValidator(collection string, fn func(raw []byte) error) {
+	collection = d.normalize(collection)
+
+	d.validators.mutex.Lock()
+	defer d.validators.mutex.Unlock()
+
+	if fn == nil {
+		delete(d.validators.byName, collection)
+		return
+	}
+	d.validators.byName[collection] = fn
+}
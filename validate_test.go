@@ -0,0 +1,42 @@
+package jsondb
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetValidator(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	d.SetValidator(collection, func(raw []byte) error {
+		if !strings.Contains(string(raw), `"type":"red"`) {
+			return errors.New("only red fish")
+		}
+		return nil
+	})
+
+	if err := d.Write(collection, "red", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	err := d.Write(collection, "blue", Fish{Type: "blue"})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "only red fish") {
+		t.Error("Expected ErrValidation, got: ", err)
+	}
+	if _, err := os.Stat(d.recordPath(collection, "blue")); !os.IsNotExist(err) {
+		t.Error("Expected the rejected record not to be stored")
+	}
+
+	// other collections are unaffected
+	if err := d.Write("other", "blue", Fish{Type: "blue"}); err != nil {
+		t.Error("Create fish failed: ", err.Error())
+	}
+
+	// removing the validator allows anything again
+	d.SetValidator(collection, nil)
+	if err := d.Write(collection, "blue", Fish{Type: "blue"}); err != nil {
+		t.Error("Create fish failed: ", err.Error())
+	}
+}
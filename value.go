@@ -0,0 +1,63 @@
+package jsondb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// keyTag marks the struct field WriteValue takes a record's key from
+const keyTag = `jsondb:"key"`
+
+// WriteValue writes [v] to [collection] under the resource name [keyFn]
+// derives from it, so a value carrying its own ID can't be stored under
+// another. A nil [keyFn] takes the name from the string or integer field of
+// [v] tagged `jsondb:"key"`.
+func WriteValue[T any](d *Driver, collection string, v T, keyFn func(T) string) error {
+	var resource string
+	if keyFn != nil {
+		resource = keyFn(v)
+	} else {
+		key, err := taggedKey(reflect.ValueOf(v))
+		if err != nil {
+			return &OpError{Op: "write", Collection: collection, Err: err}
+		}
+		resource = key
+	}
+
+	return d.Write(collection, resource, v)
+}
+
+// taggedKey returns the value of the field of the struct [v] tagged as its key
+func taggedKey(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", ErrMissingResource
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("cannot take a key from %v", v.Type())
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("jsondb") != "key" {
+			continue
+		}
+
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			return f.String(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(f.Int(), 10), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(f.Uint(), 10), nil
+		}
+		return "", fmt.Errorf("key field %s of %v is a %v", t.Field(i).Name, t, f.Type())
+	}
+
+	return "", fmt.Errorf("%v has no field tagged %s", t, keyTag)
+}
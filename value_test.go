@@ -0,0 +1,39 @@
+package jsondb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type keyedFish struct {
+	ID   int    `json:"id" jsondb:"key"`
+	Type string `json:"type"`
+}
+
+func TestWriteValue(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := WriteValue(d, collection, redfish, func(f Fish) string { return f.Type }); err != nil {
+		t.Fatal("WriteValue failed: ", err.Error())
+	}
+	if err := d.Read(collection, "red", &Fish{}); err != nil {
+		t.Error("Expected the fish stored under its type, got: ", err)
+	}
+
+	// the tagged field names the record when there's no key function
+	if err := WriteValue(d, collection, &keyedFish{ID: 7, Type: "blue"}, nil); err != nil {
+		t.Fatal("WriteValue failed: ", err.Error())
+	}
+	got := keyedFish{}
+	if err := d.Read(collection, "7", &got); err != nil || got.Type != "blue" {
+		t.Error("Expected the fish stored under its ID, got: ", got, err)
+	}
+
+	if err := WriteValue(d, collection, redfish, nil); err == nil || !strings.Contains(err.Error(), keyTag) {
+		t.Error("Expected an error without a key field, got: ", err)
+	}
+	if err := WriteValue(d, collection, Fish{}, func(f Fish) string { return f.Type }); !errors.Is(err, ErrMissingResource) {
+		t.Error("Expected ErrMissingResource, got: ", err)
+	}
+}
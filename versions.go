@@ -0,0 +1,199 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// versionField is the top-level member holding the schema version of a record
+const versionField = "_v"
+
+type migrateFunc func([]byte) ([]byte, error)
+
+// migrations holds the migration chains registered per collection
+type migrations struct {
+	mutex  sync.Mutex
+	byName map[string]map[int]migrateFunc
+}
+
+// latest returns the version records of [collection] are written with, one
+// past the highest version a migration was registered from; 0 means the
+// collection isn't versioned
+func (m *migrations) latest(collection string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	latest := 0
+	for from := range m.byName[collection] {
+		if from+1 > latest {
+			latest = from + 1
+		}
+	}
+
+	return latest
+}
+
+// chain returns the migrations of [collection] and its latest version
+func (m *migrations) chain(collection string) (map[int]migrateFunc, int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	latest := 0
+	chain := make(map[int]migrateFunc, len(m.byName[collection]))
+	for from, fn := range m.byName[collection] {
+		chain[from] = fn
+		if from+1 > latest {
+			latest = from + 1
+		}
+	}
+
+	return chain, latest
+}
+
+// RegisterMigration registers [fn] to upgrade the raw records of
+// [collection] from [fromVersion] to the next version. Once a collection has
+// migrations, every record written to it is stamped with the latest version
+// in its top-level "_v" member, and Read runs records stored with an older
+// version, or none at all (version 0), through the chain in memory before
+// unmarshaling them. Registering the same version again replaces its
+// migration.
+func (d *Driver) RegisterMigration(collection string, fromVersion int, fn func([]byte) ([]byte, error)) {
+	collection = d.normalize(collection)
+
+	d.migrations.mutex.Lock()
+	defer d.migrations.mutex.Unlock()
+
+	if d.migrations.byName[collection] == nil {
+		d.migrations.byName[collection] = make(map[int]migrateFunc)
+	}
+	d.migrations.byName[collection][fromVersion] = fn
+}
+
+// migrateRecord brings the record [b] up to the latest version of
+// [collection], writing it back when [rewrite] is set
+func (d *Driver) migrateRecord(collection, resource string, b []byte, rewrite bool) ([]byte, error) {
+	collection, resource = d.normalize(collection), d.normalize(resource)
+
+	chain, latest := d.migrations.chain(collection)
+	if latest == 0 {
+		return b, nil
+	}
+
+	version, err := recordVersion(b)
+	if err != nil || version >= latest {
+		return b, err
+	}
+
+	if !rewrite {
+		return migrateChain(chain, b, version, latest)
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// the record may have been rewritten while the lock was taken
+	if b, err = d.readFile(collection, resource); err != nil {
+		return nil, err
+	}
+
+	if version, err = recordVersion(b); err != nil || version >= latest {
+		return b, err
+	}
+
+	out, err := migrateChain(chain, b, version, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.writeRaw(collection, resource, out)
+	d.cache.invalidate(collection, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// migrateChain runs [b] through the migrations from [version] up to [latest],
+// stamping the version reached after each step
+func migrateChain(chain map[int]migrateFunc, b []byte, version, latest int) ([]byte, error) {
+	for ; version < latest; version++ {
+		fn, ok := chain[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from version %d", version)
+		}
+
+		out, err := fn(b)
+		if err != nil {
+			return nil, fmt.Errorf("migrate from version %d: %w", version, err)
+		}
+
+		if b, err = stampVersion(out, version+1); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// recordVersion returns the version stamped in the record [b]; records
+// without one, including anything but an object, are at version 0
+func recordVersion(b []byte) (int, error) {
+	return intMember(b, versionField, "record version")
+}
+
+// stampVersion sets the version member of the JSON object [b], keeping the
+// other members in order; anything but an object is returned as is
+func stampVersion(b []byte, version int) ([]byte, error) {
+	return stampInt(b, versionField, version)
+}
+
+// intMember returns the integer held by the top-level member [name] of the
+// record [b], described as [what] when it isn't one; 0 when it's missing
+func intMember(b []byte, name, what string) (int, error) {
+	obj, ok, err := parseObject(b)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	for _, m := range obj {
+		if m.name != name {
+			continue
+		}
+
+		raw, _ := m.value.(json.RawMessage)
+
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return 0, fmt.Errorf("invalid %s %s", what, raw)
+		}
+		return n, nil
+	}
+
+	return 0, nil
+}
+
+// stampInt sets the top-level member [name] of the JSON object [b] to [n],
+// keeping the other members in order; anything but an object is returned
+// as is
+func stampInt(b []byte, name string, n int) ([]byte, error) {
+	obj, ok, err := parseObject(b)
+	if err != nil || !ok {
+		return b, err
+	}
+
+	value := json.RawMessage(strconv.Itoa(n))
+	for i := range obj {
+		if obj[i].name == name {
+			obj[i].value = value
+			return obj.MarshalJSON()
+		}
+	}
+
+	return append(obj, taggedMember{name: name, value: value}).MarshalJSON()
+}
@@ -0,0 +1,121 @@
+package jsondb
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRegisterMigration(t *testing.T) {
+	for _, rewrite := range []bool{false, true} {
+		d := newTestDB(t, &Options{RewriteMigrated: rewrite})
+
+		// a record stored before the collection was versioned
+		if err := d.Write(collection, "old", map[string]string{"kind": "red"}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		d.RegisterMigration(collection, 0, func(b []byte) ([]byte, error) {
+			return bytes.Replace(b, []byte(`"kind"`), []byte(`"type"`), 1), nil
+		})
+		d.RegisterMigration(collection, 1, func(b []byte) ([]byte, error) {
+			return bytes.Replace(b, []byte(`"red"`), []byte(`"crimson"`), 1), nil
+		})
+
+		fish := Fish{}
+		if err := d.Read(collection, "old", &fish); err != nil {
+			t.Fatal("Failed to read: ", err.Error())
+		}
+		if fish.Type != "crimson" {
+			t.Error("Expected migrated fish, got: ", fish.Type)
+		}
+
+		b, err := os.ReadFile(d.recordPath(collection, "old"))
+		if err != nil {
+			t.Fatal("Failed to read file: ", err.Error())
+		}
+		if migrated := bytes.Contains(b, []byte(`"_v":2`)); migrated != rewrite {
+			t.Errorf("Expected record rewritten %v, got: %s", rewrite, b)
+		}
+
+		// new records are stamped with the latest version and not migrated
+		if err := d.Write(collection, "new", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		b, err = os.ReadFile(d.recordPath(collection, "new"))
+		if err != nil {
+			t.Fatal("Failed to read file: ", err.Error())
+		}
+		if string(b) != `{"type":"red","_v":2}` {
+			t.Error("Expected stamped record, got: ", string(b))
+		}
+
+		if err := d.Read(collection, "new", &fish); err != nil {
+			t.Fatal("Failed to read: ", err.Error())
+		}
+		if fish.Type != "red" {
+			t.Error("Expected unmigrated fish, got: ", fish.Type)
+		}
+	}
+}
+
+func TestRegisterMigrationGap(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "old", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// there is no way from version 0 to version 1
+	d.RegisterMigration(collection, 1, func(b []byte) ([]byte, error) { return b, nil })
+
+	if err := d.Read(collection, "old", &Fish{}); err == nil {
+		t.Error("Expected an error reading past a missing migration")
+	}
+}
+
+func TestTypedReadersMigrate(t *testing.T) {
+	for _, rewrite := range []bool{false, true} {
+		d := newTestDB(t, &Options{RewriteMigrated: rewrite})
+
+		if err := d.Write(collection, "old", map[string]string{"kind": "red"}); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+
+		d.RegisterMigration(collection, 0, func(b []byte) ([]byte, error) {
+			return bytes.Replace(b, []byte(`"kind"`), []byte(`"type"`), 1), nil
+		})
+
+		all, err := NewCollection[Fish](d, collection).All()
+		if err != nil || len(all) != 1 || all[0] != redfish {
+			t.Error("Expected a migrated fish from All, got: ", all, err)
+		}
+
+		found, ok, err := FindFirst(d, collection, func(f Fish) bool { return f.Type == "red" })
+		if err != nil || !ok || found != redfish {
+			t.Error("Expected a migrated fish from FindFirst, got: ", found, ok, err)
+		}
+
+		results, err := ReadAllResults[Fish](d, collection)
+		if err != nil || len(results) != 1 || results[0].Err != nil || results[0].Value != redfish {
+			t.Error("Expected a migrated fish from ReadAllResults, got: ", results, err)
+		}
+
+		fish := Fish{}
+		if missing, err := d.ReadInto(collection, map[string]interface{}{"old": &fish}); err != nil || len(missing) != 0 || fish != redfish {
+			t.Error("Expected a migrated fish from ReadInto, got: ", fish, missing, err)
+		}
+
+		fish = Fish{}
+		if modified, err := d.ReadIfModifiedSince(collection, "old", time.Time{}, &fish); err != nil || !modified || fish != redfish {
+			t.Error("Expected a migrated fish from ReadIfModifiedSince, got: ", fish, modified, err)
+		}
+
+		m, err := ReadMap[string, Fish](d, collection)
+		if err != nil || m["old"] != redfish {
+			t.Error("Expected a migrated fish from ReadMap, got: ", m, err)
+		}
+	}
+}
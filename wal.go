@@ -0,0 +1,315 @@
+package jsondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// walName is the write-ahead log a collection keeps while a batch is applied
+const walName = ".wal"
+
+// walOp is a single write or delete of a logged batch; writes carry the
+// bytes as they are stored, after marshaling and conflict resolution
+type walOp struct {
+	Resource string `json:"resource"`
+	Data     []byte `json:"data,omitempty"`
+	Delete   bool   `json:"delete,omitempty"`
+}
+
+// Recover replays the write-ahead log of every collection whose last batch
+// was interrupted, completing the batch, and then removes the log. New calls
+// it when Options.WAL is set.
+func (d *Driver) Recover() error {
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range collections {
+		if err := d.recover(collection); err != nil {
+			return &OpError{Op: "recover", Collection: collection, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (d *Driver) recover(collection string) error {
+	mutex := d.getOrCreateMutex(collection)
+	mutex.Lock()
+	defer mutex.Unlock()
+	defer d.cache.invalidate(collection, "")
+
+	b, err := os.ReadFile(d.walPath(collection))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var ops []walOp
+	if err := json.Unmarshal(b, &ops); err != nil {
+		return err
+	}
+
+	d.log("Replaying %d logged operations in '%s'\n", len(ops), collection)
+
+	for _, op := range ops {
+		if err := d.applyOp(collection, op); err != nil {
+			return err
+		}
+	}
+
+	return d.endWAL(collection, ops)
+}
+
+func (d *Driver) walPath(collection string) string {
+	return filepath.Join(d.collectionDir(collection), walName)
+}
+
+// beginWAL durably logs [ops] before they are applied, when logging is on
+func (d *Driver) beginWAL(collection string, ops []walOp) error {
+	if !d.wal {
+		return nil
+	}
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+
+	if err := d.ensureDir(collection); err != nil {
+		return err
+	}
+
+	path := d.walPath(collection)
+	if err := writeFileSync(path+tmpSuffix, b); err != nil {
+		return err
+	}
+
+	if err := os.Rename(path+tmpSuffix, path); err != nil {
+		return err
+	}
+
+	// make the rename itself durable
+	return syncFile(filepath.Dir(path))
+}
+
+// endWAL flushes the records [ops] wrote to disk and then drops the log
+func (d *Driver) endWAL(collection string, ops []walOp) error {
+	if !d.wal {
+		return nil
+	}
+
+	for _, op := range ops {
+		if op.Delete {
+			continue
+		}
+
+		if err := syncFile(d.recordPath(collection, op.Resource)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	// the renames of the records must be durable before the log goes away
+	dir := d.collectionDir(collection)
+	if err := syncFile(dir); err != nil {
+		return err
+	}
+
+	if err := os.Remove(d.walPath(collection)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return syncFile(dir)
+}
+
+// commitOps applies [ops] to [collection] between beginWAL and endWAL,
+// reporting a failed operation as [op]; the caller holds the collection lock.
+// Every record written is checked and staged before the first is moved into
+// place, so one that can't be stored leaves the collection as it was.
+func (d *Driver) commitOps(op, collection string, ops []walOp) error {
+	staged, release, err := d.stageOps(op, collection, ops)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := d.beginWAL(collection, ops); err != nil {
+		discardStaged(staged)
+		return err
+	}
+
+	if staged == nil {
+		// a single-file collection takes every change in one rewrite
+		if err := d.applySingle(collection, ops); err != nil {
+			return &OpError{Op: op, Collection: collection, Err: err}
+		}
+		return d.endWAL(collection, ops)
+	}
+
+	for i, o := range ops {
+		switch w := staged[i]; {
+		case w != nil:
+			err = d.commitRecord(collection, w)
+		case o.Delete:
+			err = d.applyOp(collection, o)
+		default:
+			// written again later in the batch under another case
+			continue
+		}
+		if err != nil {
+			discardStaged(staged[i+1:])
+			return &OpError{Op: op, Collection: collection, Resource: o.Resource, Err: err}
+		}
+	}
+
+	return d.endWAL(collection, ops)
+}
+
+// stageOps checks the records [ops] write and stages those of a collection
+// kept as files, returning them by the index of their op; nil when
+// [collection] is kept in a single file, which has nothing to stage. A
+// record written again later in [ops] isn't staged. On failure every temp
+// file staged is removed. [release] frees the unique values checked.
+func (d *Driver) stageOps(op, collection string, ops []walOp) (staged []*stagedWrite, release func(), err error) {
+	for _, o := range ops {
+		if err := d.checkNames(collection, o.Resource); err != nil {
+			return nil, nil, &OpError{Op: op, Collection: collection, Resource: o.Resource, Err: err}
+		}
+		if o.Delete {
+			continue
+		}
+		if err := d.checkKey(collection, o.Resource); err != nil {
+			return nil, nil, &OpError{Op: op, Collection: collection, Resource: o.Resource, Err: err}
+		}
+	}
+
+	release, err = d.checkUniqueBatch(collection, ops)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	single, err := d.singleFile(collection)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	if single {
+		return nil, release, nil
+	}
+
+	last := make(map[string]int, len(ops))
+	for i, o := range ops {
+		last[d.normalize(o.Resource)] = i
+	}
+
+	staged = make([]*stagedWrite, len(ops))
+	for i, o := range ops {
+		if o.Delete || last[d.normalize(o.Resource)] != i {
+			continue
+		}
+
+		w, err := d.stageRecord(collection, o.Resource, o.Data)
+		if err != nil {
+			discardStaged(staged)
+			release()
+			return nil, nil, &OpError{Op: op, Collection: collection, Resource: o.Resource, Err: err}
+		}
+		staged[i] = w
+	}
+
+	return staged, release, nil
+}
+
+// discardStaged removes the temp files of the records [staged]
+func discardStaged(staged []*stagedWrite) {
+	for _, w := range staged {
+		if w != nil {
+			os.Remove(w.tmpPath)
+		}
+	}
+}
+
+// applySingle applies [ops] to the single-file [collection] in one rewrite
+// of its file
+func (d *Driver) applySingle(collection string, ops []walOp) error {
+	changes := make(map[string][]byte, len(ops))
+	for _, o := range ops {
+		changes[o.Resource] = o.Data
+		if o.Delete {
+			changes[o.Resource] = nil
+		}
+	}
+
+	if err := d.updateSingleRecords(collection, changes); err != nil {
+		return err
+	}
+
+	for _, o := range ops {
+		var err error
+		if o.Delete {
+			err = d.recordChanged(collection, o.Resource)
+		} else {
+			err = d.written(collection, o.Resource, o.Data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyOp performs a logged operation; replaying one that already happened
+// changes nothing
+func (d *Driver) applyOp(collection string, op walOp) error {
+	if !op.Delete {
+		return d.writeRaw(collection, op.Resource, op.Data)
+	}
+
+	if _, err := d.statRecord(collection, op.Resource); errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return d.remove(collection, op.Resource)
+}
+
+// writeFileSync writes [b] to [path] and flushes it to disk
+func writeFileSync(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// syncFile flushes the file or directory at [path] to disk
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
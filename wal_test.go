@@ -0,0 +1,76 @@
+package jsondb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestWALBatch(t *testing.T) {
+	d := newTestDB(t, &Options{WAL: true})
+
+	committed, err := d.WriteBatchContext(context.Background(), collection, map[string]interface{}{
+		"red":  redfish,
+		"blue": Fish{Type: "blue"},
+	})
+	if err != nil || fmt.Sprint(committed) != "[blue red]" {
+		t.Error("Expected blue and red committed, got: ", committed, err)
+	}
+
+	if err := d.WriteAll(collection, map[string]interface{}{"red": redfish}); err != nil {
+		t.Error("WriteAll failed: ", err.Error())
+	}
+
+	// the log is gone once a batch is applied, and is never a record
+	if _, err := os.Stat(d.walPath(collection)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected no write-ahead log left, got: ", err)
+	}
+
+	names, err := d.list(collection)
+	if err != nil || fmt.Sprint(names) != "[red]" {
+		t.Error("Expected only red left, got: ", names, err)
+	}
+}
+
+func TestWALRecover(t *testing.T) {
+	d := newTestDB(t, &Options{WAL: true})
+
+	if err := d.Write(collection, "stale", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	// a batch that crashed after being logged but before being applied
+	ops := []walOp{
+		{Resource: "red", Data: []byte(`{"type":"red"}`)},
+		{Resource: "stale", Delete: true},
+		{Resource: "gone", Delete: true},
+	}
+	if err := d.beginWAL(collection, ops); err != nil {
+		t.Fatal("beginWAL failed: ", err.Error())
+	}
+
+	d, err := New(d.dir, &Options{WAL: true, Debug: t.Logf})
+	if err != nil {
+		t.Fatal("New failed: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected red to be recovered, got: ", fish, err)
+	}
+
+	if err := d.Read(collection, "stale", &fish); !errors.Is(err, ErrNotFound) && !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected stale to be deleted, got: ", err)
+	}
+
+	if _, err := os.Stat(d.walPath(collection)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected the write-ahead log to be removed, got: ", err)
+	}
+
+	// recovering again is harmless
+	if err := d.Recover(); err != nil {
+		t.Error("Recover failed: ", err.Error())
+	}
+}
@@ -0,0 +1,27 @@
+package jsondb
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Warm creates the locks of [collections] ahead of time and remembers the
+// directories of those that already exist, so the first operation on each
+// one skips that setup, and a burst of them at startup doesn't line up on
+// the driver's mutex to do it. Nothing is created on disk.
+func (d *Driver) Warm(collections []string) {
+	for _, collection := range collections {
+		collection = d.normalize(collection)
+		if collection == "" || d.checkNames(collection, "") != nil {
+			continue
+		}
+
+		d.getOrCreateMutex(collection)
+
+		if info, err := os.Stat(d.collectionDir(collection)); err == nil && info.IsDir() {
+			d.mutex.Lock()
+			d.dirs[filepath.ToSlash(filepath.Clean(collection))] = true
+			d.mutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWarm(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := os.MkdirAll(d.collectionDir(collection), dirMode); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Warm([]string{collection, "birds", ""})
+
+	if d.mutexes[collection] == nil || d.mutexes["birds"] == nil {
+		t.Error("Expected the locks to be created, got: ", d.mutexes)
+	}
+
+	if !d.dirs[collection] {
+		t.Error("Expected the existing directory to be remembered")
+	}
+
+	// nothing is created for a collection that doesn't exist yet
+	if d.dirs["birds"] {
+		t.Error("Expected the missing directory to stay unknown")
+	}
+	if _, err := os.Stat(d.collectionDir("birds")); !os.IsNotExist(err) {
+		t.Error("Expected no directory to be created")
+	}
+
+	if err := d.Write("birds", "robin", redfish); err != nil {
+		t.Error("Create bird failed: ", err.Error())
+	}
+}
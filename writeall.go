@@ -0,0 +1,117 @@
+package jsondb
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// WriteAll locks the collection and makes [records] its entire contents:
+// every record is written and any existing record whose resource isn't a key
+// of [records] is deleted. All records are marshaled before anything is
+// touched, so a record that can't be encoded leaves the collection as is; with
+// Options.WAL the whole change survives a crash as well. To write records
+// without deleting the others, use WriteBatch.
+func (d *Driver) WriteAll(collection string, records map[string]interface{}) (err error) {
+	defer wrapOpError(&err, "writeall", collection, "")
+
+	// ensure there is a place to save records
+	if collection == "" {
+		return ErrMissingCollection
+	}
+
+	names, encoded, err := d.marshalAll(collection, records)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := d.lockResource(collection, "")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, "")
+
+	existing, err := d.list(collection)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// the names listed are the ones stored, which may differ in case
+	keep := make(map[string]bool, len(names))
+	ops := make([]walOp, 0, len(names)+len(existing))
+	for _, name := range names {
+		b, err := d.resolve(collection, name, encoded[name])
+		if err != nil {
+			return &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+		ops = append(ops, walOp{Resource: name, Data: b})
+		keep[d.normalize(name)] = true
+	}
+
+	for _, name := range existing {
+		if !keep[name] {
+			ops = append(ops, walOp{Resource: name, Delete: true})
+		}
+	}
+
+	return d.commitOps("writeall", collection, ops)
+}
+
+// marshalAll marshals each of [records], returning their sorted resource
+// names along with the marshaled records by name
+func (d *Driver) marshalAll(collection string, records map[string]interface{}) ([]string, map[string][]byte, error) {
+	names := make([]string, 0, len(records))
+	for name := range records {
+		// ensure there is a resource (name) to save each record as
+		if name == "" {
+			return nil, nil, ErrMissingResource
+		}
+		if err := d.checkNames(collection, name); err != nil {
+			return nil, nil, &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	encoded := make(map[string][]byte, len(records))
+	for _, name := range names {
+		b, err := d.marshal(collection, name, records[name])
+		if err != nil {
+			return nil, nil, &OpError{Op: "write", Collection: collection, Resource: name, Err: err}
+		}
+		encoded[name] = b
+	}
+
+	return names, encoded, nil
+}
+
+// remove deletes the record [resource], leaving a tombstone when those are
+// kept; the caller holds the collection lock
+func (d *Driver) remove(collection, resource string) error {
+	if err := d.checkNames(collection, resource); err != nil {
+		return err
+	}
+
+	single, err := d.singleFile(collection)
+	if err != nil {
+		return err
+	}
+	if single {
+		if err := d.updateSingle(collection, resource, nil); err != nil {
+			return err
+		}
+		return d.recordChanged(collection, resource)
+	}
+
+	if d.tombstones {
+		return d.bury(collection, resource)
+	}
+
+	if err := os.Remove(d.recordPath(collection, resource)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return d.recordChanged(collection, resource)
+}
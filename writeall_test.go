@@ -0,0 +1,77 @@
+package jsondb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteAll(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	for _, name := range []string{"red", "blue", "stale"} {
+		if err := d.Write(collection, name, redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	err := d.WriteAll(collection, map[string]interface{}{
+		"red":   Fish{Type: "crimson"},
+		"blue":  Fish{Type: "blue"},
+		"green": Fish{Type: "green"},
+	})
+	if err != nil {
+		t.Fatal("WriteAll failed: ", err.Error())
+	}
+
+	names, err := d.list(collection)
+	if err != nil {
+		t.Fatal("List fish failed: ", err.Error())
+	}
+	if want := []string{"blue", "green", "red"}; !reflect.DeepEqual(names, want) {
+		t.Error("Expected records ", want, ", got: ", names)
+	}
+
+	var fish Fish
+	if err := d.Read(collection, "red", &fish); err != nil || fish.Type != "crimson" {
+		t.Error("Expected red to be overwritten, got: ", fish, err)
+	}
+
+	// a record that can't be marshaled leaves the collection untouched
+	err = d.WriteAll(collection, map[string]interface{}{"red": make(chan int)})
+	if err == nil {
+		t.Error("Expected an error marshaling a channel")
+	}
+	if names, _ := d.list(collection); len(names) != 3 {
+		t.Error("Expected 3 records to survive a failed WriteAll, got: ", names)
+	}
+
+	// an empty map empties the collection
+	if err := d.WriteAll(collection, nil); err != nil {
+		t.Fatal("WriteAll failed: ", err.Error())
+	}
+	if names, _ := d.list(collection); len(names) != 0 {
+		t.Error("Expected no records left, got: ", names)
+	}
+
+	if err := d.WriteAll("", nil); !errors.Is(err, ErrMissingCollection) {
+		t.Error("Expected ErrMissingCollection, got: ", err)
+	}
+}
+
+func TestWriteAllTombstones(t *testing.T) {
+	d := newTestDB(t, &Options{Tombstones: true})
+
+	if err := d.Write(collection, "stale", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	if err := d.WriteAll(collection, map[string]interface{}{"red": redfish}); err != nil {
+		t.Fatal("WriteAll failed: ", err.Error())
+	}
+
+	tombs, err := d.ListTombstones(collection)
+	if err != nil || len(tombs) != 1 || tombs[0].Resource != "stale" {
+		t.Error("Expected a tombstone for stale, got: ", tombs, err)
+	}
+}
@@ -0,0 +1,124 @@
+package jsondb
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strconv"
+)
+
+// WriteMap locks the collection and makes it mirror [m]: each value is
+// written as the record named after its key, formatted with fmt.Sprint (so
+// a key with a String method is named by it), and every other record is
+// deleted, the way WriteAll does. Two keys formatting to the same name fail
+// the call before anything is written.
+func WriteMap[K comparable, V any](d *Driver, collection string, m map[K]V) error {
+	collection = d.normalize(collection)
+
+	records := make(map[string]interface{}, len(m))
+	keys := make(map[string]K, len(m))
+	for k, v := range m {
+		name := d.normalize(fmt.Sprint(k))
+		if other, ok := keys[name]; ok {
+			return &OpError{Op: "writemap", Collection: collection, Resource: name,
+				Err: fmt.Errorf("keys %v and %v are both named %s", other, k, name)}
+		}
+		keys[name] = k
+		records[name] = v
+	}
+
+	return d.WriteAll(collection, records)
+}
+
+// ReadMap reads every record of [collection] into a map keyed by the
+// record's resource name parsed as a K, reversing WriteMap. Strings, bools
+// and numbers are parsed with strconv, other keys need an UnmarshalText
+// method. A collection that doesn't exist yet reads as an empty map.
+func ReadMap[K comparable, V any](d *Driver, collection string) (m map[K]V, err error) {
+	collection = d.normalize(collection)
+
+	defer wrapOpError(&err, "readmap", collection, "")
+
+	// ensure there is a collection to read
+	if collection == "" {
+		return nil, ErrMissingCollection
+	}
+
+	names, err := d.list(collection)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[K]V{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m = make(map[K]V, len(names))
+	for _, name := range names {
+		b, err := d.read(collection, name)
+		if errors.Is(err, fs.ErrNotExist) || (err == nil && len(b) == 0) {
+			// deleted since it was listed, or only reserved
+			continue
+		}
+
+		var k K
+		if err == nil {
+			err = parseKey(name, &k)
+		}
+
+		var v V
+		if err == nil {
+			err = d.decodeRecord(collection, name, b, &v)
+		}
+
+		if err != nil {
+			return nil, &OpError{Op: "read", Collection: collection, Resource: name, Err: err}
+		}
+
+		m[k] = v
+	}
+
+	return m, nil
+}
+
+// parseKey parses the resource name [name] into the map key [k]
+func parseKey(name string, k interface{}) error {
+	if u, ok := k.(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(name))
+	}
+
+	v := reflect.ValueOf(k).Elem()
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(name)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(name)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(name, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(name, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(name, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot parse a resource name into a %v key", v.Type())
+	}
+
+	return nil
+}
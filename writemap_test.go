@@ -0,0 +1,51 @@
+package jsondb
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWriteMap(t *testing.T) {
+	d := newTestDB(t, nil)
+
+	if err := d.Write(collection, "7", redfish); err != nil {
+		t.Fatal("Create fish failed: ", err.Error())
+	}
+
+	m := map[int]Fish{1: {Type: "red"}, 2: {Type: "blue"}}
+	if err := WriteMap(d, collection, m); err != nil {
+		t.Fatal("Failed to write map: ", err.Error())
+	}
+
+	// records missing from the map are removed
+	if _, err := os.Stat(d.recordPath(collection, "7")); !os.IsNotExist(err) {
+		t.Error("Expected record 7 to be removed")
+	}
+
+	read, err := ReadMap[int, Fish](d, collection)
+	if err != nil {
+		t.Fatal("Failed to read map: ", err.Error())
+	}
+
+	if len(read) != 2 || read[1] != m[1] || read[2] != m[2] {
+		t.Error("Expected the map back, got: ", read)
+	}
+
+	if _, err := ReadMap[bool, Fish](d, collection); err == nil {
+		t.Error("Expected an error parsing a key")
+	}
+
+	empty, err := ReadMap[string, Fish](d, "missing")
+	if err != nil || len(empty) != 0 {
+		t.Error("Expected an empty map, got: ", empty, err)
+	}
+}
+
+func TestWriteMapKeyCollision(t *testing.T) {
+	d := newTestDB(t, &Options{CaseInsensitiveKeys: true})
+
+	m := map[string]Fish{"Red": redfish, "red": redfish}
+	if err := WriteMap(d, collection, m); err == nil {
+		t.Error("Expected an error for keys naming the same record")
+	}
+}
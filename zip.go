@@ -0,0 +1,143 @@
+package jsondb
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ExportZip locks every collection and writes all records into a zip archive
+// whose paths mirror the database directory. Entries are written in sorted
+// order without timestamps, so exporting the same data is reproducible.
+func (d *Driver) ExportZip(w io.Writer) error {
+	collections, err := d.allCollections()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := d.lockCollections(collections...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	zw := zip.NewWriter(w)
+
+	for _, collection := range collections {
+		names, err := d.listDisk(collection)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			b, err := d.readFile(collection, name)
+			if err != nil {
+				return &OpError{Op: "export", Collection: collection, Resource: name, Err: err}
+			}
+
+			if err := d.writeZipEntry(zw, collection, name, b); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+// writeZipEntry adds the record [name] of [collection] to an export archive
+func (d *Driver) writeZipEntry(zw *zip.Writer, collection, name string, b []byte) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:   path.Join(collection, d.encodedName(name)+d.ext),
+		Method: zip.Deflate,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	return err
+}
+
+// ImportZip restores the records of an archive written by ExportZip, writing
+// each of them atomically and overwriting records that already exist
+func (d *Driver) ImportZip(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+
+		collection, resource, err := d.splitArchivePath(f.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := d.importFile(collection, resource, f); err != nil {
+			return &OpError{Op: "import", Collection: collection, Resource: resource, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (d *Driver) importFile(collection, resource string, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := d.lockResource(collection, resource)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer d.cache.invalidate(collection, resource)
+
+	return d.writeRaw(collection, resource, b)
+}
+
+// splitArchivePath splits an archive entry into its collection and resource,
+// refusing entries that would land outside the database directory and those
+// that aren't records, such as a collection's settings
+func (d *Driver) splitArchivePath(name string) (collection, resource string, err error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", "", fmt.Errorf("archive entry %q is outside the database", name)
+	}
+
+	dir, file := path.Split(clean)
+	dir = strings.TrimSuffix(dir, "/")
+
+	collection, ok := d.collectionName(dir)
+	if dir == "" || !ok || !d.isRecordName(file) {
+		return "", "", fmt.Errorf("archive entry %q is not a record", name)
+	}
+
+	if resource, ok = d.resourceName("", file); !ok {
+		return "", "", fmt.Errorf("archive entry %q is not a record", name)
+	}
+
+	if err := d.checkNames(collection, resource); err != nil {
+		return "", "", fmt.Errorf("archive entry %q: %w", name, err)
+	}
+
+	return collection, resource, nil
+}
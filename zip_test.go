@@ -0,0 +1,85 @@
+package jsondb
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportImportZip(t *testing.T) {
+	src := newTestDB(t, nil)
+
+	for _, c := range []string{"fish", "deep/sea"} {
+		if err := src.Write(c, "red", redfish); err != nil {
+			t.Fatal("Create fish failed: ", err.Error())
+		}
+	}
+
+	// temp files aren't exported
+	if err := os.WriteFile(filepath.Join(src.dir, "fish", "blue.tmp"), []byte("{"), fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second bytes.Buffer
+	if err := src.ExportZip(&first); err != nil {
+		t.Fatal("Failed to export: ", err.Error())
+	}
+
+	// exports are reproducible
+	if err := src.ExportZip(&second); err != nil {
+		t.Fatal("Failed to export: ", err.Error())
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("Expected identical archives")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(first.Bytes()), int64(first.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[0].Name != "deep/sea/red" || zr.File[1].Name != "fish/red" {
+		t.Error("Unexpected archive entries: ", zr.File)
+	}
+
+	dst := newTestDB(t, nil)
+	if err := dst.ImportZip(&first); err != nil {
+		t.Fatal("Failed to import: ", err.Error())
+	}
+
+	fish := Fish{}
+	if err := dst.Read("deep/sea", "red", &fish); err != nil || fish.Type != "red" {
+		t.Error("Expected imported red fish, got: ", fish.Type, err)
+	}
+}
+
+func TestImportZipOutsideDatabase(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("../escape/red")
+	f.Write([]byte(`{}`))
+	zw.Close()
+
+	d := newTestDB(t, nil)
+	if err := d.ImportZip(&buf); err == nil {
+		t.Error("Expected error importing entry outside the database")
+	}
+
+	// only records are imported, never jsondb's own files
+	for _, name := range []string{"fish/" + configName, "fish/" + schemaName, "fish/" + uniqueName, ".objects/red.json", "fish/red.json.tmp"} {
+		buf.Reset()
+		zw := zip.NewWriter(&buf)
+		f, _ := zw.Create(name)
+		f.Write([]byte(`{}`))
+		zw.Close()
+
+		if err := d.ImportZip(&buf); err == nil {
+			t.Errorf("Expected error importing %s", name)
+		}
+	}
+
+	if entries, err := os.ReadDir(d.dir); err == nil && len(entries) > 0 {
+		t.Error("Expected nothing imported, got: ", entries)
+	}
+}